@@ -14,6 +14,9 @@ import (
 	modcmd "github.com/MontFerret/cli/v2/cmd/internal/mod"
 	replcmd "github.com/MontFerret/cli/v2/cmd/internal/repl"
 	runcmd "github.com/MontFerret/cli/v2/cmd/internal/run"
+	servecmd "github.com/MontFerret/cli/v2/cmd/internal/serve"
+	testcmd "github.com/MontFerret/cli/v2/cmd/internal/test"
+	tracecmd "github.com/MontFerret/cli/v2/cmd/internal/trace"
 	updatecmd "github.com/MontFerret/cli/v2/cmd/internal/update"
 	versioncmd "github.com/MontFerret/cli/v2/cmd/internal/version"
 	"github.com/MontFerret/cli/v2/pkg/config"
@@ -77,6 +80,21 @@ func RunCommand(store *config.Store) *cobra.Command {
 	return runcmd.New(store)
 }
 
+// ServeCommand creates the remote runtime server command.
+func ServeCommand(store *config.Store) *cobra.Command {
+	return servecmd.New(store)
+}
+
+// TestCommand creates the FQL snapshot testing command.
+func TestCommand(store *config.Store) *cobra.Command {
+	return testcmd.New(store)
+}
+
+// TraceCommand creates the execution trace command group.
+func TraceCommand(store *config.Store) *cobra.Command {
+	return tracecmd.New(store)
+}
+
 // SelfUpdateCommand creates the CLI self-update command group.
 func SelfUpdateCommand(store *config.Store) *cobra.Command {
 	return updatecmd.New(store)
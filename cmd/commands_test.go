@@ -19,15 +19,18 @@ func TestCommandFacadePreservesCommandMetadata(t *testing.T) {
 	}{
 		{name: "browser", use: "browser", subcommands: []string{"close", "open"}},
 		{name: "build", use: "build [files...]"},
-		{name: "check", use: "check [files...]"},
-		{name: "config", use: "config", subcommands: []string{"get", "list", "set", "unset"}},
-		{name: "debug", use: "debug <script.fql>"},
+		{name: "check", use: "check [files|dirs...]"},
+		{name: "config", use: "config", subcommands: []string{"get", "list", "profiles", "set", "unset"}},
+		{name: "debug", use: "debug <script.fql|script.fqlc>"},
 		{name: "format", use: "fmt [files...]"},
 		{name: "inspect", use: "inspect [script]"},
 		{name: "migrate", use: "migrate", subcommands: []string{"check", "run"}},
 		{name: "mod", use: "mod", subcommands: []string{"info", "init", "install", "publish", "search"}},
 		{name: "repl", use: "repl"},
-		{name: "run", use: "run [script]", aliases: []string{"exec"}},
+		{name: "run", use: "run [script|dir...]", aliases: []string{"exec"}},
+		{name: "serve", use: "serve"},
+		{name: "test", use: "test [files|dirs...]"},
+		{name: "trace", use: "trace", subcommands: []string{"view"}},
 		{name: "update", use: "update", subcommands: []string{"self"}},
 		{name: "version", use: "version"},
 	}
@@ -43,6 +46,9 @@ func TestCommandFacadePreservesCommandMetadata(t *testing.T) {
 		"mod":     commandMetadataFrom(ModCommand(store, new(facadeModuleService))),
 		"repl":    commandMetadataFrom(ReplCommand(store)),
 		"run":     commandMetadataFrom(RunCommand(store)),
+		"serve":   commandMetadataFrom(ServeCommand(store)),
+		"test":    commandMetadataFrom(TestCommand(store)),
+		"trace":   commandMetadataFrom(TraceCommand(store)),
 		"update":  commandMetadataFrom(SelfUpdateCommand(store)),
 		"version": commandMetadataFrom(VersionCommand(store)),
 	}
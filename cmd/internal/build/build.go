@@ -29,16 +29,23 @@ func New(store *config.Store) *cobra.Command {
 				return err
 			}
 
-			return runBuild(args, output)
+			debugInfo, err := cmd.Flags().GetBool("debug-info")
+
+			if err != nil {
+				return err
+			}
+
+			return runBuild(args, output, clibuild.ArtifactOptions{StripDebugInfo: !debugInfo})
 		},
 	}
 
 	cmd.Flags().StringP("output", "o", "", "Output path: file (for single input) or directory (for single or multiple inputs)")
+	cmd.Flags().Bool("debug-info", true, "Keep source text and spans in artifacts so they can be debugged with ferret debug; --debug-info=false strips them")
 
 	return cmd
 }
 
-func runBuild(args []string, output string) error {
+func runBuild(args []string, output string, opts clibuild.ArtifactOptions) error {
 	plan, err := clibuild.PlanOutputs(args, output)
 
 	if err != nil {
@@ -61,7 +68,7 @@ func runBuild(args []string, output string) error {
 	failed := 0
 
 	for i, src := range sources {
-		if err := clibuild.WriteArtifact(c, src, plan.Targets[i].OutputPath); err != nil {
+		if err := clibuild.WriteArtifactWithOptions(c, src, plan.Targets[i].OutputPath, opts); err != nil {
 			diagnostics.PrintError(err)
 			failed++
 		}
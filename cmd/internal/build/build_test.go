@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/MontFerret/cli/v2/cmd/internal/testutil"
+	clibuild "github.com/MontFerret/cli/v2/pkg/build"
 )
 
 func TestRunBuild_MixedMultiFileBuildContinues(t *testing.T) {
@@ -18,7 +19,7 @@ func TestRunBuild_MixedMultiFileBuildContinues(t *testing.T) {
 	testutil.WriteQuery(t, invalid, "FOR item IN")
 
 	_, err := testutil.CaptureStderr(t, func() error {
-		return runBuild([]string{valid, invalid}, outputDir)
+		return runBuild([]string{valid, invalid}, outputDir, clibuild.ArtifactOptions{})
 	})
 
 	if err == nil {
@@ -41,7 +42,7 @@ func TestRunBuild_PlanErrorReturned(t *testing.T) {
 	testutil.WriteQuery(t, output, "not a directory")
 
 	_, err := testutil.CaptureStderr(t, func() error {
-		return runBuild([]string{inputA, inputB}, output)
+		return runBuild([]string{inputA, inputB}, output, clibuild.ArtifactOptions{})
 	})
 
 	if err == nil {
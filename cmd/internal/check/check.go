@@ -1,55 +1,132 @@
 package check
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/MontFerret/ferret/v2/pkg/compiler"
+	fsource "github.com/MontFerret/ferret/v2/pkg/source"
 
 	"github.com/MontFerret/cli/v2/cmd/internal/diagnostics"
 	"github.com/MontFerret/cli/v2/pkg/config"
 	"github.com/MontFerret/cli/v2/pkg/source"
+	"github.com/MontFerret/cli/v2/pkg/watch"
 )
 
+const watchFlag = "watch"
+
 func New(store *config.Store) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "check [files...]",
+		Use:   "check [files|dirs...]",
 		Short: "Check FQL scripts for syntax and semantic errors",
-		Args:  cobra.MinimumNArgs(0),
+		Long: `Check FQL scripts for syntax and semantic errors. Directories are searched
+for .fql files.
+
+With --watch, check runs again every time a checked file or a file in a
+checked directory changes, until interrupted.`,
+		Args: cobra.MinimumNArgs(0),
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			store.BindFlags(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			sources, err := source.Resolve(source.Input{Args: args})
+			watching, err := cmd.Flags().GetBool(watchFlag)
 
 			if err != nil {
 				return err
 			}
 
-			if sources == nil {
-				return cmd.Help()
+			if watching {
+				return checkWatch(cmd.Context(), args)
 			}
 
-			c := compiler.New()
-			failed := 0
-
-			for _, src := range sources {
-				_, err := c.Compile(src)
+			sources, err := source.Resolve(source.Input{Args: args, Recursive: true})
 
-				if err != nil {
-					diagnostics.PrintError(err)
-					failed++
-				}
+			if err != nil {
+				return err
 			}
 
-			if failed > 0 {
-				return fmt.Errorf("%d of %d scripts have errors", failed, len(sources))
+			if sources == nil {
+				return cmd.Help()
 			}
 
-			return nil
+			return checkAll(sources)
 		},
 	}
 
+	cmd.Flags().Bool(watchFlag, false, "Check again whenever a checked file changes")
+
 	return cmd
 }
+
+func checkAll(sources []*fsource.Source) error {
+	c := compiler.New()
+	failed := 0
+
+	for _, src := range sources {
+		_, err := c.Compile(src)
+
+		if err != nil {
+			diagnostics.PrintError(err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scripts have errors", failed, len(sources))
+	}
+
+	return nil
+}
+
+// checkWatch checks args, then checks them again on every change. Files
+// added to a watched directory are picked up by the next check.
+func checkWatch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("--%s needs files or directories to watch", watchFlag)
+	}
+
+	watcher, err := watch.New(args)
+
+	if err != nil {
+		return err
+	}
+
+	defer watcher.Close()
+
+	for {
+		started := time.Now()
+		sources, err := source.Resolve(source.Input{Args: args, Recursive: true})
+
+		if err == nil {
+			err = checkAll(sources)
+		}
+
+		elapsed := time.Since(started).Round(time.Millisecond)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s (%s)\n", err, elapsed)
+		} else {
+			fmt.Fprintf(os.Stderr, "%d scripts OK (%s)\n", len(sources), elapsed)
+		}
+
+		fmt.Fprintf(os.Stderr, "Watching %s for changes (Ctrl-C to stop)\n", strings.Join(args, ", "))
+
+		changed, err := watcher.Wait(ctx)
+
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "\nChanged: %s\n", strings.Join(changed, ", "))
+	}
+}
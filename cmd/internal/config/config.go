@@ -61,6 +61,10 @@ func New(store *cliconfig.Store) *cobra.Command {
 				return err
 			}
 
+			if err := validateLimitConfigSet(args[0], args[1]); err != nil {
+				return err
+			}
+
 			err := store.Set(args[0], args[1])
 
 			if err == cliconfig.ErrInvalidFlag {
@@ -104,5 +108,25 @@ func New(store *cliconfig.Store) *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "profiles",
+		Short: "List the config profiles, marking the active one",
+		Args:  cobra.MaximumNArgs(0),
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			store.BindFlags(cmd)
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			for _, name := range store.Profiles() {
+				marker := " "
+
+				if name == store.Profile() {
+					marker = "*"
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", marker, name)
+			}
+		},
+	})
+
 	return cmd
 }
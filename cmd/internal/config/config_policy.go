@@ -77,6 +77,27 @@ func validatePolicyConfigSet(store *cliconfig.Store, key, value string) error {
 	return nil
 }
 
+// validateLimitConfigSet rejects execution limits run would refuse. Each
+// limit stands alone, so only the new value is checked.
+func validateLimitConfigSet(key, value string) error {
+	if key != cliconfig.LimitTimeout && key != cliconfig.LimitMaxOutputSize && key != cliconfig.LimitMaxMemory {
+		return nil
+	}
+
+	command := &cobra.Command{Use: "config-limit-validation"}
+	execution.AddLimitFlags(command)
+
+	if err := command.Flags().Set(key, value); err != nil {
+		return fmt.Errorf("invalid limit configuration for %q: %w", key, err)
+	}
+
+	if _, err := execution.LimitsFromCommand(command); err != nil {
+		return fmt.Errorf("invalid limit configuration for %q: %w", key, err)
+	}
+
+	return nil
+}
+
 func persistedPolicyValue(store *cliconfig.Store, policyKey, candidateKey, candidateValue string) (any, error) {
 	if policyKey == candidateKey {
 		return candidateValue, nil
@@ -165,6 +165,28 @@ func TestConfigCommandRejectsInvalidPolicyValuesWithoutWriting(t *testing.T) {
 	}
 }
 
+func TestConfigCommandValidatesLimits(t *testing.T) {
+	store := newConfigCommandTestStore(t, t.TempDir())
+
+	for _, args := range [][]string{
+		{"set", config.LimitTimeout, "later"},
+		{"set", config.LimitMaxOutputSize, "--", "-1"},
+		{"set", config.LimitMaxMemory, "512MB"},
+	} {
+		if _, err := executeConfigCommand(store, args...); err == nil || !strings.Contains(err.Error(), "invalid limit configuration") {
+			t.Fatalf("expected limit validation error for %v, got %v", args, err)
+		}
+	}
+
+	if _, err := executeConfigCommand(store, "set", config.LimitTimeout, "5m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, err := store.Get(config.LimitTimeout); err != nil || got != "5m" {
+		t.Fatalf("expected timeout to be stored, got %v, %v", got, err)
+	}
+}
+
 func TestConfigCommandRejectsPolicyConflictsWithoutWriting(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -315,8 +337,53 @@ func TestConfigCommandSetAndUnsetRequireExactArguments(t *testing.T) {
 	}
 }
 
+func TestConfigCommandWritesToSelectedProfile(t *testing.T) {
+	home := t.TempDir()
+	store := newConfigCommandTestStore(t, home)
+
+	if _, err := executeConfigCommand(store, "set", config.ExecRuntime, "builtin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeConfigCommand(store, "set", "--profile", "ci", config.PolicyFSReadOnly, "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := string(readConfigCommandTestFile(t, home))
+	if !strings.Contains(contents, "profiles:") || !strings.Contains(contents, "ci:") {
+		t.Fatalf("expected the ci profile in the config file:\n%s", contents)
+	}
+
+	out, err := executeConfigCommand(store, "get", "--profile", "ci", config.ExecRuntime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "builtin" {
+		t.Fatalf("expected the profile to inherit the base runtime, got %q", out)
+	}
+
+	out, err = executeConfigCommand(store, "profiles", "--profile", "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "* ci" {
+		t.Fatalf("unexpected profiles output: %q", out)
+	}
+
+	homedir.Reset()
+	store = newConfigCommandTestStore(t, home)
+
+	out, err = executeConfigCommand(store, "get", config.PolicyFSReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "<nil>" {
+		t.Fatalf("expected the base config to be unchanged, got %q", out)
+	}
+}
+
 func executeConfigCommand(store *config.Store, args ...string) (string, error) {
 	command := New(store)
+	config.AddProfileFlag(command.PersistentFlags())
 	out := new(bytes.Buffer)
 	command.SetOut(out)
 	command.SetErr(out)
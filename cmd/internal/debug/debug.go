@@ -1,81 +1,245 @@
 package debug
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
 	"github.com/MontFerret/cli/v2/cmd/internal/diagnostics"
 	"github.com/MontFerret/cli/v2/cmd/internal/execution"
 	"github.com/MontFerret/cli/v2/pkg/browser"
+	clibuild "github.com/MontFerret/cli/v2/pkg/build"
 	"github.com/MontFerret/cli/v2/pkg/config"
 	"github.com/MontFerret/cli/v2/pkg/debugger"
+	"github.com/MontFerret/cli/v2/pkg/debugger/dap"
 	clirun "github.com/MontFerret/cli/v2/pkg/run"
 	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
 )
 
+const (
+	dapFlag      = "dap"
+	listenFlag   = "listen"
+	commandsFlag = "commands"
+	formatFlag   = "format"
+	breakFlag    = "break"
+	snapshotFlag = "snapshots"
+
+	historyFile = "debug_history"
+)
+
+// adapterOptions selects how the debug session is exposed to the user.
+type adapterOptions struct {
+	DAP      bool
+	Listen   string
+	Commands string
+	Format   debugger.Format
+	// History is the file interactive prompt commands are saved to. Empty
+	// disables persistent history.
+	History string
+	// Breakpoints are set before the program starts.
+	Breakpoints []debugger.Command
+	// Snapshots is the number of pauses the prompt keeps for back and
+	// reverse-continue.
+	Snapshots int
+	// StrictParams fails the session when a supplied param is not referenced.
+	StrictParams bool
+}
+
 func New(store *config.Store) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "debug <script.fql>",
+		Use:   "debug <script.fql|script.fqlc>",
 		Short: "Debug a FQL script interactively",
 		Long: `Debug a local FQL source script using the interactive Ferret debugger.
 
-Prompt commands: help, break, delete, breakpoints, continue, step, next, out,
-pause, where, locals, print, and quit.
+Compiled artifacts can be debugged unless they were built with
+"ferret build --debug-info=false"; breakpoints and stack traces resolve to the
+original FQL source embedded in the artifact.
+
+Prompt commands: help, break, logpoint, delete, breakpoints, continue, step,
+next, out, pause, where, up, down, frame, list, locals, print, watch, unwatch,
+break-on-change, back, reverse-continue, history, and quit. Breakpoints accept "hits=<n>" and "if <condition>"
+after the location. Locals and print apply to the frame selected with up, down,
+or frame. Prompt history is kept in the Ferret config directory, and Tab
+completes command and local variable names.
+
+The prompt keeps a snapshot of the stack and locals at each of the last
+--snapshots pauses. back and reverse-continue move to an earlier pause without
+re-running the script; step, next, out, and continue then move forward through
+the snapshots until the prompt is back at the present.
+
+--break sets a breakpoint before the program starts and takes the arguments of
+the break command, e.g. --break 12 or --break "lib.fql:40 if x > 1". The
+interactive prompt also loads breakpoints from .ferret-breakpoints in the
+current directory and saves them back on quit when they changed.
+
+With --commands the prompt commands are read from a script file ("-" for stdin)
+and the debugger exits when the script ends, failing if any command reported
+an error. Add --format json to emit one JSON object per pause, frame list,
+locals dump, and evaluation.
 
-Debugging currently requires the builtin runtime and does not support compiled
-artifacts, stdin, inline evaluation, remote runtimes, or conditional breakpoints.`,
+With --dap the session speaks the Debug Adapter Protocol on stdin/stdout so
+editors such as VS Code can drive it. Add --listen to accept a single DAP
+client over TCP instead.
+
+With --runtime set to a worker URL the session runs on the worker, which must
+expose the /debug/sessions protocol. Debugging does not support stdin or inline
+evaluation.`,
 		Args: cobra.ExactArgs(1),
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			store.BindFlags(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			paramFlags, err := cmd.Flags().GetStringArray(execution.ParamFlag)
+			params, err := execution.ParamsFromCommand(cmd)
 			if err != nil {
 				return err
 			}
 
-			params, err := execution.ParseParams(paramFlags)
+			store := config.From(cmd.Context())
+			rtOpts, err := execution.OptionsFromCommand(cmd, store)
 			if err != nil {
 				return err
 			}
 
-			store := config.From(cmd.Context())
-			rtOpts, err := execution.OptionsFromCommand(cmd, store)
+			adapter, err := adapterOptionsFromCommand(cmd)
 			if err != nil {
 				return err
 			}
 
-			return execute(cmd, rtOpts, store.GetBrowserOptions(), params, args)
+			if dir := store.Dir(); dir != "" {
+				adapter.History = filepath.Join(dir, historyFile)
+			}
+
+			return execute(cmd, rtOpts, store.GetBrowserOptions(), params, adapter, args)
 		},
 	}
 
 	execution.AddParamFlags(cmd)
+	execution.AddStrictParamsFlag(cmd)
 	execution.AddRuntimeFlags(cmd)
+	cmd.Flags().Bool(dapFlag, false, "Serve the session over the Debug Adapter Protocol on stdin/stdout")
+	cmd.Flags().String(listenFlag, "", "Serve the DAP session on a TCP address instead of stdin/stdout, e.g. 127.0.0.1:4711 (implies --dap)")
+	cmd.Flags().String(commandsFlag, "", `Run prompt commands from a script file ("-" for stdin) and exit`)
+	cmd.Flags().String(formatFlag, string(debugger.FormatText), "Debugger output format: text or json")
+	cmd.Flags().StringArray(breakFlag, nil, `Set a breakpoint before the program starts, using the break command syntax, e.g. "lib.fql:40 if x > 1"`)
+	cmd.Flags().Int(snapshotFlag, debugger.DefaultSnapshots, "Number of pauses kept for back and reverse-continue (0 disables time travel)")
 
 	return cmd
 }
 
-func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]any, args []string) error {
+func adapterOptionsFromCommand(cmd *cobra.Command) (adapterOptions, error) {
+	enabled, err := cmd.Flags().GetBool(dapFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	listen, err := cmd.Flags().GetString(listenFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	commands, err := cmd.Flags().GetString(commandsFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	formatValue, err := cmd.Flags().GetString(formatFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	format, err := debugger.ParseFormat(formatValue)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	breakFlags, err := cmd.Flags().GetStringArray(breakFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	var breakpoints []debugger.Command
+
+	for _, value := range breakFlags {
+		breakpoint, err := debugger.ParseBreakpointFlag(value)
+		if err != nil {
+			return adapterOptions{}, fmt.Errorf("--%s %q: %w", breakFlag, value, err)
+		}
+
+		breakpoints = append(breakpoints, breakpoint)
+	}
+
+	snapshots, err := cmd.Flags().GetInt(snapshotFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	if snapshots < 0 {
+		return adapterOptions{}, fmt.Errorf("--%s cannot be negative", snapshotFlag)
+	}
+
+	strictParams, err := cmd.Flags().GetBool(execution.StrictParamsFlag)
+	if err != nil {
+		return adapterOptions{}, err
+	}
+
+	opts := adapterOptions{
+		DAP:          enabled || listen != "",
+		Listen:       listen,
+		Commands:     commands,
+		Format:       format,
+		Breakpoints:  breakpoints,
+		Snapshots:    snapshots,
+		StrictParams: strictParams,
+	}
+
+	if opts.DAP && opts.Commands != "" {
+		return adapterOptions{}, fmt.Errorf("--%s cannot be combined with --%s", commandsFlag, dapFlag)
+	}
+
+	if opts.DAP && len(opts.Breakpoints) > 0 {
+		return adapterOptions{}, fmt.Errorf("--%s cannot be combined with --%s; set breakpoints from the editor", breakFlag, dapFlag)
+	}
+
+	if opts.Format == debugger.FormatJSON && opts.Commands == "" {
+		return adapterOptions{}, fmt.Errorf("--%s json requires --%s", formatFlag, commandsFlag)
+	}
+
+	return opts, nil
+}
+
+func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]any, adapter adapterOptions, args []string) error {
 	input, err := clirun.ResolveInput("", args)
 	if err != nil {
 		return err
 	}
 
 	if input != nil && len(input.Artifact) > 0 {
-		return fmt.Errorf("debugging compiled artifacts is not supported yet; run debug with the original .fql source file")
+		src, err := clibuild.DebugSource(input.Artifact)
+		if err != nil {
+			return fmt.Errorf("debug %s: %w", args[0], err)
+		}
+
+		input = &clirun.Input{Name: input.Name, Source: src}
 	}
 
 	if input == nil || input.Source == nil {
 		return fmt.Errorf("debug requires a source script file")
 	}
 
-	if err := cliruntime.ValidateOptions(rtOpts); err != nil {
+	params = execution.WithDefaultParams(cmd.Context(), []*clirun.Input{input}, params)
+
+	if err := execution.ValidateParams(os.Stderr, []*clirun.Input{input}, params, adapter.StrictParams); err != nil {
 		return err
 	}
 
-	if !cliruntime.IsBuiltinType(rtOpts.Type) {
-		return cliruntime.ErrDebugRequiresBuiltinRuntime
+	if err := cliruntime.ValidateOptions(rtOpts); err != nil {
+		return err
 	}
 
 	cleanup, err := browser.EnsureBrowser(cmd.Context(), rtOpts, brOpts)
@@ -84,11 +248,46 @@ func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Optio
 	}
 	defer cleanup()
 
-	session, err := cliruntime.NewDebugSession(cmd.Context(), rtOpts, params, input.Source)
+	session, err := execution.NewDebugSession(cmd.Context(), rtOpts, params, input.Source)
 	if err != nil {
 		diagnostics.PrintError(err)
 		return err
 	}
 
-	return debugger.Start(cmd.Context(), session, input.Source)
+	if adapter.Commands != "" {
+		return runScript(cmd, session, input.Source, adapter)
+	}
+
+	if !adapter.DAP {
+		return debugger.Start(cmd.Context(), session, input.Source, debugger.Options{
+			HistoryFile:    adapter.History,
+			Breakpoints:    adapter.Breakpoints,
+			BreakpointFile: debugger.BreakpointFileName,
+			Snapshots:      adapter.Snapshots,
+		})
+	}
+
+	if adapter.Listen != "" {
+		return dap.ListenAndServe(cmd.Context(), adapter.Listen, session, input.Source, func(addr net.Addr) {
+			fmt.Fprintf(os.Stderr, "DAP server listening on %s\n", addr)
+		})
+	}
+
+	return dap.Serve(cmd.Context(), session, input.Source, os.Stdin, os.Stdout)
+}
+
+func runScript(cmd *cobra.Command, session debugger.Session, src *source.Source, adapter adapterOptions) error {
+	opts := debugger.Options{Format: adapter.Format, Breakpoints: adapter.Breakpoints, Snapshots: adapter.Snapshots}
+
+	if adapter.Commands == "-" {
+		return debugger.Script(cmd.Context(), session, src, os.Stdin, os.Stdout, opts)
+	}
+
+	file, err := os.Open(adapter.Commands)
+	if err != nil {
+		return errors.Join(fmt.Errorf("open debugger commands: %w", err), session.Close())
+	}
+	defer file.Close()
+
+	return debugger.Script(cmd.Context(), session, src, file, os.Stdout, opts)
 }
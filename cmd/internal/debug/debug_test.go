@@ -3,17 +3,23 @@ package debug
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/MontFerret/cli/v2/cmd/internal/execution"
 	"github.com/MontFerret/cli/v2/cmd/internal/testutil"
+	"github.com/MontFerret/ferret/v2"
 	"github.com/MontFerret/ferret/v2/pkg/compiler"
 	"github.com/MontFerret/ferret/v2/pkg/source"
 
 	"github.com/MontFerret/cli/v2/pkg/browser"
 	"github.com/MontFerret/cli/v2/pkg/build"
 	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/debugger"
 	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
 )
 
@@ -31,31 +37,32 @@ func TestDebugCommandRequiresExactlyOneScript(t *testing.T) {
 	}
 }
 
-func TestExecuteDebugRejectsRemoteRuntimeBeforeStarting(t *testing.T) {
+func TestExecuteDebugRejectsRemoteRuntimeWithoutDebugProtocol(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
 	path := filepath.Join(t.TempDir(), "query.fql")
 	testutil.WriteQuery(t, path, "RETURN 1")
 
 	err := execute(
 		testutil.NewCommand(),
-		cliruntime.Options{Type: "https://worker.example"},
+		cliruntime.Options{Type: server.URL},
 		browser.Options{},
 		nil,
+		adapterOptions{},
 		[]string{path},
 	)
-	if !errors.Is(err, cliruntime.ErrDebugRequiresBuiltinRuntime) {
-		t.Fatalf("expected builtin runtime error, got %v", err)
-	}
-	if got := err.Error(); got != "debug currently supports only the builtin runtime" {
-		t.Fatalf("unexpected builtin runtime error: %q", got)
+	if !errors.Is(err, cliruntime.ErrRemoteDebugUnsupported) {
+		t.Fatalf("expected remote debug error, got %v", err)
 	}
 }
 
-func TestExecuteDebugRejectsArtifact(t *testing.T) {
+func TestExecuteDebugRejectsArtifactWithoutDebugInfo(t *testing.T) {
 	dir := t.TempDir()
 	sourcePath := filepath.Join(dir, "query.fql")
 	artifactPath := filepath.Join(dir, "query.fqlc")
 	testutil.WriteQuery(t, sourcePath, "RETURN 1")
-	if err := build.WriteArtifact(compiler.New(), source.New(sourcePath, "RETURN 1"), artifactPath); err != nil {
+	if err := build.WriteArtifactWithOptions(compiler.New(), source.New(sourcePath, "RETURN 1"), artifactPath, build.ArtifactOptions{StripDebugInfo: true}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -64,9 +71,10 @@ func TestExecuteDebugRejectsArtifact(t *testing.T) {
 		cliruntime.NewDefaultOptions(),
 		browser.Options{},
 		nil,
+		adapterOptions{},
 		[]string{artifactPath},
 	)
-	if err == nil || err.Error() != "debugging compiled artifacts is not supported yet; run debug with the original .fql source file" {
+	if !errors.Is(err, build.ErrNoDebugInfo) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
@@ -90,3 +98,91 @@ func TestDebugCommandUsesSharedParamFlags(t *testing.T) {
 		t.Fatalf("unexpected params: %#v", params)
 	}
 }
+
+func TestDebugCommandListenImpliesDAP(t *testing.T) {
+	command := New(new(config.Store))
+
+	if err := command.Flags().Set(listenFlag, "127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+
+	adapter, err := adapterOptionsFromCommand(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !adapter.DAP || adapter.Listen != "127.0.0.1:0" {
+		t.Fatalf("unexpected adapter options: %#v", adapter)
+	}
+}
+
+func TestDebugCommandScriptFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  map[string]string
+		want   adapterOptions
+		errHas string
+	}{
+		{
+			name:  "commands with json",
+			flags: map[string]string{commandsFlag: "session.fdb", formatFlag: "json"},
+			want:  adapterOptions{Commands: "session.fdb", Format: debugger.FormatJSON, Snapshots: debugger.DefaultSnapshots},
+		},
+		{
+			name:  "commands default to text",
+			flags: map[string]string{commandsFlag: "-"},
+			want:  adapterOptions{Commands: "-", Format: debugger.FormatText, Snapshots: debugger.DefaultSnapshots},
+		},
+		{name: "json without commands", flags: map[string]string{formatFlag: "json"}, errHas: "--format json requires --commands"},
+		{name: "commands with dap", flags: map[string]string{commandsFlag: "-", dapFlag: "true"}, errHas: "cannot be combined"},
+		{name: "unknown format", flags: map[string]string{formatFlag: "yaml"}, errHas: "unknown debugger format"},
+		{
+			name:  "break",
+			flags: map[string]string{breakFlag: "--exact lib.fql:40 if x > 1"},
+			want: adapterOptions{
+				Format: debugger.FormatText,
+				Breakpoints: []debugger.Command{{
+					Name:              debugger.CommandBreak,
+					Argument:          "--exact lib.fql:40 if x > 1",
+					Location:          ferret.DebugSourceLocation{File: "lib.fql", Line: 40},
+					BreakpointOptions: ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindExact},
+					BreakpointRule:    debugger.BreakpointRule{Condition: "x > 1"},
+				}},
+				Snapshots: debugger.DefaultSnapshots,
+			},
+		},
+		{name: "invalid break", flags: map[string]string{breakFlag: "lib.fql:zero"}, errHas: `--break "lib.fql:zero": usage: break`},
+		{name: "break with dap", flags: map[string]string{breakFlag: "12", dapFlag: "true"}, errHas: "set breakpoints from the editor"},
+		{
+			name:  "snapshots disabled",
+			flags: map[string]string{snapshotFlag: "0"},
+			want:  adapterOptions{Format: debugger.FormatText},
+		},
+		{name: "negative snapshots", flags: map[string]string{snapshotFlag: "-1"}, errHas: "--snapshots cannot be negative"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			command := New(new(config.Store))
+
+			for name, value := range test.flags {
+				if err := command.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			adapter, err := adapterOptionsFromCommand(command)
+			if test.errHas != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errHas) {
+					t.Fatalf("expected error containing %q, got %v", test.errHas, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(adapter, test.want) {
+				t.Fatalf("unexpected adapter options: %#v", adapter)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package execution
+
+import (
+	"context"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/debugger"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+// NewDebugSession opens a debug session in-process for the builtin runtime and
+// on the worker for remote runtimes.
+func NewDebugSession(ctx context.Context, opts cliruntime.Options, params map[string]any, src *source.Source) (debugger.Session, error) {
+	if cliruntime.IsBuiltinType(opts.Type) {
+		return cliruntime.NewDebugSession(ctx, opts, params, src)
+	}
+
+	return cliruntime.NewRemoteDebugSession(ctx, opts, params, src)
+}
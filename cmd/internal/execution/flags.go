@@ -7,7 +7,7 @@ import (
 	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
 )
 
-// AddRuntimeFlags keeps the execution-policy surface identical across run, debug, and repl.
+// AddRuntimeFlags keeps the execution-policy surface identical across run, debug, repl, and test.
 func AddRuntimeFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(config.ExecRuntime, "r", cliruntime.DefaultRuntime, "Ferret runtime type (\"builtin\"|$url)")
 	cmd.Flags().String(config.ExecProxy, "x", "Proxy server address")
@@ -16,13 +16,25 @@ func AddRuntimeFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolP(config.ExecWithBrowser, "B", false, "Open browser for script execution")
 	cmd.Flags().BoolP(config.ExecWithBrowserHeadless, "b", false, "Open browser for script execution in headless mode")
 	cmd.Flags().BoolP(config.ExecKeepCookies, "c", false, "Keep cookies between queries")
+	AddRemoteFlags(cmd)
 	AddFSPolicyFlags(cmd)
 	AddHTTPPolicyFlags(cmd)
+	AddHTTPFixtureFlags(cmd)
+	AddHTMLFixturesFlag(cmd)
 }
 
-// AddParamFlags registers the repeatable JSON-aware runtime parameter flag.
+// AddParamFlags registers the repeatable runtime parameter flags.
 func AddParamFlags(cmd *cobra.Command) {
 	cmd.Flags().StringArrayP(ParamFlag, "p", []string{}, "Runtime parameter as name=value. Values parse as JSON when possible, otherwise strings. Examples: --param name=Steve, --param age=42, --param active=true, --param tags='[\"admin\",\"editor\"]', --param user='{\"name\":\"Ada\"}', --param code='\"123\"'")
+	cmd.Flags().StringArray(ParamsFileFlag, []string{}, "Load runtime parameters from a JSON, YAML, or TOML object file; --param, --param-file, and --param-env override its values")
+	cmd.Flags().StringArray(ParamFileFlag, []string{}, "Runtime parameter as name=@path, set to the file's content as a string")
+	cmd.Flags().StringArray(ParamEnvFlag, []string{}, "Runtime parameter as name=ENV_VAR, set to the environment variable's value as a string")
+}
+
+// AddStrictParamsFlag registers the flag that rejects params a script does not
+// reference.
+func AddStrictParamsFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(StrictParamsFlag, false, "Fail when a supplied parameter is not referenced by the script")
 }
 
 // AddEvalFlag registers inline FQL input for commands that support it.
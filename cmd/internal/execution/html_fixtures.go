@@ -0,0 +1,35 @@
+package execution
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const HTMLFixturesFlag = "html-fixtures"
+
+// AddHTMLFixturesFlag registers the flag that serves pages of the in-memory
+// HTML driver from local files.
+func AddHTMLFixturesFlag(cmd *cobra.Command) {
+	cmd.Flags().String(HTMLFixturesFlag, "", "Open pages of the in-memory HTML driver from local files listed in a mapping file, or in fixtures.yaml in a directory")
+}
+
+// HTMLFixturesFromCommand returns the fixtures path, or empty when none was set.
+func HTMLFixturesFromCommand(cmd *cobra.Command) (string, error) {
+	if cmd == nil || !cmd.Flags().Changed(HTMLFixturesFlag) {
+		return "", nil
+	}
+
+	path, err := cmd.Flags().GetString(HTMLFixturesFlag)
+	if err != nil {
+		return "", err
+	}
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("--%s cannot be empty", HTMLFixturesFlag)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,46 @@
+package execution_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/cmd/internal/execution"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+func TestHTMLFixturesFlagValueReachesRuntimeOptions(t *testing.T) {
+	command := &cobra.Command{Use: "fixtures-test"}
+	execution.AddHTMLFixturesFlag(command)
+	if err := command.Flags().Parse([]string{"--html-fixtures= testdata/pages "}); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := execution.HTMLFixturesFromCommand(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "testdata/pages" {
+		t.Fatalf("unexpected fixtures path: %q", path)
+	}
+
+	if err := command.Flags().Parse([]string{"--html-fixtures= "}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = execution.HTMLFixturesFromCommand(command)
+	if err == nil || err.Error() != "--html-fixtures cannot be empty" {
+		t.Fatalf("expected blank path error, got %v", err)
+	}
+}
+
+func TestHTMLFixturesRequireBuiltinRuntime(t *testing.T) {
+	opts := cliruntime.NewDefaultOptions()
+	opts.Type = "https://worker.example.com"
+	opts.HTMLFixtures = "testdata/pages"
+
+	if err := cliruntime.ValidateOptions(opts); !errors.Is(err, cliruntime.ErrHTMLFixturesRequireBuiltinRuntime) {
+		t.Fatalf("expected builtin runtime error, got %v", err)
+	}
+}
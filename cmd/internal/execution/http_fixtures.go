@@ -0,0 +1,83 @@
+package execution
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+const (
+	HTTPRecordFlag        = "http-record"
+	HTTPRecordSecretsFlag = "http-record-secrets"
+	HTTPReplayFlag        = "http-replay"
+	HTTPReplayMatchFlag   = "http-replay-match"
+	HTTPReplayStrictFlag  = "http-replay-strict"
+)
+
+// AddHTTPFixtureFlags registers the flags that record or replay the builtin
+// runtime's outbound HTTP.
+func AddHTTPFixtureFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.String(HTTPRecordFlag, "", "Record outbound HTTP requests and responses to a HAR file, or to recording.har in a directory")
+	flags.Bool(HTTPRecordSecretsFlag, false, "Keep Authorization and cookie values in recordings instead of redacting them")
+	flags.String(HTTPReplayFlag, "", "Answer outbound HTTP requests from a HAR file, or from every .har file in a directory")
+	flags.StringSlice(HTTPReplayMatchFlag, nil, "Request parts that must equal the recording when replaying: method, url, path, or body (default method,url)")
+	flags.Bool(HTTPReplayStrictFlag, false, "Fail replayed requests that have no recording instead of sending them to the network")
+}
+
+// HTTPFixturesFromCommand returns nil when neither recording nor replaying
+// was requested.
+func HTTPFixturesFromCommand(cmd *cobra.Command) (*cliruntime.HTTPFixtures, error) {
+	if cmd == nil || cmd.Flags().Lookup(HTTPRecordFlag) == nil {
+		return nil, nil
+	}
+
+	flags := cmd.Flags()
+	fixtures := &cliruntime.HTTPFixtures{}
+	var err error
+
+	if fixtures.Record, err = flags.GetString(HTTPRecordFlag); err != nil {
+		return nil, err
+	}
+
+	if fixtures.KeepSecrets, err = flags.GetBool(HTTPRecordSecretsFlag); err != nil {
+		return nil, err
+	}
+
+	if fixtures.Replay, err = flags.GetString(HTTPReplayFlag); err != nil {
+		return nil, err
+	}
+
+	if fixtures.Match, err = flags.GetStringSlice(HTTPReplayMatchFlag); err != nil {
+		return nil, err
+	}
+
+	if fixtures.Strict, err = flags.GetBool(HTTPReplayStrictFlag); err != nil {
+		return nil, err
+	}
+
+	fixtures.Record = strings.TrimSpace(fixtures.Record)
+	fixtures.Replay = strings.TrimSpace(fixtures.Replay)
+
+	if fixtures.KeepSecrets && fixtures.Record == "" {
+		return nil, fmt.Errorf("--%s requires --%s", HTTPRecordSecretsFlag, HTTPRecordFlag)
+	}
+
+	if fixtures.Record == "" && fixtures.Replay == "" {
+		if len(fixtures.Match) > 0 || fixtures.Strict {
+			return nil, fmt.Errorf("--%s and --%s require --%s", HTTPReplayMatchFlag, HTTPReplayStrictFlag, HTTPReplayFlag)
+		}
+
+		return nil, nil
+	}
+
+	if fixtures.Record != "" && fixtures.Replay != "" {
+		return nil, fmt.Errorf("--%s cannot be combined with --%s", HTTPRecordFlag, HTTPReplayFlag)
+	}
+
+	return fixtures, nil
+}
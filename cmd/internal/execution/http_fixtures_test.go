@@ -0,0 +1,92 @@
+package execution_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/cmd/internal/execution"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+func TestHTTPFixtureFlagDefaultsDoNotCreateFixtures(t *testing.T) {
+	command := &cobra.Command{Use: "fixtures-test"}
+	execution.AddHTTPFixtureFlags(command)
+
+	fixtures, err := execution.HTTPFixturesFromCommand(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixtures != nil {
+		t.Fatalf("expected no HTTP fixtures, got %#v", fixtures)
+	}
+}
+
+func TestHTTPFixtureFlagValuesReachRuntimeOptions(t *testing.T) {
+	command := &cobra.Command{Use: "fixtures-test"}
+	execution.AddHTTPFixtureFlags(command)
+	if err := command.Flags().Parse([]string{"--http-replay", "fixtures/", "--http-replay-match", "method,path", "--http-replay-strict"}); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures, err := execution.HTTPFixturesFromCommand(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &cliruntime.HTTPFixtures{Replay: "fixtures/", Match: []string{"method", "path"}, Strict: true}
+	if !reflect.DeepEqual(fixtures, want) {
+		t.Fatalf("expected %#v, got %#v", want, fixtures)
+	}
+}
+
+func TestHTTPFixtureFlagsRejectInvalidCombinations(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "record and replay",
+			args: []string{"--http-record", "out/", "--http-replay", "in/"},
+			want: "--http-record cannot be combined with --http-replay",
+		},
+		{
+			name: "secrets without record",
+			args: []string{"--http-record-secrets"},
+			want: "--http-record-secrets requires --http-record",
+		},
+		{
+			name: "strict without replay",
+			args: []string{"--http-replay-strict"},
+			want: "--http-replay-match and --http-replay-strict require --http-replay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := &cobra.Command{Use: "fixtures-test"}
+			execution.AddHTTPFixtureFlags(command)
+			if err := command.Flags().Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := execution.HTTPFixturesFromCommand(command)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHTTPFixturesRequireBuiltinRuntime(t *testing.T) {
+	opts := cliruntime.NewDefaultOptions()
+	opts.Type = "https://worker.example.com"
+	opts.HTTPFixtures = &cliruntime.HTTPFixtures{Replay: "fixtures/"}
+
+	if err := cliruntime.ValidateOptions(opts); !errors.Is(err, cliruntime.ErrHTTPFixturesRequireBuiltinRuntime) {
+		t.Fatalf("expected builtin runtime error, got %v", err)
+	}
+}
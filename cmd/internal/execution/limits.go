@@ -0,0 +1,51 @@
+package execution
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/limit"
+)
+
+// AddLimitFlags registers the execution limits. They are off by default.
+func AddLimitFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.Duration(config.LimitTimeout, 0, "Stop a script that runs longer than this, e.g. 30s or 5m (0 disables)")
+	flags.Int64(config.LimitMaxOutputSize, 0, "Maximum result size in bytes (0 disables)")
+	flags.Int64(config.LimitMaxMemory, 0, "Soft ceiling on the heap of the builtin runtime in bytes (0 disables)")
+}
+
+// LimitsFromCommand reads the execution limits, including values from the
+// config file and environment bound to the flags.
+func LimitsFromCommand(cmd *cobra.Command) (limit.Options, error) {
+	flags := cmd.Flags()
+
+	timeout, err := flags.GetDuration(config.LimitTimeout)
+	if err != nil {
+		return limit.Options{}, err
+	}
+
+	maxOutputSize, err := flags.GetInt64(config.LimitMaxOutputSize)
+	if err != nil {
+		return limit.Options{}, err
+	}
+
+	maxMemory, err := flags.GetInt64(config.LimitMaxMemory)
+	if err != nil {
+		return limit.Options{}, err
+	}
+
+	switch {
+	case timeout < 0:
+		return limit.Options{}, fmt.Errorf("--%s cannot be negative", config.LimitTimeout)
+	case maxOutputSize < 0:
+		return limit.Options{}, fmt.Errorf("--%s cannot be negative", config.LimitMaxOutputSize)
+	case maxMemory < 0:
+		return limit.Options{}, fmt.Errorf("--%s cannot be negative", config.LimitMaxMemory)
+	}
+
+	return limit.Options{Timeout: timeout, MaxOutputSize: maxOutputSize, MaxMemory: maxMemory}, nil
+}
@@ -1,14 +1,278 @@
 package execution
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/pkg/config"
+	clirun "github.com/MontFerret/cli/v2/pkg/run"
 )
 
 // ParamFlag is shared by every command that accepts runtime parameters.
 const ParamFlag = "param"
 
+const (
+	// ParamsFileFlag loads a JSON, YAML, or TOML object of parameters.
+	ParamsFileFlag = "params-file"
+	// ParamFileFlag loads a file's content as the string value of a parameter.
+	ParamFileFlag = "param-file"
+	// ParamEnvFlag reads the string value of a parameter from an environment
+	// variable.
+	ParamEnvFlag = "param-env"
+	// StrictParamsFlag turns supplied params that no script references into
+	// an error.
+	StrictParamsFlag = "strict-params"
+)
+
+// ValidateParams fails when inputs reference params that were not supplied,
+// and warns on w about supplied params none of them reference, which is an
+// error instead when strict is set.
+func ValidateParams(w io.Writer, inputs []*clirun.Input, params map[string]interface{}, strict bool) error {
+	check := clirun.CheckParams(inputs, params)
+
+	if err := check.Err(strict); err != nil {
+		return err
+	}
+
+	if len(check.Unused) > 0 {
+		fmt.Fprintf(w, "warning: unused params %s\n", strings.Join(check.Unused, ", "))
+	}
+
+	return nil
+}
+
+// WithDefaultParams returns params with the project config's default params
+// that inputs reference and params do not set. Defaults no input uses are left
+// out, so that they are not reported as unused; when an input's params cannot
+// be determined, or inputs is empty, every default is added.
+func WithDefaultParams(ctx context.Context, inputs []*clirun.Input, params map[string]interface{}) map[string]interface{} {
+	if ctx == nil {
+		return params
+	}
+
+	store := config.From(ctx)
+
+	if store == nil {
+		return params
+	}
+
+	defaults := store.DefaultParams()
+
+	if len(defaults) == 0 {
+		return params
+	}
+
+	referenced := make(map[string]bool)
+	all := len(inputs) == 0
+
+	for _, input := range inputs {
+		names, ok := clirun.ReferencedParams(input)
+
+		if !ok {
+			all = true
+			break
+		}
+
+		for _, name := range names {
+			referenced[name] = true
+		}
+	}
+
+	res := make(map[string]interface{}, len(params)+len(defaults))
+
+	for name, value := range defaults {
+		if all || referenced[name] {
+			res[name] = value
+		}
+	}
+
+	mergeParams(res, params)
+
+	return res
+}
+
+// ParamsFromCommand collects the runtime parameters of every source. Values
+// from --params-file are applied first, in flag order, and --param-file,
+// --param-env, and --param override them.
+func ParamsFromCommand(cmd *cobra.Command) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+
+	paramsFiles, err := cmd.Flags().GetStringArray(ParamsFileFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paramsFiles {
+		params, err := LoadParamsFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		mergeParams(res, params)
+	}
+
+	paramFiles, err := cmd.Flags().GetStringArray(ParamFileFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := ParseParamFiles(paramFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeParams(res, params)
+
+	paramEnvs, err := cmd.Flags().GetStringArray(ParamEnvFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err = ParseParamEnvs(paramEnvs)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeParams(res, params)
+
+	paramFlags, err := cmd.Flags().GetStringArray(ParamFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err = ParseParams(paramFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeParams(res, params)
+
+	return res, nil
+}
+
+// LoadParamsFile reads an object of parameters. The format follows the file
+// extension: .json, .yaml or .yml, or .toml. Values are normalized to the
+// types --param produces, so numbers become float64 and dates strings.
+func LoadParamsFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read params file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+	case ".yaml", ".yml":
+		data, err = yaml.YAMLToJSON(data)
+	case ".toml":
+		var doc map[string]interface{}
+
+		if err = toml.Unmarshal(data, &doc); err == nil {
+			data, err = json.Marshal(doc)
+		}
+	default:
+		return nil, fmt.Errorf("params file %s: unsupported extension %q; expected .json, .yaml, .yml, or .toml", path, ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("params file %s: %w", path, err)
+	}
+
+	var params map[string]interface{}
+
+	if data = bytes.TrimSpace(data); len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return map[string]interface{}{}, nil
+	}
+
+	if data[0] != '{' {
+		return nil, fmt.Errorf("params file %s: expected an object of parameters", path)
+	}
+
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("params file %s: %w", path, err)
+	}
+
+	return params, nil
+}
+
+// ParseParamFiles reads name=@path entries. The file's content is used
+// unchanged as a string, so it is never interpreted as JSON.
+func ParseParamFiles(flags []string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+
+	for _, entry := range flags {
+		name, path, err := splitParam(entry, "name=@path")
+		if err != nil {
+			return nil, err
+		}
+
+		path = strings.TrimPrefix(path, "@")
+		if path == "" {
+			return nil, fmt.Errorf("invalid param file %q: path cannot be empty", entry)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %w", name, err)
+		}
+
+		res[name] = string(content)
+	}
+
+	return res, nil
+}
+
+// ParseParamEnvs reads name=ENV_VAR entries. The variable's value is used
+// unchanged as a string, and an unset variable is an error.
+func ParseParamEnvs(flags []string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+
+	for _, entry := range flags {
+		name, variable, err := splitParam(entry, "name=ENV_VAR")
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := os.LookupEnv(variable)
+		if !ok {
+			return nil, fmt.Errorf("param %s: environment variable %s is not set", name, variable)
+		}
+
+		res[name] = value
+	}
+
+	return res, nil
+}
+
+func splitParam(input, expected string) (string, string, error) {
+	name, value, ok := strings.Cut(input, "=")
+	if !ok {
+		return "", "", fmt.Errorf("invalid param %q: expected %s", input, expected)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", fmt.Errorf("invalid param %q: parameter name cannot be empty", input)
+	}
+
+	return name, strings.TrimSpace(value), nil
+}
+
+func mergeParams(dst, src map[string]interface{}) {
+	for name, value := range src {
+		dst[name] = value
+	}
+}
+
 // ParseParams decodes JSON values when possible and otherwise preserves their raw string form.
 func ParseParams(flags []string) (map[string]interface{}, error) {
 	res := make(map[string]interface{})
@@ -1,9 +1,20 @@
 package execution
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/config"
+	clirun "github.com/MontFerret/cli/v2/pkg/run"
 )
 
 func TestParseParams(t *testing.T) {
@@ -84,3 +95,141 @@ func TestParseParams_InvalidInput(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadParamsFile(t *testing.T) {
+	want := map[string]any{
+		"url":   "https://example.com",
+		"limit": float64(10),
+		"tags":  []any{"news", "tech"},
+		"auth":  map[string]any{"user": "ada"},
+	}
+
+	files := map[string]string{
+		"params.json": `{"url": "https://example.com", "limit": 10, "tags": ["news", "tech"], "auth": {"user": "ada"}}`,
+		"params.yaml": "url: https://example.com\nlimit: 10\ntags: [news, tech]\nauth:\n  user: ada\n",
+		"params.toml": "url = \"https://example.com\"\nlimit = 10\ntags = [\"news\", \"tech\"]\n\n[auth]\nuser = \"ada\"\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeParamsFile(t, name, content)
+
+			params, err := LoadParamsFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(params, want) {
+				t.Fatalf("expected %v, got %v", want, params)
+			}
+		})
+	}
+}
+
+func TestLoadParamsFile_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantError string
+	}{
+		{name: "unsupported extension", file: "params.ini", content: "a=1", wantError: `unsupported extension ".ini"`},
+		{name: "not an object", file: "params.json", content: `[1, 2]`, wantError: "expected an object of parameters"},
+		{name: "invalid yaml", file: "params.yaml", content: "a: [1", wantError: "params file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadParamsFile(writeParamsFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestParamsFromCommand_ExplicitFlagsOverrideFiles(t *testing.T) {
+	paramsFile := writeParamsFile(t, "params.yaml", "url: https://file.example\nlimit: 5\ntoken: from-file\nmode: file\n")
+	tokenFile := writeParamsFile(t, "token.txt", "s3cret\n")
+	t.Setenv("FERRET_TEST_MODE", "42")
+
+	cmd := &cobra.Command{}
+	AddParamFlags(cmd)
+
+	err := cmd.ParseFlags([]string{
+		"--params-file", paramsFile,
+		"--param-file", "token=@" + tokenFile,
+		"--param-env", "mode=FERRET_TEST_MODE",
+		"--param", "limit=10",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params, err := ParamsFromCommand(cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"url":   "https://file.example",
+		"limit": float64(10),
+		"token": "s3cret\n",
+		"mode":  "42",
+	}
+
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("expected %v, got %v", want, params)
+	}
+}
+
+func TestParseParamEnvs_UnsetVariable(t *testing.T) {
+	_, err := ParseParamEnvs([]string{"token=FERRET_TEST_UNSET_VARIABLE"})
+
+	if err == nil || err.Error() != "param token: environment variable FERRET_TEST_UNSET_VARIABLE is not set" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func writeParamsFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	return path
+}
+
+func TestWithDefaultParams_AddsReferencedProjectDefaults(t *testing.T) {
+	project := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	homedir.Reset()
+	t.Cleanup(homedir.Reset)
+
+	if err := os.WriteFile(filepath.Join(project, config.ProjectFile), []byte("params:\n  env: staging\n  region: eu\n  unused: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(project)
+
+	store, err := config.NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := config.With(context.Background(), store)
+	inputs := []*clirun.Input{{Name: "query", Source: source.NewAnonymous("RETURN [@env, @region]")}}
+
+	got := WithDefaultParams(ctx, inputs, map[string]interface{}{"region": "us"})
+	want := map[string]interface{}{"env": "staging", "region": "us"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if got := WithDefaultParams(ctx, nil, nil); len(got) != 3 {
+		t.Fatalf("expected every default without inputs, got %v", got)
+	}
+}
@@ -0,0 +1,24 @@
+package execution
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/pkg/config"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+// AddRemoteFlags registers the connection flags for remote runtimes. The
+// store reads them, so they can also come from the config file or FERRET_*
+// environment variables.
+func AddRemoteFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.String(config.RemoteToken, "", "Bearer token sent to a remote runtime")
+	flags.String(config.RemoteUsername, "", "Basic auth username for a remote runtime")
+	flags.String(config.RemotePassword, "", "Basic auth password for a remote runtime")
+	flags.String(config.RemoteCACert, "", "PEM file of CA certificates trusted for a remote runtime")
+	flags.String(config.RemoteClientCert, "", "PEM client certificate for a remote runtime that requires mutual TLS")
+	flags.String(config.RemoteClientKey, "", "PEM client key for --"+config.RemoteClientCert)
+	flags.Duration(config.RemoteTimeout, 0, "Timeout of each request to a remote runtime, including its result (0 means none)")
+	flags.Int(config.RemoteRetries, cliruntime.DefaultRemoteRetries, "Retries of idempotent requests to a remote runtime after connection errors or 429/502/503/504 answers")
+}
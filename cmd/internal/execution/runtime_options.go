@@ -9,6 +9,12 @@ import (
 
 // OptionsFromCommand overlays explicit command policies onto the store-backed runtime options.
 func OptionsFromCommand(cmd *cobra.Command, store *config.Store) (cliruntime.Options, error) {
+	if err := store.ValidateProfile(); err != nil {
+		return cliruntime.Options{}, err
+	}
+
+	store.ReportProject(cmd.ErrOrStderr())
+
 	opts := store.GetRuntimeOptions()
 
 	httpPolicy, err := HTTPPolicyOptionsFromCommand(cmd)
@@ -23,5 +29,17 @@ func OptionsFromCommand(cmd *cobra.Command, store *config.Store) (cliruntime.Opt
 	}
 	opts.FSPolicy = fsPolicy
 
+	httpFixtures, err := HTTPFixturesFromCommand(cmd)
+	if err != nil {
+		return cliruntime.Options{}, err
+	}
+	opts.HTTPFixtures = httpFixtures
+
+	htmlFixtures, err := HTMLFixturesFromCommand(cmd)
+	if err != nil {
+		return cliruntime.Options{}, err
+	}
+	opts.HTMLFixtures = htmlFixtures
+
 	return opts, nil
 }
@@ -78,11 +78,11 @@ func New(store *config.Store) *cobra.Command {
 	}
 
 	cmd.Flags().Bool("dry-run", false, "Do not overwrite files and print the output to stdout")
-	cmd.Flags().Uint64("print-width", 80, "Maximum line length")
-	cmd.Flags().Uint64("tab-width", 4, "Indentation size")
-	cmd.Flags().Bool("single-quote", false, "Use single quotes instead of double quotes")
-	cmd.Flags().Bool("bracket-spacing", true, "Add spaces inside brackets")
-	cmd.Flags().String("case-mode", "lower", "Keyword case mode: lower (default), upper, or ignore")
+	cmd.Flags().Uint64(config.FormatPrintWidth, 80, "Maximum line length")
+	cmd.Flags().Uint64(config.FormatTabWidth, 4, "Indentation size")
+	cmd.Flags().Bool(config.FormatSingleQuote, false, "Use single quotes instead of double quotes")
+	cmd.Flags().Bool(config.FormatBracketSpacing, true, "Add spaces inside brackets")
+	cmd.Flags().String(config.FormatCaseMode, "lower", "Keyword case mode: lower (default), upper, or ignore")
 
 	return cmd
 }
@@ -90,8 +90,8 @@ func New(store *config.Store) *cobra.Command {
 func buildFormatterOptions(cmd *cobra.Command) ([]formatter.Option, error) {
 	var opts []formatter.Option
 
-	if cmd.Flags().Changed("print-width") {
-		v, err := cmd.Flags().GetUint64("print-width")
+	if cmd.Flags().Changed(config.FormatPrintWidth) {
+		v, err := cmd.Flags().GetUint64(config.FormatPrintWidth)
 
 		if err != nil {
 			return nil, err
@@ -100,8 +100,8 @@ func buildFormatterOptions(cmd *cobra.Command) ([]formatter.Option, error) {
 		opts = append(opts, formatter.WithPrintWidth(v))
 	}
 
-	if cmd.Flags().Changed("tab-width") {
-		v, err := cmd.Flags().GetUint64("tab-width")
+	if cmd.Flags().Changed(config.FormatTabWidth) {
+		v, err := cmd.Flags().GetUint64(config.FormatTabWidth)
 
 		if err != nil {
 			return nil, err
@@ -110,8 +110,8 @@ func buildFormatterOptions(cmd *cobra.Command) ([]formatter.Option, error) {
 		opts = append(opts, formatter.WithTabWidth(v))
 	}
 
-	if cmd.Flags().Changed("single-quote") {
-		v, err := cmd.Flags().GetBool("single-quote")
+	if cmd.Flags().Changed(config.FormatSingleQuote) {
+		v, err := cmd.Flags().GetBool(config.FormatSingleQuote)
 
 		if err != nil {
 			return nil, err
@@ -120,8 +120,8 @@ func buildFormatterOptions(cmd *cobra.Command) ([]formatter.Option, error) {
 		opts = append(opts, formatter.WithSingleQuote(v))
 	}
 
-	if cmd.Flags().Changed("bracket-spacing") {
-		v, err := cmd.Flags().GetBool("bracket-spacing")
+	if cmd.Flags().Changed(config.FormatBracketSpacing) {
+		v, err := cmd.Flags().GetBool(config.FormatBracketSpacing)
 
 		if err != nil {
 			return nil, err
@@ -130,7 +130,7 @@ func buildFormatterOptions(cmd *cobra.Command) ([]formatter.Option, error) {
 		opts = append(opts, formatter.WithBracketSpacing(v))
 	}
 
-	v, err := cmd.Flags().GetString("case-mode")
+	v, err := cmd.Flags().GetString(config.FormatCaseMode)
 
 	if err != nil {
 		return nil, err
@@ -18,13 +18,13 @@ func New(store *config.Store) *cobra.Command {
 			store.BindFlags(cmd)
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			paramFlag, err := cmd.Flags().GetStringArray(execution.ParamFlag)
+			params, err := execution.ParamsFromCommand(cmd)
 
 			if err != nil {
 				return err
 			}
 
-			params, err := execution.ParseParams(paramFlag)
+			limits, err := execution.LimitsFromCommand(cmd)
 
 			if err != nil {
 				return err
@@ -44,12 +44,15 @@ func New(store *config.Store) *cobra.Command {
 
 			defer cleanup()
 
-			return clirepl.Start(cmd.Context(), rtOpts, params)
+			params = execution.WithDefaultParams(cmd.Context(), nil, params)
+
+			return clirepl.Start(cmd.Context(), rtOpts, params, limits)
 		},
 	}
 
 	execution.AddParamFlags(cmd)
 	execution.AddRuntimeFlags(cmd)
+	execution.AddLimitFlags(cmd)
 
 	return cmd
 }
@@ -0,0 +1,252 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/cmd/internal/diagnostics"
+	"github.com/MontFerret/cli/v2/cmd/internal/execution"
+	"github.com/MontFerret/cli/v2/pkg/browser"
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	"github.com/MontFerret/cli/v2/pkg/output"
+	clirun "github.com/MontFerret/cli/v2/pkg/run"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+// batchFormat is how the results of several scripts are written.
+type batchFormat string
+
+const (
+	// batchSections writes each result in --output-format under a
+	// "==> script <==" header.
+	batchSections batchFormat = "sections"
+	// batchRecords writes one batchRecord per script as NDJSON.
+	batchRecords batchFormat = "records"
+)
+
+func parseBatchFormat(value string) (batchFormat, error) {
+	switch format := batchFormat(value); format {
+	case batchSections, batchRecords:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown batch format %q: expected sections or records", value)
+	}
+}
+
+// batchRecord is the NDJSON line written for each script of a batch.
+type batchRecord struct {
+	Script     string          `json:"script"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+}
+
+// isBatch reports whether args name more than one script, either as several
+// paths or as a directory.
+func isBatch(args []string) bool {
+	if len(args) > 1 {
+		return true
+	}
+
+	if len(args) == 0 {
+		return false
+	}
+
+	info, err := os.Stat(args[0])
+
+	return err == nil && info.IsDir()
+}
+
+// executeBatch runs several scripts through one runtime and one browser.
+// Results are written in argument order; a summary goes to stderr, and the
+// command fails when any script failed.
+func executeBatch(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]any, opts runOptions, args []string) error {
+	if opts.Trace != "" {
+		return fmt.Errorf("--%s records a single script; it cannot be used with several", traceFlag)
+	}
+
+	if opts.Output != "" {
+		return fmt.Errorf("--%s writes a single result; it cannot be used with several scripts", outputFlag)
+	}
+
+	if opts.BatchFormat == batchRecords && opts.Format != output.FormatJSON && opts.Format != "" {
+		return fmt.Errorf("--%s %s embeds each result as JSON; it cannot be combined with --%s %s", batchFormatFlag, batchRecords, outputFormatFlag, opts.Format)
+	}
+
+	inputs, err := clirun.ResolveInputs(args)
+
+	if err != nil {
+		return err
+	}
+
+	if err := cliruntime.ValidateOptions(rtOpts); err != nil {
+		return err
+	}
+
+	params = execution.WithDefaultParams(cmd.Context(), inputs, params)
+
+	if err := execution.ValidateParams(os.Stderr, inputs, params, opts.StrictParams); err != nil {
+		return err
+	}
+
+	cleanup, err := browser.EnsureBrowser(cmd.Context(), rtOpts, brOpts)
+
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	rt, err := cliruntime.New(rtOpts)
+
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	report := sectionReporter(os.Stdout, opts.Format)
+
+	if opts.BatchFormat == batchRecords {
+		report = recordReporter(os.Stdout)
+	}
+
+	ctx, cancel := opts.Limits.WithMemoryLimit(cmd.Context())
+	results := clirun.ExecuteAll(ctx, rt, params, inputs, opts.Jobs, opts.Limits, report)
+	cancel()
+	err = errors.Join(err, closeRuntime(rt))
+
+	failed := writeSummary(os.Stderr, results, time.Since(started))
+
+	if failed > 0 {
+		return errors.Join(err, newBatchError(results, failed))
+	}
+
+	return err
+}
+
+// batchError reports how many scripts failed. It unwraps to the first limit
+// that stopped a script, so the command exits with that limit's code.
+type batchError struct {
+	failed int
+	total  int
+	limit  error
+}
+
+func newBatchError(results []clirun.Result, failed int) *batchError {
+	batchErr := &batchError{failed: failed, total: len(results)}
+
+	for _, result := range results {
+		var limitErr *limit.Error
+
+		if errors.As(result.Err, &limitErr) {
+			batchErr.limit = limitErr
+			break
+		}
+	}
+
+	return batchErr
+}
+
+func (e *batchError) Error() string {
+	return fmt.Sprintf("%d of %d scripts failed", e.failed, e.total)
+}
+
+func (e *batchError) Unwrap() error {
+	return e.limit
+}
+
+func closeRuntime(rt cliruntime.Runtime) error {
+	if err := rt.Close(); err != nil {
+		return fmt.Errorf("close runtime: %w", err)
+	}
+
+	return nil
+}
+
+// sectionReporter writes each result under a "==> name <==" header in
+// format. Errors are printed to stderr, and a result that cannot be written
+// in format fails its script.
+func sectionReporter(out io.Writer, format output.Format) func(*clirun.Result) {
+	first := true
+
+	return func(result *clirun.Result) {
+		if !first {
+			fmt.Fprintln(out)
+		}
+
+		first = false
+		fmt.Fprintf(out, "==> %s <==\n", result.Name)
+
+		if result.Err == nil {
+			content, err := output.Convert(result.Output, format)
+
+			if err != nil {
+				result.Err = err
+			} else {
+				_, _ = out.Write(content)
+
+				if !bytes.HasSuffix(content, []byte("\n")) {
+					fmt.Fprintln(out)
+				}
+			}
+		}
+
+		if result.Err != nil {
+			diagnostics.PrintError(fmt.Errorf("%s: %w", result.Name, result.Err))
+		}
+	}
+}
+
+// recordReporter writes one NDJSON record per script, tagged with its name.
+func recordReporter(out io.Writer) func(*clirun.Result) {
+	encoder := json.NewEncoder(out)
+
+	return func(result *clirun.Result) {
+		record := batchRecord{Script: result.Name, DurationMs: result.Duration.Milliseconds()}
+
+		switch {
+		case result.Err != nil:
+			record.Error = result.Err.Error()
+		case json.Valid(result.Output):
+			var compact bytes.Buffer
+			_ = json.Compact(&compact, result.Output)
+			record.Result = compact.Bytes()
+		default:
+			record.Result, _ = json.Marshal(string(result.Output))
+		}
+
+		_ = encoder.Encode(record)
+	}
+}
+
+// writeSummary lists every script with its status and duration and returns
+// the number that failed.
+func writeSummary(out io.Writer, results []clirun.Result, elapsed time.Duration) int {
+	failed := 0
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	for _, result := range results {
+		status := "ok"
+
+		if result.Err != nil {
+			status = "FAIL"
+			failed++
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\n", status, result.Name, result.Duration.Round(time.Millisecond))
+	}
+
+	_ = table.Flush()
+
+	fmt.Fprintf(out, "%d scripts, %d failed, %s\n", len(results), failed, elapsed.Round(time.Millisecond))
+
+	return failed
+}
@@ -1,6 +1,9 @@
 package run
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,41 +13,145 @@ import (
 	"github.com/MontFerret/cli/v2/cmd/internal/diagnostics"
 	"github.com/MontFerret/cli/v2/cmd/internal/execution"
 	"github.com/MontFerret/cli/v2/pkg/browser"
+	clibuild "github.com/MontFerret/cli/v2/pkg/build"
 	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	"github.com/MontFerret/cli/v2/pkg/output"
 	clirun "github.com/MontFerret/cli/v2/pkg/run"
 	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+	"github.com/MontFerret/cli/v2/pkg/trace"
 )
 
+const (
+	traceFlag        = "trace"
+	outputFlag       = "output"
+	outputFormatFlag = "output-format"
+	batchFormatFlag  = "batch-format"
+	jobsFlag         = "jobs"
+	watchFlag        = "watch"
+)
+
+// runOptions holds the run-specific flags.
+type runOptions struct {
+	Eval string
+	// Trace is the file an execution trace is written to. Empty disables
+	// tracing.
+	Trace string
+	// Format is how the result is written.
+	Format output.Format
+	// BatchFormat is how the results are laid out when several scripts run.
+	BatchFormat batchFormat
+	// Output is the file the result is written to instead of stdout.
+	Output string
+	// Jobs is the number of scripts run at once when several are given.
+	Jobs int
+	// StrictParams fails the run when a supplied param is not referenced.
+	StrictParams bool
+	// Limits bound the time, memory, and result size of each script.
+	Limits limit.Options
+	// Watch re-runs the script whenever it or the files it may read change.
+	Watch bool
+}
+
 func New(store *config.Store) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "run [script]",
+		Use:     "run [script|dir...]",
 		Aliases: []string{"exec"},
-		Short:   "Run a FQL script or compiled artifact",
-		Args:    cobra.MaximumNArgs(1),
+		Short:   "Run FQL scripts or compiled artifacts",
+		Long: `Run a FQL script or compiled artifact and print its result.
+
+Given several scripts, or a directory that is searched for .fql and .fqlc
+files, run executes all of them through one runtime and one browser, --jobs
+at a time. Each result is printed in --output-format under a "==> script <=="
+header, or as one NDJSON record per script with --batch-format records. A
+summary is written to stderr, and the command fails when any script failed.
+
+With --watch, run keeps the browser open and runs the script again each time
+it, or a file under --policy-fs-root, changes. After every run it reports the
+timing and a diff against the previous result.`,
+		Args: cobra.ArbitraryArgs,
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			store.BindFlags(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			paramFlag, err := cmd.Flags().GetStringArray(execution.ParamFlag)
+			params, err := execution.ParamsFromCommand(cmd)
 
 			if err != nil {
 				return err
 			}
 
-			params, err := execution.ParseParams(paramFlag)
+			eval, err := cmd.Flags().GetString("eval")
 
 			if err != nil {
 				return err
 			}
 
-			eval, err := cmd.Flags().GetString("eval")
+			if eval != "" && len(args) > 0 {
+				return fmt.Errorf("cannot use --eval with file arguments")
+			}
+
+			tracePath, err := cmd.Flags().GetString(traceFlag)
 
 			if err != nil {
 				return err
 			}
 
-			if eval != "" && len(args) > 0 {
-				return fmt.Errorf("cannot use --eval with file arguments")
+			formatValue, err := cmd.Flags().GetString(outputFormatFlag)
+
+			if err != nil {
+				return err
+			}
+
+			format, err := output.ParseFormat(formatValue)
+
+			if err != nil {
+				return err
+			}
+
+			batchFormatValue, err := cmd.Flags().GetString(batchFormatFlag)
+
+			if err != nil {
+				return err
+			}
+
+			batchFormat, err := parseBatchFormat(batchFormatValue)
+
+			if err != nil {
+				return err
+			}
+
+			outputPath, err := cmd.Flags().GetString(outputFlag)
+
+			if err != nil {
+				return err
+			}
+
+			jobs, err := cmd.Flags().GetInt(jobsFlag)
+
+			if err != nil {
+				return err
+			}
+
+			if jobs < 1 {
+				return fmt.Errorf("--%s must be at least 1", jobsFlag)
+			}
+
+			strictParams, err := cmd.Flags().GetBool(execution.StrictParamsFlag)
+
+			if err != nil {
+				return err
+			}
+
+			limits, err := execution.LimitsFromCommand(cmd)
+
+			if err != nil {
+				return err
+			}
+
+			watching, err := cmd.Flags().GetBool(watchFlag)
+
+			if err != nil {
+				return err
 			}
 
 			store := config.From(cmd.Context())
@@ -53,19 +160,45 @@ func New(store *config.Store) *cobra.Command {
 				return err
 			}
 
-			return execute(cmd, rtOpts, store.GetBrowserOptions(), params, eval, args)
+			return execute(cmd, rtOpts, store.GetBrowserOptions(), params, runOptions{
+				Eval:         eval,
+				Trace:        tracePath,
+				Format:       format,
+				BatchFormat:  batchFormat,
+				Output:       outputPath,
+				Jobs:         jobs,
+				StrictParams: strictParams,
+				Limits:       limits,
+				Watch:        watching,
+			}, args)
 		},
 	}
 
 	execution.AddEvalFlag(cmd)
 	execution.AddParamFlags(cmd)
+	execution.AddStrictParamsFlag(cmd)
 	execution.AddRuntimeFlags(cmd)
+	execution.AddLimitFlags(cmd)
+	cmd.Flags().String(traceFlag, "", `Record every step of the execution to a trace file for "ferret trace view"`)
+	cmd.Flags().String(outputFormatFlag, string(output.FormatJSON), "Result format: json, pretty, yaml, ndjson, csv, or table")
+	cmd.Flags().String(batchFormatFlag, string(batchSections), `Layout of results when several scripts run: sections, or records for one {"script", "result", "error"} JSON line each`)
+	cmd.Flags().String(outputFlag, "", "Write the result to a file instead of stdout; the file is replaced only when the run succeeds")
+	cmd.Flags().IntP(jobsFlag, "j", 1, "Number of scripts to run at once when several are given")
+	cmd.Flags().Bool(watchFlag, false, "Run the script again whenever it, or a file under --policy-fs-root, changes")
 
 	return cmd
 }
 
-func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]interface{}, eval string, args []string) error {
-	input, err := clirun.ResolveInput(eval, args)
+func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]interface{}, opts runOptions, args []string) error {
+	if opts.Watch {
+		return executeWatch(cmd, rtOpts, brOpts, params, opts, args)
+	}
+
+	if opts.Eval == "" && isBatch(args) {
+		return executeBatch(cmd, rtOpts, brOpts, params, opts, args)
+	}
+
+	input, err := clirun.ResolveInput(opts.Eval, args)
 
 	if err != nil {
 		return err
@@ -79,8 +212,10 @@ func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Optio
 		return err
 	}
 
-	if len(input.Artifact) > 0 && !cliruntime.IsBuiltinType(rtOpts.Type) {
-		return cliruntime.ErrArtifactRequiresBuiltinRuntime
+	params = execution.WithDefaultParams(cmd.Context(), []*clirun.Input{input}, params)
+
+	if err := execution.ValidateParams(os.Stderr, []*clirun.Input{input}, params, opts.StrictParams); err != nil {
+		return err
 	}
 
 	cleanup, err := browser.EnsureBrowser(cmd.Context(), rtOpts, brOpts)
@@ -91,7 +226,16 @@ func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Optio
 
 	defer cleanup()
 
-	out, err := clirun.Execute(cmd.Context(), rtOpts, params, input)
+	ctx, cancel := opts.Limits.Context(cmd.Context())
+	defer cancel()
+
+	if opts.Trace != "" {
+		return executeTrace(ctx, rtOpts, params, input, opts)
+	}
+
+	out, err := limit.Run(ctx, func(ctx context.Context) (io.ReadCloser, error) {
+		return clirun.Execute(ctx, rtOpts, params, input)
+	})
 
 	if err != nil {
 		diagnostics.PrintError(err)
@@ -100,7 +244,67 @@ func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Optio
 
 	defer out.Close()
 
-	_, err = io.Copy(os.Stdout, out)
+	return limit.Err(ctx, writeResult(opts.Limits.Reader(out), opts))
+}
+
+// writeResult writes the result in the requested format to the output file,
+// or to stdout when none is set.
+func writeResult(result io.Reader, opts runOptions) error {
+	if opts.Output == "" {
+		return output.Write(os.Stdout, result, opts.Format)
+	}
+
+	return output.WriteFile(opts.Output, func(w io.Writer) error {
+		return output.Write(w, result, opts.Format)
+	})
+}
+
+// executeTrace runs the program through a debug session so every step can be
+// recorded. Steps are streamed to the trace file as they happen, and the
+// trace is finished even when the program fails, since that is when it is
+// needed most.
+func executeTrace(ctx context.Context, rtOpts cliruntime.Options, params map[string]any, input *clirun.Input, opts runOptions) error {
+	src := input.Source
+
+	if len(input.Artifact) > 0 {
+		debugSrc, err := clibuild.DebugSource(input.Artifact)
+		if err != nil {
+			return fmt.Errorf("trace: %w", err)
+		}
+
+		src = debugSrc
+	}
+
+	session, err := execution.NewDebugSession(ctx, rtOpts, params, src)
+	if err != nil {
+		diagnostics.PrintError(err)
+		return err
+	}
+
+	writer, err := trace.Create(opts.Trace, src)
+	if err != nil {
+		err = fmt.Errorf("write trace: %w", err)
+		diagnostics.PrintError(err)
+
+		return errors.Join(err, session.Close())
+	}
+
+	event, err := trace.Record(ctx, session, writer)
+	err = errors.Join(limit.Err(ctx, err), session.Close())
+
+	if err != nil {
+		diagnostics.PrintError(err)
+		return err
+	}
+
+	if event != nil && event.Error != nil {
+		diagnostics.PrintError(event.Error)
+		return event.Error
+	}
+
+	if event != nil && event.Output != nil {
+		err = writeResult(opts.Limits.Reader(bytes.NewReader(event.Output.Content)), opts)
+	}
 
 	return err
 }
@@ -1,12 +1,21 @@
 package run
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 
@@ -14,45 +23,77 @@ import (
 	"github.com/MontFerret/cli/v2/pkg/browser"
 	"github.com/MontFerret/cli/v2/pkg/build"
 	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/limit"
 	"github.com/MontFerret/cli/v2/pkg/logger"
+	"github.com/MontFerret/cli/v2/pkg/output"
 	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+	"github.com/MontFerret/cli/v2/pkg/trace"
+	"github.com/MontFerret/ferret/v2"
 	"github.com/MontFerret/ferret/v2/pkg/compiler"
 	ferrethttp "github.com/MontFerret/ferret/v2/pkg/net/http"
 	"github.com/MontFerret/ferret/v2/pkg/source"
 )
 
-func TestExecuteRun_ArtifactRemoteRuntimeRejected(t *testing.T) {
+func TestExecuteRun_ArtifactSentToRemoteRuntime(t *testing.T) {
 	dir := t.TempDir()
 	input := filepath.Join(dir, "query.fql")
 	artifactPath := filepath.Join(dir, "query.fqlc")
 
-	testutil.WriteQuery(t, input, "RETURN 42")
+	testutil.WriteQuery(t, input, "RETURN @value")
 
-	if err := build.WriteArtifact(compiler.New(), source.New(input, "RETURN 42"), artifactPath); err != nil {
+	if err := build.WriteArtifact(compiler.New(), source.New(input, "RETURN @value"), artifactPath); err != nil {
 		t.Fatalf("build artifact: %v", err)
 	}
 
-	_, err := testutil.CaptureStdout(t, func() error {
+	artifact, err := os.ReadFile(artifactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			_, _ = w.Write([]byte(`{"version":{"ferret":"2"},"protocol":2,"features":["artifact"]}`))
+		case "/artifact":
+			var body struct {
+				Artifact []byte         `json:"artifact"`
+				Params   map[string]any `json:"params"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !bytes.Equal(body.Artifact, artifact) || body.Params["value"] != float64(42) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"unexpected artifact request"}`))
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`42`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	stdout, err := testutil.CaptureStdout(t, func() error {
 		return execute(
 			testutil.NewCommand(),
-			cliruntime.Options{Type: "https://worker.example"},
+			cliruntime.Options{Type: server.URL},
 			browser.Options{},
-			nil,
-			"",
+			map[string]any{"value": 42},
+			runOptions{},
 			[]string{artifactPath},
 		)
 	})
-
-	if err == nil {
-		t.Fatal("expected error")
+	if err != nil {
+		t.Fatalf("unexpected run error: %v", err)
 	}
 
-	if !errors.Is(err, cliruntime.ErrArtifactRequiresBuiltinRuntime) {
-		t.Fatalf("unexpected error: %v", err)
+	if strings.TrimSpace(stdout) != "42" {
+		t.Fatalf("unexpected stdout: %q", stdout)
 	}
 }
 
-func TestExecuteRun_ArtifactStdinRemoteRuntimeRejected(t *testing.T) {
+func TestExecuteRun_ArtifactStdinRemoteRuntimeWithoutSupportRejected(t *testing.T) {
 	dir := t.TempDir()
 	input := filepath.Join(dir, "query.fql")
 	artifactPath := filepath.Join(dir, "query.fqlc")
@@ -68,25 +109,26 @@ func TestExecuteRun_ArtifactStdinRemoteRuntimeRejected(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+
+		_, _ = w.Write([]byte(`{"version":{"ferret":"2"}}`))
+	}))
+	defer server.Close()
+
 	testutil.WithStdinBytes(t, data, func() {
 		err := execute(
 			testutil.NewCommand(),
-			cliruntime.Options{
-				Type:           "https://worker.example",
-				WithBrowser:    true,
-				BrowserAddress: "://invalid",
-			},
+			cliruntime.Options{Type: server.URL},
 			browser.Options{},
 			nil,
-			"",
+			runOptions{},
 			nil,
 		)
 
-		if err == nil {
-			t.Fatal("expected error")
-		}
-
-		if !errors.Is(err, cliruntime.ErrArtifactRequiresBuiltinRuntime) {
+		if !errors.Is(err, cliruntime.ErrRemoteArtifactUnsupported) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -104,7 +146,7 @@ func TestExecuteRun_HTTPPolicyRemoteRuntimeRejectedBeforeBrowserStartup(t *testi
 		opts,
 		browser.Options{},
 		nil,
-		"RETURN 1",
+		runOptions{Eval: "RETURN 1"},
 		nil,
 	)
 	if !errors.Is(err, cliruntime.ErrHTTPPolicyRequiresBuiltinRuntime) {
@@ -124,7 +166,7 @@ func TestExecuteRun_FSPolicyRemoteRuntimeRejectedBeforeBrowserStartup(t *testing
 		opts,
 		browser.Options{},
 		nil,
-		"RETURN 1",
+		runOptions{Eval: "RETURN 1"},
 		nil,
 	)
 	if !errors.Is(err, cliruntime.ErrFSPolicyRequiresBuiltinRuntime) {
@@ -132,11 +174,11 @@ func TestExecuteRun_FSPolicyRemoteRuntimeRejectedBeforeBrowserStartup(t *testing
 	}
 }
 
-func TestRunCommand_RejectsMultiplePositionalArgs(t *testing.T) {
+func TestRunCommand_AcceptsMultiplePositionalArgs(t *testing.T) {
 	cmd := New(new(config.Store))
 
-	if err := cmd.Args(cmd, []string{"one.fql", "two.fql"}); err == nil {
-		t.Fatal("expected argument validation error")
+	if err := cmd.Args(cmd, []string{"one.fql", "two.fql", "suite/"}); err != nil {
+		t.Fatalf("unexpected argument validation error: %v", err)
 	}
 }
 
@@ -155,7 +197,7 @@ func TestRunCommand_RejectsEvalWithFileArgs(t *testing.T) {
 func TestExecuteRun_NoInputShowsHelp(t *testing.T) {
 	cmd := testutil.NewCommand()
 	testutil.WithDevNullStdin(t, func() {
-		if err := execute(cmd, cliruntime.NewDefaultOptions(), browser.Options{}, nil, "", nil); err != nil {
+		if err := execute(cmd, cliruntime.NewDefaultOptions(), browser.Options{}, nil, runOptions{}, nil); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -176,7 +218,7 @@ func TestExecuteRun_ExecutionLogsGoToStderrNotStdout(t *testing.T) {
 				opts,
 				browser.Options{},
 				nil,
-				"LET printed = PRINT(\"hello\") RETURN 42",
+				runOptions{Eval: "LET printed = PRINT(\"hello\") RETURN 42"},
 				nil,
 			)
 		})
@@ -215,7 +257,7 @@ func TestExecuteRun_ExecutionLogsGoToFile(t *testing.T) {
 			opts,
 			browser.Options{},
 			nil,
-			"LET printed = PRINT(\"hello\") RETURN 42",
+			runOptions{Eval: "LET printed = PRINT(\"hello\") RETURN 42"},
 			nil,
 		)
 	})
@@ -247,7 +289,7 @@ func TestExecuteRun_RejectsInvalidLogOutput(t *testing.T) {
 		opts,
 		browser.Options{},
 		nil,
-		"RETURN 42",
+		runOptions{Eval: "RETURN 42"},
 		nil,
 	)
 
@@ -270,7 +312,7 @@ func TestExecuteRun_RejectsEmptyLogOutput(t *testing.T) {
 		opts,
 		browser.Options{},
 		nil,
-		"RETURN 42",
+		runOptions{Eval: "RETURN 42"},
 		nil,
 	)
 
@@ -293,7 +335,7 @@ func TestExecuteRun_RejectsEmptyLogFile(t *testing.T) {
 		opts,
 		browser.Options{},
 		nil,
-		"RETURN 42",
+		runOptions{Eval: "RETURN 42"},
 		nil,
 	)
 
@@ -305,3 +347,351 @@ func TestExecuteRun_RejectsEmptyLogFile(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestExecuteRun_TraceWritesReplayableTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+
+	stdout, err := testutil.CaptureStdout(t, func() error {
+		return execute(
+			testutil.NewCommand(),
+			cliruntime.NewDefaultOptions(),
+			browser.Options{},
+			nil,
+			runOptions{Eval: "LET x = 41\nRETURN x + 1", Trace: path},
+			nil,
+		)
+	})
+	if err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	if strings.TrimSpace(stdout) != "42" {
+		t.Fatalf("expected stdout result 42, got %q", stdout)
+	}
+
+	recorded, err := trace.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded.Reason != ferret.DebugReasonCompleted || strings.TrimSpace(string(recorded.Result)) != "42" || len(recorded.Steps) == 0 {
+		t.Fatalf("unexpected trace: %#v", recorded)
+	}
+}
+
+func TestExecuteRun_OutputFormatWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.csv")
+
+	stdout, err := testutil.CaptureStdout(t, func() error {
+		return execute(
+			testutil.NewCommand(),
+			cliruntime.NewDefaultOptions(),
+			browser.Options{},
+			nil,
+			runOptions{Eval: `RETURN [{ name: "a", n: 1 }, { name: "b", n: 2 }]`, Format: output.FormatCSV, Output: path},
+			nil,
+		)
+	})
+	if err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	if stdout != "" {
+		t.Fatalf("expected no stdout output, got %q", stdout)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "name,n\na,1\nb,2\n" {
+		t.Fatalf("unexpected output file: %q", data)
+	}
+}
+
+func TestRunCommand_RejectsUnknownOutputFormat(t *testing.T) {
+	cmd := New(new(config.Store))
+	cmd.SetContext(config.With(context.Background(), new(config.Store)))
+	cmd.Flags().Set("output-format", "xml")
+
+	err := cmd.RunE(cmd, nil)
+
+	if err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestExecuteRun_BatchRunsEveryScriptAndFailsOnErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Text string `json:"text"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil || strings.Contains(query.Text, "FAIL") {
+			panic(http.ErrAbortHandler)
+		}
+
+		_, _ = w.Write([]byte(`{"query": ` + strconv.Quote(query.Text) + `}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	testutil.WriteQuery(t, filepath.Join(dir, "suite", "a.fql"), "RETURN 1")
+	testutil.WriteQuery(t, filepath.Join(dir, "suite", "b.fql"), "RETURN FAIL")
+	testutil.WriteQuery(t, filepath.Join(dir, "c.fql"), "RETURN 3")
+
+	var stdout string
+	var err error
+
+	stderr, _ := testutil.CaptureStderr(t, func() error {
+		stdout, err = testutil.CaptureStdout(t, func() error {
+			return execute(
+				testutil.NewCommand(),
+				cliruntime.Options{Type: server.URL},
+				browser.Options{},
+				nil,
+				runOptions{BatchFormat: batchRecords, Jobs: 2},
+				[]string{filepath.Join(dir, "suite"), filepath.Join(dir, "c.fql")},
+			)
+		})
+
+		return err
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 scripts failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one record per script, got %q", stdout)
+	}
+
+	for i, expected := range []string{
+		`"script":"` + filepath.Join(dir, "suite", "a.fql") + `","result":{"query":"RETURN 1"}`,
+		`"script":"` + filepath.Join(dir, "suite", "b.fql") + `","error":`,
+		`"script":"` + filepath.Join(dir, "c.fql") + `","result":{"query":"RETURN 3"}`,
+	} {
+		if !strings.Contains(lines[i], expected) {
+			t.Fatalf("expected %s in record %d: %s", expected, i, lines[i])
+		}
+	}
+
+	if !strings.Contains(stderr, "FAIL  "+filepath.Join(dir, "suite", "b.fql")) || !strings.Contains(stderr, "3 scripts, 1 failed") {
+		t.Fatalf("unexpected summary: %q", stderr)
+	}
+}
+
+func TestExecuteRun_BatchRejectsSingleResultFlags(t *testing.T) {
+	err := execute(
+		testutil.NewCommand(),
+		cliruntime.NewDefaultOptions(),
+		browser.Options{},
+		nil,
+		runOptions{Output: "result.json"},
+		[]string{"a.fql", "b.fql"},
+	)
+
+	if err == nil || !strings.Contains(err.Error(), "cannot be used with several scripts") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = execute(
+		testutil.NewCommand(),
+		cliruntime.NewDefaultOptions(),
+		browser.Options{},
+		nil,
+		runOptions{Format: output.FormatCSV, BatchFormat: batchRecords},
+		[]string{"a.fql", "b.fql"},
+	)
+
+	if err == nil || !strings.Contains(err.Error(), "--batch-format records embeds each result as JSON") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestExecuteRun_ParamsCheckedBeforeRunning(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+
+	run := func(params map[string]any, strict bool) (string, error) {
+		var err error
+
+		stderr, _ := testutil.CaptureStderr(t, func() error {
+			_, err = testutil.CaptureStdout(t, func() error {
+				return execute(
+					testutil.NewCommand(),
+					cliruntime.Options{Type: server.URL},
+					browser.Options{},
+					params,
+					runOptions{Eval: "RETURN DOCUMENT(@url)", StrictParams: strict},
+					nil,
+				)
+			})
+
+			return err
+		})
+
+		return stderr, err
+	}
+
+	if _, err := run(map[string]any{"ur": "https://example.com"}, false); err == nil || err.Error() != "<eval>: missing params url" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := run(map[string]any{"url": "https://example.com", "limit": 5}, true); err == nil || err.Error() != "unknown params limit" {
+		t.Fatalf("unexpected strict error: %v", err)
+	}
+
+	if requests != 0 {
+		t.Fatalf("expected invalid params to fail before the query is sent, got %d requests", requests)
+	}
+
+	stderr, err := run(map[string]any{"url": "https://example.com", "limit": 5}, false)
+	if err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	if !strings.Contains(stderr, "warning: unused params limit") || requests != 1 {
+		t.Fatalf("expected a warning and a run, got %q after %d requests", stderr, requests)
+	}
+}
+
+func TestExecuteRun_LimitsStopRemoteRun(t *testing.T) {
+	var slow atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server notices a cancelled request only once the body is read.
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		if slow.Load() {
+			<-r.Context().Done()
+			return
+		}
+
+		_, _ = w.Write([]byte(`"` + strings.Repeat("x", 64) + `"`))
+	}))
+	defer server.Close()
+
+	run := func(limits limit.Options) error {
+		_, err := testutil.CaptureStdout(t, func() error {
+			return execute(
+				testutil.NewCommand(),
+				cliruntime.Options{Type: server.URL},
+				browser.Options{},
+				nil,
+				runOptions{Eval: "RETURN 1", Limits: limits},
+				nil,
+			)
+		})
+
+		return err
+	}
+
+	slow.Store(true)
+	err := run(limit.Options{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, limit.ErrTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	slow.Store(false)
+	err = run(limit.Options{MaxOutputSize: 16})
+	if !errors.Is(err, limit.ErrOutputSize) {
+		t.Fatalf("expected output size error, got %v", err)
+	}
+
+	if err := run(limit.Options{MaxOutputSize: 66}); err != nil {
+		t.Fatalf("unexpected error within limits: %v", err)
+	}
+}
+
+func TestWatchRun_ReportsTimingAndDiff(t *testing.T) {
+	var result atomic.Value
+	result.Store(`{"title":"Old","links":3}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(result.Load().(string)))
+	}))
+	defer server.Close()
+
+	script := filepath.Join(t.TempDir(), "page.fql")
+	testutil.WriteQuery(t, script, "RETURN 1")
+
+	rt, err := cliruntime.New(cliruntime.Options{Type: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	var status bytes.Buffer
+	var previous []byte
+
+	stdout, _ := testutil.CaptureStdout(t, func() error {
+		previous = watchRun(context.Background(), &status, rt, nil, runOptions{}, script, 1, nil)
+		previous = watchRun(context.Background(), &status, rt, nil, runOptions{}, script, 2, previous)
+
+		result.Store(`{"title":"New","links":3}`)
+		previous = watchRun(context.Background(), &status, rt, nil, runOptions{}, script, 3, previous)
+
+		return nil
+	})
+
+	if string(previous) != `{"title":"New","links":3}` {
+		t.Fatalf("unexpected latest result: %s", previous)
+	}
+
+	if strings.Count(stdout, `"links":3`) != 3 {
+		t.Fatalf("expected every result on stdout, got %q", stdout)
+	}
+
+	report := status.String()
+	for _, expected := range []string{
+		"Run 1 finished in ",
+		"; result unchanged\n",
+		"Run 3 finished in ",
+		"-  \"title\": \"Old\",\n+  \"title\": \"New\",\n",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Fatalf("expected %q in status:\n%s", expected, report)
+		}
+	}
+}
+
+func TestWatchPaths_IgnoreFilesTheRunWrites(t *testing.T) {
+	rtOpts := cliruntime.Options{
+		FSPolicy:     &cliruntime.FileSystemPolicy{Root: "data"},
+		HTTPFixtures: &cliruntime.HTTPFixtures{Record: "data/recordings"},
+	}
+
+	paths, ignored := watchPaths(rtOpts, runOptions{Output: "data/result.json"}, "scrape.fql")
+
+	if !reflect.DeepEqual(paths, []string{"scrape.fql", "data"}) {
+		t.Fatalf("unexpected watched paths: %v", paths)
+	}
+
+	if !reflect.DeepEqual(ignored, []string{"data/result.json", "data/recordings"}) {
+		t.Fatalf("unexpected ignored paths: %v", ignored)
+	}
+}
+
+func TestExecuteRun_WatchRejectsUnsupportedInput(t *testing.T) {
+	for _, tt := range []struct {
+		opts runOptions
+		args []string
+		want string
+	}{
+		{opts: runOptions{Watch: true, Eval: "RETURN 1"}, want: "needs exactly one script file"},
+		{opts: runOptions{Watch: true}, args: []string{"a.fql", "b.fql"}, want: "needs exactly one script file"},
+		{opts: runOptions{Watch: true, Trace: "trace.json"}, args: []string{"a.fql"}, want: "cannot be combined with --trace"},
+	} {
+		err := execute(testutil.NewCommand(), cliruntime.NewDefaultOptions(), browser.Options{}, nil, tt.opts, tt.args)
+
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("expected %q, got %v", tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/cmd/internal/diagnostics"
+	"github.com/MontFerret/cli/v2/cmd/internal/execution"
+	"github.com/MontFerret/cli/v2/pkg/browser"
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	"github.com/MontFerret/cli/v2/pkg/output"
+	clirun "github.com/MontFerret/cli/v2/pkg/run"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+	"github.com/MontFerret/cli/v2/pkg/watch"
+)
+
+// maxDiffLines caps the diff printed after a run, so a result that changed
+// completely does not scroll the previous one away.
+const maxDiffLines = 40
+
+// executeWatch runs a script, then runs it again every time the script, a
+// file under the filesystem policy root, or an HTML fixture changes, until
+// interrupted. The runtime and the browser stay open between runs. Failed
+// runs are reported and watching continues.
+func executeWatch(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]any, opts runOptions, args []string) (err error) {
+	if opts.Eval != "" || len(args) != 1 || isBatch(args) {
+		return fmt.Errorf("--%s needs exactly one script file", watchFlag)
+	}
+
+	if opts.Trace != "" {
+		return fmt.Errorf("--%s cannot be combined with --%s", watchFlag, traceFlag)
+	}
+
+	if err := cliruntime.ValidateOptions(rtOpts); err != nil {
+		return err
+	}
+
+	paths, ignored := watchPaths(rtOpts, opts, args[0])
+	watcher, err := watch.New(paths)
+
+	if err != nil {
+		return err
+	}
+
+	defer watcher.Close()
+
+	if err := watcher.Ignore(ignored...); err != nil {
+		return err
+	}
+
+	cleanup, err := browser.EnsureBrowser(cmd.Context(), rtOpts, brOpts)
+
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	rt, err := cliruntime.New(rtOpts)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, closeRuntime(rt))
+	}()
+
+	var previous []byte
+
+	for run := 1; ; run++ {
+		previous = watchRun(cmd.Context(), os.Stderr, rt, params, opts, args[0], run, previous)
+
+		fmt.Fprintf(os.Stderr, "Watching %s for changes (Ctrl-C to stop)\n", strings.Join(paths, ", "))
+
+		changed, err := watcher.Wait(cmd.Context())
+
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "\nChanged: %s\n", strings.Join(relativePaths(changed), ", "))
+	}
+}
+
+// watchPaths returns what a watched run of script depends on, and the files
+// the run writes itself, which must not trigger another run even when they
+// lie inside a watched directory.
+func watchPaths(rtOpts cliruntime.Options, opts runOptions, script string) (paths, ignored []string) {
+	paths = []string{script}
+
+	if rtOpts.FSPolicy != nil && rtOpts.FSPolicy.Root != "" {
+		paths = append(paths, rtOpts.FSPolicy.Root)
+	}
+
+	if rtOpts.HTMLFixtures != "" {
+		paths = append(paths, rtOpts.HTMLFixtures)
+	}
+
+	if opts.Output != "" {
+		ignored = append(ignored, opts.Output)
+	}
+
+	if rtOpts.HTTPFixtures != nil && rtOpts.HTTPFixtures.Record != "" {
+		ignored = append(ignored, rtOpts.HTTPFixtures.Record)
+	}
+
+	return paths, ignored
+}
+
+// watchRun executes the script once and writes its result. It reports the
+// timing and how the result differs from previous on status, and returns
+// the result to compare the next run against; a failed run keeps previous.
+func watchRun(ctx context.Context, status io.Writer, rt cliruntime.Runtime, params map[string]any, opts runOptions, path string, run int, previous []byte) []byte {
+	started := time.Now()
+	result, err := executeOnce(ctx, rt, params, opts, path)
+	elapsed := time.Since(started).Round(time.Millisecond)
+
+	if err == nil {
+		err = writeResult(bytes.NewReader(result), opts)
+	}
+
+	if err != nil {
+		diagnostics.PrintError(err)
+		fmt.Fprintf(status, "Run %d failed after %s\n", run, elapsed)
+
+		return previous
+	}
+
+	switch {
+	case run == 1:
+		fmt.Fprintf(status, "Run %d finished in %s\n", run, elapsed)
+	case bytes.Equal(previous, result):
+		fmt.Fprintf(status, "Run %d finished in %s; result unchanged\n", run, elapsed)
+	default:
+		fmt.Fprintf(status, "Run %d finished in %s; result changed:\n", run, elapsed)
+		writeDiff(status, previous, result)
+	}
+
+	return result
+}
+
+// executeOnce reads the script again and runs it through rt, applying the
+// same checks and limits as a single run.
+func executeOnce(ctx context.Context, rt cliruntime.Runtime, params map[string]any, opts runOptions, path string) ([]byte, error) {
+	input, err := clirun.ResolveInput("", []string{path})
+
+	if err != nil {
+		return nil, err
+	}
+
+	params = execution.WithDefaultParams(ctx, []*clirun.Input{input}, params)
+
+	if err := execution.ValidateParams(os.Stderr, []*clirun.Input{input}, params, opts.StrictParams); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := opts.Limits.Context(ctx)
+	defer cancel()
+
+	out, err := limit.Run(ctx, func(ctx context.Context) (io.ReadCloser, error) {
+		if len(input.Artifact) > 0 {
+			return rt.RunArtifact(ctx, input.Artifact, params)
+		}
+
+		return rt.Run(ctx, input.Source, params)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer out.Close()
+
+	result, err := io.ReadAll(opts.Limits.Reader(out))
+
+	return result, limit.Err(ctx, err)
+}
+
+// writeDiff writes a unified diff of two results, indented as JSON when
+// possible so that a changed field shows up on its own line.
+func writeDiff(w io.Writer, previous, current []byte) {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(diffText(previous)),
+		B:        difflib.SplitLines(diffText(current)),
+		FromFile: "previous",
+		ToFile:   "current",
+		Context:  1,
+	})
+
+	lines := strings.SplitAfter(diff, "\n")
+
+	if len(lines) > maxDiffLines {
+		omitted := len(lines) - maxDiffLines
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more lines\n", omitted))
+	}
+
+	for _, line := range lines {
+		fmt.Fprint(w, line)
+	}
+}
+
+func diffText(result []byte) string {
+	if pretty, err := output.Convert(result, output.FormatPretty); err == nil {
+		return string(pretty)
+	}
+
+	return string(result)
+}
+
+// relativePaths shortens paths below the working directory.
+func relativePaths(paths []string) []string {
+	wd, err := os.Getwd()
+
+	if err != nil {
+		return paths
+	}
+
+	res := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+
+		res = append(res, path)
+	}
+
+	return res
+}
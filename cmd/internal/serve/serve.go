@@ -0,0 +1,237 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/cmd/internal/execution"
+	"github.com/MontFerret/cli/v2/pkg/browser"
+	"github.com/MontFerret/cli/v2/pkg/config"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+	"github.com/MontFerret/cli/v2/pkg/server"
+)
+
+const (
+	listenFlag      = "listen"
+	concurrencyFlag = "concurrency"
+	authTokenFlag   = "auth-token"
+	debugFlag       = "debug"
+)
+
+// defaultListen only accepts connections from this machine, since the
+// server runs any script it is sent.
+const defaultListen = "127.0.0.1:8080"
+
+// shutdownTimeout is how long running requests may take to finish once the
+// server is asked to stop.
+const shutdownTimeout = 30 * time.Second
+
+func New(store *config.Store) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the builtin runtime over HTTP for remote execution",
+		Long: `Serve the builtin runtime over HTTP with the protocol of remote runtimes,
+so that "ferret run --runtime http://host:port" executes scripts here.
+
+Scripts run with the filesystem and HTTP policies, browser options, and
+limits given to serve; each request gets its own timeout and result size
+limit. With --auth-token, or FERRET_AUTH_TOKEN, every request must send the
+token as "Authorization: Bearer <token>".
+
+The server listens on 127.0.0.1:8080 by default. Anyone who can reach it can
+run scripts, so listening on any other interface, for example with
+--listen :8080, requires --auth-token.
+
+With --debug, "ferret debug --runtime http://host:port" can also open debugger
+sessions here. At most --concurrency sessions are open at once, run limits do
+not apply to them, and a session idle for 10 minutes is closed.`,
+		Args: cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			store.BindFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			listen, err := cmd.Flags().GetString(listenFlag)
+
+			if err != nil {
+				return err
+			}
+
+			concurrency, err := cmd.Flags().GetInt(concurrencyFlag)
+
+			if err != nil {
+				return err
+			}
+
+			if concurrency < 0 {
+				return fmt.Errorf("--%s cannot be negative", concurrencyFlag)
+			}
+
+			if concurrency == 0 {
+				concurrency = runtime.NumCPU()
+			}
+
+			token, err := cmd.Flags().GetString(authTokenFlag)
+
+			if err != nil {
+				return err
+			}
+
+			if err := checkListen(listen, token); err != nil {
+				return err
+			}
+
+			debug, err := cmd.Flags().GetBool(debugFlag)
+
+			if err != nil {
+				return err
+			}
+
+			limits, err := execution.LimitsFromCommand(cmd)
+
+			if err != nil {
+				return err
+			}
+
+			store := config.From(cmd.Context())
+			rtOpts, err := execution.OptionsFromCommand(cmd, store)
+
+			if err != nil {
+				return err
+			}
+
+			if !cliruntime.IsBuiltinType(rtOpts.Type) {
+				return fmt.Errorf("serve exposes the builtin runtime; --%s cannot point at another worker", config.ExecRuntime)
+			}
+
+			cleanup, err := browser.EnsureBrowser(cmd.Context(), rtOpts, store.GetBrowserOptions())
+
+			if err != nil {
+				return err
+			}
+
+			defer cleanup()
+
+			rt, err := cliruntime.New(rtOpts)
+
+			if err != nil {
+				return err
+			}
+
+			defer rt.Close()
+
+			opts := server.Options{
+				Runtime:     rt,
+				Version:     store.AppVersion(),
+				Concurrency: concurrency,
+				Limits:      limits,
+				Token:       token,
+			}
+
+			if debug {
+				opts.Debug = func(ctx context.Context, src *source.Source, params map[string]any) (server.DebugSession, error) {
+					session, err := cliruntime.NewDebugSession(ctx, rtOpts, params, src)
+
+					if err != nil {
+						return nil, err
+					}
+
+					return session, nil
+				}
+			}
+
+			// Requests share the heap, so the memory limit is set once for
+			// the process instead of per request.
+			restoreMemoryLimit := limits.SetMemoryLimit()
+			defer restoreMemoryLimit()
+
+			srv := server.New(opts)
+			defer srv.Close()
+
+			return serve(cmd.Context(), listen, srv)
+		},
+	}
+
+	execution.AddRuntimeFlags(cmd)
+	execution.AddLimitFlags(cmd)
+	cmd.Flags().String(listenFlag, defaultListen, "Address to listen on; addresses other than loopback require --auth-token")
+	cmd.Flags().Int(concurrencyFlag, 0, "Number of scripts run at once; further requests wait (default: number of CPUs)")
+	cmd.Flags().String(authTokenFlag, "", "Bearer token every request must send")
+	cmd.Flags().Bool(debugFlag, false, "Host remote debugger sessions for ferret debug")
+
+	return cmd
+}
+
+// checkListen refuses to serve on an address reachable from other machines
+// without a token, since every request runs a script.
+func checkListen(listen, token string) error {
+	if token != "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(listen)
+
+	if err != nil {
+		return fmt.Errorf("invalid --%s %q: %w", listenFlag, listen, err)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+
+	return fmt.Errorf("--%s %s accepts connections from other machines; set --%s, or FERRET_AUTH_TOKEN, to require a token", listenFlag, listen, authTokenFlag)
+}
+
+// serve handles requests until ctx is cancelled, then lets running requests
+// finish for up to shutdownTimeout.
+func serve(ctx context.Context, listen string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", listen)
+
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", listen, err)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Fprintf(os.Stderr, "Serving the builtin runtime on http://%s (Ctrl-C to stop)\n", listener.Addr())
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shut down server: %w", err)
+	}
+
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
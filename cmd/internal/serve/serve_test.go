@@ -0,0 +1,28 @@
+package serve
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckListen_RequiresTokenBeyondLoopback(t *testing.T) {
+	for _, listen := range []string{defaultListen, "localhost:9000", "[::1]:8080"} {
+		if err := checkListen(listen, ""); err != nil {
+			t.Fatalf("expected %s to be allowed without a token, got %v", listen, err)
+		}
+	}
+
+	for _, listen := range []string{":8080", "0.0.0.0:8080", "10.0.0.5:8080", "worker.internal:8080"} {
+		if err := checkListen(listen, ""); err == nil || !strings.Contains(err.Error(), "--auth-token") {
+			t.Fatalf("expected %s to require a token, got %v", listen, err)
+		}
+
+		if err := checkListen(listen, "secret"); err != nil {
+			t.Fatalf("expected %s to be allowed with a token, got %v", listen, err)
+		}
+	}
+
+	if err := checkListen("8080", ""); err == nil || !strings.Contains(err.Error(), "invalid --listen") {
+		t.Fatalf("expected invalid address error, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/cmd/internal/diagnostics"
+	"github.com/MontFerret/cli/v2/cmd/internal/execution"
+	"github.com/MontFerret/cli/v2/pkg/browser"
+	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/har"
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	"github.com/MontFerret/cli/v2/pkg/output"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+	"github.com/MontFerret/cli/v2/pkg/testrun"
+)
+
+const (
+	updateFlag = "update"
+	junitFlag  = "junit"
+)
+
+// testOptions holds the test-specific flags.
+type testOptions struct {
+	// Update rewrites the snapshots of tests whose result changed.
+	Update bool
+	// JUnit is the file a JUnit XML report is written to. Empty disables it.
+	JUnit string
+	// Limits bound the time and result size of each test.
+	Limits limit.Options
+}
+
+func New(store *config.Store) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [files|dirs...]",
+		Short: "Run FQL scripts and compare their results with snapshots",
+		Long: `Run FQL scripts and compare their results with snapshots.
+
+Directories, the current one by default, are searched for tests: every
+*_test.fql script, and every script.fql next to a script.expected.json
+snapshot. A result matches its snapshot when both hold the same JSON value;
+key order and formatting do not matter. A *_test.fql script without a
+snapshot passes unless it fails or returns false.
+
+Params given with --param and the other param flags apply to every test. A
+script.params.json, .yaml, .yml, or .toml file next to a script adds params
+for that test and overrides shared ones of the same name.
+
+With --update, missing and differing snapshots are rewritten from the
+results instead of failing. With --http-record or --http-replay and a
+directory, each test records its HTTP traffic to, or replays it from, its own
+HAR file there, named after the script's path below the directory it was
+found in: tests/pages/login_test.fql uses pages/login_test.har.`,
+		Args: cobra.ArbitraryArgs,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			store.BindFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := execution.ParamsFromCommand(cmd)
+
+			if err != nil {
+				return err
+			}
+
+			update, err := cmd.Flags().GetBool(updateFlag)
+
+			if err != nil {
+				return err
+			}
+
+			junit, err := cmd.Flags().GetString(junitFlag)
+
+			if err != nil {
+				return err
+			}
+
+			limits, err := execution.LimitsFromCommand(cmd)
+
+			if err != nil {
+				return err
+			}
+
+			store := config.From(cmd.Context())
+			rtOpts, err := execution.OptionsFromCommand(cmd, store)
+
+			if err != nil {
+				return err
+			}
+
+			return execute(cmd, rtOpts, store.GetBrowserOptions(), params, testOptions{
+				Update: update,
+				JUnit:  junit,
+				Limits: limits,
+			}, args)
+		},
+	}
+
+	execution.AddParamFlags(cmd)
+	execution.AddRuntimeFlags(cmd)
+	execution.AddLimitFlags(cmd)
+	cmd.Flags().Bool(updateFlag, false, "Rewrite missing and differing snapshots from the results")
+	cmd.Flags().String(junitFlag, "", "Write a JUnit XML report to a file")
+
+	return cmd
+}
+
+// execute runs the tests found in args one after another through one
+// browser. Each test is reported as it finishes, followed by a summary, and
+// the command fails when any test failed.
+func execute(cmd *cobra.Command, rtOpts cliruntime.Options, brOpts browser.Options, params map[string]any, opts testOptions, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	cases, err := testrun.Discover(args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(cases) == 0 {
+		return fmt.Errorf("no tests found in %s", strings.Join(args, ", "))
+	}
+
+	if err := cliruntime.ValidateOptions(rtOpts); err != nil {
+		return err
+	}
+
+	if err := checkFixtureFiles(cases, rtOpts); err != nil {
+		return err
+	}
+
+	cleanup, err := browser.EnsureBrowser(cmd.Context(), rtOpts, brOpts)
+
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	ctx, cancel := opts.Limits.WithMemoryLimit(cmd.Context())
+	defer cancel()
+
+	params = execution.WithDefaultParams(ctx, nil, params)
+
+	started := time.Now()
+	results := make([]testrun.Result, 0, len(cases))
+
+	for _, c := range cases {
+		var res testrun.Result
+		caseParams, err := paramsFor(c, params)
+
+		if err != nil {
+			res = testrun.Result{Case: c, Status: testrun.StatusError, Err: err}
+		} else {
+			res = testrun.Run(ctx, caseOptions(c, rtOpts), caseParams, c, opts.Limits, opts.Update)
+		}
+
+		writeResult(os.Stderr, res)
+		results = append(results, res)
+	}
+
+	failed := writeSummary(os.Stderr, results, time.Since(started))
+
+	if opts.JUnit != "" {
+		err = output.WriteFile(opts.JUnit, func(w io.Writer) error {
+			return testrun.WriteJUnit(w, results)
+		})
+	}
+
+	if failed > 0 {
+		return errors.Join(err, fmt.Errorf("%d of %d tests failed", failed, len(results)))
+	}
+
+	return err
+}
+
+// paramsFor returns the shared params with the params file of c applied.
+func paramsFor(c testrun.Case, shared map[string]any) (map[string]any, error) {
+	if c.Params == "" {
+		return shared, nil
+	}
+
+	own, err := execution.LoadParamsFile(c.Params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]any, len(shared)+len(own))
+
+	for name, value := range shared {
+		res[name] = value
+	}
+
+	for name, value := range own {
+		res[name] = value
+	}
+
+	return res, nil
+}
+
+// caseOptions gives each test its own HAR file when HTTP traffic is recorded
+// to, or replayed from, a directory. The file is keyed by the test's path
+// below the directory it was found in, so tests neither overwrite each
+// other's recording nor get answered from another test's exchanges.
+func caseOptions(c testrun.Case, rtOpts cliruntime.Options) cliruntime.Options {
+	fixtures := rtOpts.HTTPFixtures
+
+	if fixtures == nil {
+		return rtOpts
+	}
+
+	own := *fixtures
+
+	if own.Record != "" && !strings.EqualFold(filepath.Ext(own.Record), har.Ext) {
+		own.Record = fixtureFile(own.Record, c)
+	}
+
+	if info, err := os.Stat(own.Replay); own.Replay != "" && err == nil && info.IsDir() {
+		own.Replay = fixtureFile(own.Replay, c)
+	}
+
+	if own.Record == fixtures.Record && own.Replay == fixtures.Replay {
+		return rtOpts
+	}
+
+	rtOpts.HTTPFixtures = &own
+
+	return rtOpts
+}
+
+func fixtureFile(dir string, c testrun.Case) string {
+	return filepath.Join(dir, strings.TrimSuffix(c.Name, ".fql")+har.Ext)
+}
+
+// checkFixtureFiles fails when two tests would use the same HAR file, which
+// happens when directories given together hold tests of the same name.
+func checkFixtureFiles(cases []testrun.Case, rtOpts cliruntime.Options) error {
+	owners := make(map[string]string, len(cases))
+
+	for _, c := range cases {
+		fixtures := caseOptions(c, rtOpts).HTTPFixtures
+
+		if fixtures == rtOpts.HTTPFixtures {
+			return nil
+		}
+
+		path := fixtures.Record
+
+		if path == "" {
+			path = fixtures.Replay
+		}
+
+		if other, ok := owners[path]; ok {
+			return fmt.Errorf("tests %s and %s would share the HTTP fixture %s; run them separately", other, c.Script, path)
+		}
+
+		owners[path] = c.Script
+	}
+
+	return nil
+}
+
+func writeResult(w io.Writer, res testrun.Result) {
+	elapsed := res.Duration.Round(time.Millisecond)
+
+	switch res.Status {
+	case testrun.StatusPassed:
+		fmt.Fprintf(w, "ok      %s (%s)\n", res.Case.Script, elapsed)
+	case testrun.StatusUpdated:
+		fmt.Fprintf(w, "updated %s (%s)\n", res.Case.Script, elapsed)
+	case testrun.StatusFailed:
+		fmt.Fprintf(w, "FAIL    %s (%s): %s\n", res.Case.Script, elapsed, res.Err)
+
+		if res.Diff != "" {
+			fmt.Fprint(w, res.Diff)
+		}
+	default:
+		fmt.Fprintf(w, "ERROR   %s (%s)\n", res.Case.Script, elapsed)
+		diagnostics.PrintError(res.Err)
+	}
+}
+
+// writeSummary writes the counts of each outcome and returns the number of
+// tests that failed or could not run.
+func writeSummary(w io.Writer, results []testrun.Result, elapsed time.Duration) int {
+	counts := make(map[testrun.Status]int)
+
+	for _, res := range results {
+		counts[res.Status]++
+	}
+
+	failed := counts[testrun.StatusFailed] + counts[testrun.StatusError]
+	summary := fmt.Sprintf("%d passed, %d failed", counts[testrun.StatusPassed], failed)
+
+	if updated := counts[testrun.StatusUpdated]; updated > 0 {
+		summary += fmt.Sprintf(", %d updated", updated)
+	}
+
+	fmt.Fprintf(w, "\n%s in %s\n", summary, elapsed.Round(time.Millisecond))
+
+	return failed
+}
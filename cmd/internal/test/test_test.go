@@ -0,0 +1,96 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MontFerret/cli/v2/pkg/browser"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+	"github.com/MontFerret/cli/v2/pkg/testrun"
+)
+
+func TestParamsFor_OverridesSharedParams(t *testing.T) {
+	dir := t.TempDir()
+	paramsFile := filepath.Join(dir, "scrape.params.json")
+
+	if err := os.WriteFile(paramsFile, []byte(`{"url": "https://example.com", "depth": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	shared := map[string]any{"url": "https://other.com", "debug": true}
+	params, err := paramsFor(testrun.Case{Params: paramsFile}, shared)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{"url": "https://example.com", "depth": float64(2), "debug": true}
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("expected params %#v, got %#v", want, params)
+	}
+
+	if shared["url"] != "https://other.com" {
+		t.Fatalf("shared params were modified: %#v", shared)
+	}
+}
+
+func TestCaseOptions_KeysFixturesByPathBelowTheRoot(t *testing.T) {
+	replay := t.TempDir()
+	first := testrun.Case{Script: "tests/a/x_test.fql", Name: filepath.Join("a", "x_test.fql")}
+	second := testrun.Case{Script: "tests/b/x_test.fql", Name: filepath.Join("b", "x_test.fql")}
+
+	recording := cliruntime.Options{HTTPFixtures: &cliruntime.HTTPFixtures{Record: "fixtures"}}
+	if got := caseOptions(first, recording).HTTPFixtures.Record; got != filepath.Join("fixtures", "a", "x_test.har") {
+		t.Fatalf("unexpected recording: %s", got)
+	}
+	if got := caseOptions(second, recording).HTTPFixtures.Record; got != filepath.Join("fixtures", "b", "x_test.har") {
+		t.Fatalf("unexpected recording: %s", got)
+	}
+
+	replaying := cliruntime.Options{HTTPFixtures: &cliruntime.HTTPFixtures{Replay: replay}}
+	if got := caseOptions(second, replaying).HTTPFixtures.Replay; got != filepath.Join(replay, "b", "x_test.har") {
+		t.Fatalf("expected replay from the test's own file, got %s", got)
+	}
+
+	single := cliruntime.Options{HTTPFixtures: &cliruntime.HTTPFixtures{Record: "all.har"}}
+	if got := caseOptions(first, single); got.HTTPFixtures != single.HTTPFixtures {
+		t.Fatalf("expected a HAR file to be shared, got %#v", got.HTTPFixtures)
+	}
+
+	if err := checkFixtureFiles([]testrun.Case{first, second}, recording); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clash := testrun.Case{Script: "other/a/x_test.fql", Name: filepath.Join("a", "x_test.fql")}
+	if err := checkFixtureFiles([]testrun.Case{first, clash}, recording); err == nil || !strings.Contains(err.Error(), "would share the HTTP fixture") {
+		t.Fatalf("expected shared fixture error, got %v", err)
+	}
+}
+
+func TestTestCommand_FailsWithoutTests(t *testing.T) {
+	err := execute(New(nil), cliruntime.Options{}, browser.Options{}, nil, testOptions{}, []string{t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "no tests found") {
+		t.Fatalf("expected no tests error, got %v", err)
+	}
+}
+
+func TestWriteSummary_CountsFailuresAndErrors(t *testing.T) {
+	var out bytes.Buffer
+	failed := writeSummary(&out, []testrun.Result{
+		{Status: testrun.StatusPassed},
+		{Status: testrun.StatusFailed},
+		{Status: testrun.StatusError},
+		{Status: testrun.StatusUpdated},
+	}, 0)
+
+	if failed != 2 {
+		t.Fatalf("expected 2 failures, got %d", failed)
+	}
+
+	if !strings.Contains(out.String(), "1 passed, 2 failed, 1 updated") {
+		t.Fatalf("unexpected summary: %q", out.String())
+	}
+}
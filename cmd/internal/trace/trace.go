@@ -0,0 +1,67 @@
+package trace
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/debugger"
+	clitrace "github.com/MontFerret/cli/v2/pkg/trace"
+)
+
+const historyFile = "trace_history"
+
+func New(store *config.Store) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trace",
+		Short: "Inspect execution traces",
+		Args:  cobra.MaximumNArgs(0),
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			store.BindFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+
+			return fmt.Errorf("unknown command %q", args[0])
+		},
+	}
+
+	viewCmd := &cobra.Command{
+		Use:   "view <trace.json>",
+		Short: "Replay a trace recorded with \"ferret run --trace\"",
+		Long: `Replay a trace recorded with "ferret run --trace" in the debugger prompt,
+without re-running the script.
+
+The prompt commands of "ferret debug" are available. Stepping moves between
+recorded steps, breakpoints stop at recorded locations, and print shows the
+recorded value of a variable; other expressions cannot be evaluated because
+the program state is gone.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return view(cmd, config.From(cmd.Context()), args[0])
+		},
+	}
+
+	cmd.AddCommand(viewCmd)
+
+	return cmd
+}
+
+func view(cmd *cobra.Command, store *config.Store, path string) error {
+	recorded, err := clitrace.Load(path)
+	if err != nil {
+		return err
+	}
+
+	opts := debugger.Options{Snapshots: debugger.DefaultSnapshots}
+
+	if dir := store.Dir(); dir != "" {
+		opts.HistoryFile = filepath.Join(dir, historyFile)
+	}
+
+	return debugger.Start(cmd.Context(), clitrace.NewReplay(recorded), recorded.SourceFile(), opts)
+}
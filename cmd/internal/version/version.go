@@ -28,6 +28,10 @@ func New(store *config.Store) *cobra.Command {
 }
 
 func runVersion(cmd *cobra.Command, store *config.Store) error {
+	if err := store.ValidateProfile(); err != nil {
+		return err
+	}
+
 	rt, err := runtime.New(store.GetRuntimeOptions())
 
 	if err != nil {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -15,6 +16,7 @@ import (
 	"github.com/MontFerret/cli/v2/cmd"
 	"github.com/MontFerret/cli/v2/internal/migration"
 	"github.com/MontFerret/cli/v2/pkg/config"
+	"github.com/MontFerret/cli/v2/pkg/limit"
 	"github.com/MontFerret/cli/v2/pkg/logger"
 	modulelifecycle "github.com/MontFerret/cli/v2/pkg/module"
 	"github.com/MontFerret/cli/v2/pkg/module/discovery"
@@ -57,6 +59,7 @@ func main() {
 	rootCmd.PersistentFlags().StringP(config.LoggerLevel, "l", zerolog.InfoLevel.String(), fmt.Sprintf("Set the logging level (%s)", logger.LevelsFmt()))
 	rootCmd.PersistentFlags().String(config.LoggerOutput, logger.OutputStderr, fmt.Sprintf("Set the query execution log output (%s)", logger.OutputsFmt()))
 	rootCmd.PersistentFlags().String(config.LoggerFile, "ferret.log", "Set the query execution log file path when --log-output=file")
+	config.AddProfileFlag(rootCmd.PersistentFlags())
 
 	registryClient, err := barnregistry.NewClient()
 	if err != nil {
@@ -81,9 +84,12 @@ func main() {
 		cmd.ConfigCommand(store),
 		cmd.RunCommand(store),
 		cmd.DebugCommand(store),
+		cmd.TraceCommand(store),
 		cmd.ReplCommand(store),
 		cmd.FormatCommand(store),
 		cmd.CheckCommand(store),
+		cmd.TestCommand(store),
+		cmd.ServeCommand(store),
 		cmd.BuildCommand(store),
 		cmd.InspectCommand(store),
 		cmd.MigrateCommand(store, migrationService),
@@ -112,8 +118,21 @@ func main() {
 func exit(err error) {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	os.Exit(0)
 }
+
+// exitCode is 1 unless err is the execution limit that stopped a script.
+// Only limit errors are matched: other errors with an ExitCode method, such
+// as a failed subprocess, must not leak their status into the CLI's.
+func exitCode(err error) int {
+	var limitErr *limit.Error
+
+	if errors.As(err, &limitErr) {
+		return limitErr.ExitCode()
+	}
+
+	return 1
+}
@@ -2,17 +2,26 @@ package build
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 
+	"github.com/MontFerret/ferret/v2/pkg/bytecode"
 	"github.com/MontFerret/ferret/v2/pkg/bytecode/artifact"
 	"github.com/MontFerret/ferret/v2/pkg/compiler"
 	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/output"
 )
 
 var renameArtifactFile = os.Rename
 
 func WriteArtifact(c *compiler.Compiler, src *source.Source, outputPath string) error {
+	return WriteArtifactWithOptions(c, src, outputPath, ArtifactOptions{})
+}
+
+// WriteArtifactWithOptions compiles src and atomically writes the artifact to
+// outputPath, keeping or stripping debug info as opts say.
+func WriteArtifactWithOptions(c *compiler.Compiler, src *source.Source, outputPath string, opts ArtifactOptions) error {
 	same, err := samePath(src.Name(), outputPath)
 
 	if err != nil {
@@ -29,60 +38,28 @@ func WriteArtifact(c *compiler.Compiler, src *source.Source, outputPath string)
 		return err
 	}
 
-	data, err := artifact.Marshal(program)
-
-	if err != nil {
-		return fmt.Errorf("serialize %s: %w", src.Name(), err)
-	}
-
-	outputDir := filepath.Dir(outputPath)
-
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		return fmt.Errorf("create output directory %s: %w", outputDir, err)
+	if opts.StripDebugInfo {
+		stripDebugInfo(program)
 	}
 
-	tempFile, err := os.CreateTemp(outputDir, artifactTempPattern(outputPath))
+	data, err := artifact.Marshal(program)
 
 	if err != nil {
-		return fmt.Errorf("create temporary artifact for %s: %w", outputPath, err)
+		return fmt.Errorf("serialize %s: %w", src.Name(), err)
 	}
 
-	tempPath := tempFile.Name()
-	cleanupTemp := true
-	defer func() {
-		if !cleanupTemp {
-			return
+	return output.ReplaceFile(outputPath, "artifact", renameArtifactFile, func(w io.Writer) error {
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("write temporary artifact for %s: %w", outputPath, err)
 		}
 
-		_ = tempFile.Close()
-		_ = os.Remove(tempPath)
-	}()
-
-	if _, err := tempFile.Write(data); err != nil {
-		return fmt.Errorf("write temporary artifact for %s: %w", outputPath, err)
-	}
-
-	if err := tempFile.Chmod(0o644); err != nil {
-		return fmt.Errorf("set permissions on temporary artifact for %s: %w", outputPath, err)
-	}
-
-	if err := tempFile.Sync(); err != nil {
-		return fmt.Errorf("sync temporary artifact for %s: %w", outputPath, err)
-	}
-
-	if err := tempFile.Close(); err != nil {
-		return fmt.Errorf("close temporary artifact for %s: %w", outputPath, err)
-	}
-
-	if err := renameArtifactFile(tempPath, outputPath); err != nil {
-		return fmt.Errorf("replace %s with temporary artifact: %w", outputPath, err)
-	}
-
-	cleanupTemp = false
-
-	return nil
+		return nil
+	})
 }
 
-func artifactTempPattern(outputPath string) string {
-	return "." + filepath.Base(outputPath) + ".tmp-*"
+// stripDebugInfo drops the source text and instruction spans that are only
+// needed to map bytecode back to the original FQL.
+func stripDebugInfo(program *bytecode.Program) {
+	program.Source = nil
+	program.Metadata.DebugSpans = nil
 }
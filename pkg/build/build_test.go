@@ -10,6 +10,8 @@ import (
 	"github.com/MontFerret/ferret/v2/pkg/bytecode/artifact"
 	"github.com/MontFerret/ferret/v2/pkg/compiler"
 	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/output"
 )
 
 func TestPlanOutputs_DefaultOutputPath(t *testing.T) {
@@ -364,6 +366,63 @@ func TestWriteArtifact_ArtifactRoundTrip(t *testing.T) {
 	assertArtifactSource(t, output, "RETURN 42")
 }
 
+func TestWriteArtifactWithOptions_StripsDebugInfo(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "query.fql")
+	output := filepath.Join(dir, "query.fqlc")
+
+	writeQuery(t, input, "RETURN 42")
+
+	if err := WriteArtifactWithOptions(compiler.New(), source.New(input, "RETURN 42"), output, ArtifactOptions{StripDebugInfo: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	program, err := artifact.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal artifact: %v", err)
+	}
+
+	if program.Source != nil || len(program.Metadata.DebugSpans) != 0 {
+		t.Fatalf("expected debug info to be stripped, got source=%v spans=%d", program.Source, len(program.Metadata.DebugSpans))
+	}
+
+	if _, err := DebugSource(data); !errors.Is(err, ErrNoDebugInfo) {
+		t.Fatalf("expected missing debug info error, got %v", err)
+	}
+}
+
+func TestDebugSource_ReturnsEmbeddedSource(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "query.fql")
+	output := filepath.Join(dir, "query.fqlc")
+	query := "LET x = 1\nRETURN x + 1"
+
+	writeQuery(t, input, query)
+
+	if err := WriteArtifact(compiler.New(), source.New(input, query), output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := DebugSource(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if src.Name() != input || src.Content() != query {
+		t.Fatalf("unexpected source: %s %q", src.Name(), src.Content())
+	}
+}
+
 func TestWriteArtifact_InvalidQueryDoesNotCreateArtifactInMissingParentDirectory(t *testing.T) {
 	dir := t.TempDir()
 	input := filepath.Join(dir, "broken.fql")
@@ -419,7 +478,7 @@ func assertArtifactSource(t *testing.T, path, expected string) {
 func assertNoTempArtifacts(t *testing.T, dir, outputPath string) {
 	t.Helper()
 
-	matches, err := filepath.Glob(filepath.Join(dir, artifactTempPattern(outputPath)))
+	matches, err := filepath.Glob(filepath.Join(dir, output.TempPattern(outputPath)))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,54 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/MontFerret/ferret/v2/pkg/bytecode/artifact"
+	"github.com/MontFerret/ferret/v2/pkg/compiler"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+var (
+	// ErrNoDebugInfo indicates an artifact was built with --debug-info=false.
+	ErrNoDebugInfo = errors.New("artifact has no debug info; rebuild it without --debug-info=false")
+
+	// ErrDebugInfoMismatch indicates the embedded source no longer compiles to
+	// the program stored in the artifact, usually after a compiler upgrade.
+	ErrDebugInfoMismatch = errors.New("artifact program does not match its embedded source; rebuild it with this version of ferret")
+)
+
+// DebugSource returns the original source embedded in a debug-info artifact.
+// The source is recompiled with the compiler ferret build uses, and the
+// serialized result must match the artifact byte for byte, so that bytecode,
+// constants, functions, and params are all exactly what the artifact runs.
+func DebugSource(data []byte) (*source.Source, error) {
+	program, err := artifact.Unmarshal(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("deserialize artifact: %w", err)
+	}
+
+	if program.Source == nil || len(program.Metadata.DebugSpans) == 0 {
+		return nil, ErrNoDebugInfo
+	}
+
+	recompiled, err := compiler.New().Compile(program.Source)
+
+	if err != nil {
+		return nil, errors.Join(ErrDebugInfoMismatch, err)
+	}
+
+	serialized, err := artifact.Marshal(recompiled)
+
+	if err != nil {
+		return nil, errors.Join(ErrDebugInfoMismatch, err)
+	}
+
+	if !bytes.Equal(serialized, data) {
+		return nil, ErrDebugInfoMismatch
+	}
+
+	return program.Source, nil
+}
@@ -10,4 +10,12 @@ type (
 		OutputDir string
 		Targets   []Target
 	}
+
+	// ArtifactOptions controls what WriteArtifact keeps in the serialized program.
+	ArtifactOptions struct {
+		// StripDebugInfo drops the source text and instruction spans. Stripped
+		// artifacts are smaller but cannot be debugged, and runtime errors no
+		// longer point at FQL lines.
+		StripDebugInfo bool
+	}
 )
@@ -4,4 +4,11 @@ import "errors"
 
 var (
 	ErrInvalidFlag = errors.New("invalid flag")
+
+	// ErrProfileNotFound indicates the selected profile is not defined in the
+	// config file.
+	ErrProfileNotFound = errors.New("profile not found")
+
+	// ErrInvalidProfile indicates a profile name that cannot be stored.
+	ErrInvalidProfile = errors.New("invalid profile name")
 )
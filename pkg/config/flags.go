@@ -15,6 +15,15 @@ const (
 	ExecProxy               = "proxy"
 	ExecUserAgent           = "user-agent"
 
+	RemoteToken      = "remote-token"
+	RemoteUsername   = "remote-username"
+	RemotePassword   = "remote-password"
+	RemoteCACert     = "remote-ca-cert"
+	RemoteClientCert = "remote-client-cert"
+	RemoteClientKey  = "remote-client-key"
+	RemoteTimeout    = "remote-timeout"
+	RemoteRetries    = "remote-retries"
+
 	PolicyFSRoot                    = "policy-fs-root"
 	PolicyFSReadOnly                = "policy-fs-read-only"
 	PolicyHTTPAllowedSchemes        = "policy-http-allowed-schemes"
@@ -36,6 +45,20 @@ const (
 	PolicyHTTPFollowRedirects       = "policy-http-follow-redirects"
 	PolicyHTTPMaxRedirects          = "policy-http-max-redirects"
 
+	// ProjectLockPolicies keeps the policies of the user config over those
+	// of a project config. A project config cannot set it.
+	ProjectLockPolicies = "project-lock-policies"
+
+	LimitTimeout       = "timeout"
+	LimitMaxOutputSize = "max-output-size"
+	LimitMaxMemory     = "max-memory"
+
+	FormatPrintWidth     = "print-width"
+	FormatTabWidth       = "tab-width"
+	FormatSingleQuote    = "single-quote"
+	FormatBracketSpacing = "bracket-spacing"
+	FormatCaseMode       = "case-mode"
+
 	BrowserPort     = "port"
 	BrowserDetach   = "detach"
 	BrowserHeadless = "headless"
@@ -53,6 +76,14 @@ var Flags = []string{
 	ExecWithBrowserHeadless,
 	ExecProxy,
 	ExecUserAgent,
+	RemoteToken,
+	RemoteUsername,
+	RemotePassword,
+	RemoteCACert,
+	RemoteClientCert,
+	RemoteClientKey,
+	RemoteTimeout,
+	RemoteRetries,
 	PolicyFSRoot,
 	PolicyFSReadOnly,
 	PolicyHTTPAllowedSchemes,
@@ -73,6 +104,10 @@ var Flags = []string{
 	PolicyHTTPMaxResponseHeaderSize,
 	PolicyHTTPFollowRedirects,
 	PolicyHTTPMaxRedirects,
+	ProjectLockPolicies,
+	LimitTimeout,
+	LimitMaxOutputSize,
+	LimitMaxMemory,
 }
 var FlagsStr = strings.Join(Flags, `"|"`)
 
@@ -13,14 +13,22 @@ import (
 	"github.com/spf13/viper"
 )
 
-func ensureConfigFile(v *viper.Viper, appName string) error {
+func configDir(appName string) (string, error) {
 	home, err := homedir.Dir()
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	projectDir := path.Join(home, "."+appName)
+	return path.Join(home, "."+appName), nil
+}
+
+func ensureConfigFile(v *viper.Viper, appName string) error {
+	projectDir, err := configDir(appName)
+
+	if err != nil {
+		return err
+	}
 
 	_, err = os.Stat(projectDir)
 
@@ -51,6 +59,11 @@ func ensureConfigFile(v *viper.Viper, appName string) error {
 
 func bindFlags(v *viper.Viper, flags *pflag.FlagSet, envPrefix string) {
 	flags.VisitAll(func(f *pflag.Flag) {
+		// The profile selects config rather than being one of its keys.
+		if f.Name == Profile {
+			return
+		}
+
 		v.BindPFlag(f.Name, f)
 
 		// Environment variables can't have dashes in them, so bind them to their equivalent
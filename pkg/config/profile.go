@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Profile is the flag, and with the env prefix the environment variable,
+// that selects a named profile.
+const Profile = "profile"
+
+// profilesKey holds the named profiles in the config file, each a map of
+// config keys that override the base settings.
+const profilesKey = "profiles"
+
+// AddProfileFlag registers the flag that selects a profile.
+func AddProfileFlag(flags *pflag.FlagSet) {
+	flags.String(Profile, "", "Configuration profile whose settings override the base config")
+}
+
+// Profile returns the name of the active profile, or an empty string when
+// only the base settings are used.
+func (s *Store) Profile() string {
+	return s.profile
+}
+
+// Profiles returns the names of the profiles defined in the config file.
+func (s *Store) Profiles() []string {
+	names := make([]string, 0)
+
+	for name := range s.v.GetStringMap(profilesKey) {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// UseProfile layers the named profile over the base settings of the config
+// file. Flags and environment variables still take precedence. An empty
+// name returns to the base settings.
+func (s *Store) UseProfile(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if strings.Contains(name, ".") {
+		return fmt.Errorf("%w: %q cannot contain dots", ErrInvalidProfile, name)
+	}
+
+	if name == s.profile {
+		return nil
+	}
+
+	s.profile = name
+
+	return s.reload()
+}
+
+// ValidateProfile fails when the active profile is not defined in the config
+// file. Commands that only read settings call it; config set creates
+// profiles, so it does not.
+func (s *Store) ValidateProfile() error {
+	if s.profileErr != nil {
+		return s.profileErr
+	}
+
+	if s.profile == "" || s.v.IsSet(s.profileKey("")) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q", ErrProfileNotFound, s.profile)
+}
+
+// profileFrom returns the profile selected by the command's flag, falling
+// back to the environment.
+func (s *Store) profileFrom(cmd *cobra.Command) string {
+	if flag := cmd.Flags().Lookup(Profile); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+
+	return os.Getenv(s.envPrefix + "_" + strings.ToUpper(Profile))
+}
+
+// profileKey returns the config file path of key inside the active profile,
+// or of the profile itself for an empty key.
+func (s *Store) profileKey(key string) string {
+	path := profilesKey + "." + s.profile
+
+	if key == "" {
+		return path
+	}
+
+	return path + "." + key
+}
+
+// reload reads the config file again and merges the active profile, then
+// the project config, over it.
+func (s *Store) reload() error {
+	if err := s.v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return err
+		}
+	}
+
+	if s.profile != "" {
+		if err := s.v.MergeConfigMap(s.v.GetStringMap(s.profileKey(""))); err != nil {
+			return err
+		}
+	}
+
+	if s.project != nil {
+		return s.project.merge(s.v)
+	}
+
+	return nil
+}
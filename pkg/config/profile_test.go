@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+)
+
+func TestProfilesOverrideAndInheritBaseSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	homedir.Reset()
+	t.Cleanup(homedir.Reset)
+
+	store, err := NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Set(ExecRuntime, "builtin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(PolicyFSRoot, "./fixtures"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.UseProfile("Staging"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ValidateProfile(); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("expected undefined profile error, got %v", err)
+	}
+	if err := store.Set(ExecRuntime, "https://staging.example"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ValidateProfile(); err != nil {
+		t.Fatalf("expected profile to be defined, got %v", err)
+	}
+
+	opts := store.GetRuntimeOptions()
+	if opts.Type != "https://staging.example" {
+		t.Fatalf("expected profile runtime, got %q", opts.Type)
+	}
+	if got, _ := store.Get(PolicyFSRoot); got != "./fixtures" {
+		t.Fatalf("expected base filesystem root to be inherited, got %v", got)
+	}
+
+	if names := store.Profiles(); !slices.Equal(names, []string{"staging"}) {
+		t.Fatalf("unexpected profiles: %v", names)
+	}
+
+	contents, err := os.ReadFile(filepath.Join(home, ".ferret", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "runtime: builtin") || !strings.Contains(string(contents), "runtime: https://staging.example") {
+		t.Fatalf("expected base and profile runtimes in config file:\n%s", contents)
+	}
+
+	if err := store.Unset(ExecRuntime); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(ExecRuntime); got != "builtin" {
+		t.Fatalf("expected base runtime after unsetting the profile value, got %v", got)
+	}
+
+	if err := store.UseProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(ExecRuntime); got != "builtin" {
+		t.Fatalf("expected base runtime without a profile, got %v", got)
+	}
+}
+
+func TestProfileSelectedFromFlagOrEnvironment(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	homedir.Reset()
+	t.Cleanup(homedir.Reset)
+
+	store, err := NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, profile := range []string{"ci", "staging"} {
+		if err := store.UseProfile(profile); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Set(ExecRuntime, "https://"+profile+".example"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newCommand := func(args ...string) *cobra.Command {
+		command := &cobra.Command{Use: "profile-test"}
+		AddProfileFlag(command.Flags())
+		command.Flags().String(ExecRuntime, "", "")
+
+		if err := command.Flags().Parse(args); err != nil {
+			t.Fatal(err)
+		}
+
+		return command
+	}
+
+	t.Setenv("FERRET_PROFILE", "ci")
+	store.BindFlags(newCommand())
+	if got := store.GetRuntimeOptions().Type; got != "https://ci.example" {
+		t.Fatalf("expected the environment profile, got %q", got)
+	}
+
+	store.BindFlags(newCommand("--profile", "staging"))
+	if got := store.GetRuntimeOptions().Type; got != "https://staging.example" {
+		t.Fatalf("expected the flag profile to win over the environment, got %q", got)
+	}
+
+	store.BindFlags(newCommand("--profile", "a.b"))
+	if err := store.ValidateProfile(); !errors.Is(err, ErrInvalidProfile) {
+		t.Fatalf("expected invalid profile error, got %v", err)
+	}
+	if err := store.Set(ExecRuntime, "builtin"); !errors.Is(err, ErrInvalidProfile) {
+		t.Fatalf("expected set to reject the invalid profile, got %v", err)
+	}
+}
@@ -0,0 +1,409 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ProjectFile is the project-local config file, looked up from the working
+	// directory towards the filesystem root.
+	ProjectFile = "ferret.config.yaml"
+	// ModuleFile is the module manifest, whose "cli" section is used when a
+	// directory has no ProjectFile.
+	ModuleFile = "ferret.yaml"
+
+	moduleSection = "cli"
+	projectParams = "params"
+)
+
+// projectKind is the type a project config key must have.
+type projectKind int
+
+const (
+	kindString projectKind = iota
+	kindPath
+	kindBool
+	kindInt
+	kindDuration
+	kindList
+	kindHeaders
+	kindCaseMode
+)
+
+// projectKeys is the subset of settings a project may set: policies,
+// browser settings, and formatter options. Params are handled apart.
+var projectKeys = map[string]projectKind{
+	PolicyFSRoot:                    kindPath,
+	PolicyFSReadOnly:                kindBool,
+	PolicyHTTPAllowedSchemes:        kindList,
+	PolicyHTTPAllowedMethods:        kindList,
+	PolicyHTTPAllowedHosts:          kindList,
+	PolicyHTTPBlockedHosts:          kindList,
+	PolicyHTTPAllowLocalhost:        kindBool,
+	PolicyHTTPAllowPrivateNetworks:  kindBool,
+	PolicyHTTPAllowLinkLocal:        kindBool,
+	PolicyHTTPDefaultHeaders:        kindHeaders,
+	PolicyHTTPBlockedRequestHeaders: kindList,
+	PolicyHTTPTimeout:               kindDuration,
+	PolicyHTTPNoTimeout:             kindBool,
+	PolicyHTTPMaxRequestSize:        kindInt,
+	PolicyHTTPUnlimitedRequestSize:  kindBool,
+	PolicyHTTPMaxResponseSize:       kindInt,
+	PolicyHTTPUnlimitedResponseSize: kindBool,
+	PolicyHTTPMaxResponseHeaderSize: kindInt,
+	PolicyHTTPFollowRedirects:       kindBool,
+	PolicyHTTPMaxRedirects:          kindInt,
+
+	ExecBrowserAddress:      kindString,
+	ExecWithBrowser:         kindBool,
+	ExecWithBrowserHeadless: kindBool,
+	ExecKeepCookies:         kindBool,
+	ExecProxy:               kindString,
+	ExecUserAgent:           kindString,
+	BrowserPort:             kindInt,
+	BrowserHeadless:         kindBool,
+	BrowserDetach:           kindBool,
+	BrowserUserDir:          kindPath,
+
+	FormatPrintWidth:     kindInt,
+	FormatTabWidth:       kindInt,
+	FormatSingleQuote:    kindBool,
+	FormatBracketSpacing: kindBool,
+	FormatCaseMode:       kindCaseMode,
+}
+
+// project is a validated project config.
+type project struct {
+	// file is the path the config was read from.
+	file string
+	// settings are the config keys, with values as the matching flags parse
+	// them.
+	settings map[string]any
+	// params are the default runtime parameters.
+	params map[string]any
+	// ignored are the policy keys left out of the last merge because the
+	// user config locks them.
+	ignored []string
+}
+
+// merge layers the project settings over the user config in v. When the
+// user config sets ProjectLockPolicies, the policy keys it or its active
+// profile sets are kept instead, so a checked-out repository cannot loosen
+// them.
+func (p *project) merge(v *viper.Viper) error {
+	settings := make(map[string]any, len(p.settings))
+	locked := v.GetBool(ProjectLockPolicies)
+	p.ignored = nil
+
+	for key, value := range p.settings {
+		if locked && strings.HasPrefix(key, "policy-") && v.InConfig(key) {
+			p.ignored = append(p.ignored, key)
+			continue
+		}
+
+		settings[key] = value
+	}
+
+	sort.Strings(p.ignored)
+
+	return v.MergeConfigMap(settings)
+}
+
+// findProject walks up from dir to the first directory with a project
+// config and loads it. It returns nil when there is none.
+func findProject(dir string) (*project, error) {
+	for {
+		file := filepath.Join(dir, ProjectFile)
+		raw, err := readProjectFile(file, "")
+
+		if raw == nil && err == nil {
+			file = filepath.Join(dir, ModuleFile)
+			raw, err = readProjectFile(file, moduleSection)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if raw != nil {
+			return parseProject(file, raw)
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+// readProjectFile returns the config in file, or in its section when one is
+// given. It returns nil when the file or section does not exist.
+func readProjectFile(file, section string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+
+	raw := make(map[string]any)
+
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	if section == "" {
+		return raw, nil
+	}
+
+	value, ok := raw[section]
+
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	sectionRaw, ok := value.(map[string]any)
+
+	if !ok {
+		return nil, fmt.Errorf("%s: %q must be a mapping", file, section)
+	}
+
+	return sectionRaw, nil
+}
+
+func parseProject(file string, raw map[string]any) (*project, error) {
+	p := &project{
+		file:     file,
+		settings: make(map[string]any, len(raw)),
+	}
+
+	keys := make([]string, 0, len(raw))
+
+	for key := range raw {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := raw[key]
+
+		if key == projectParams {
+			params, err := parseProjectParams(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q %w", file, key, err)
+			}
+
+			p.params = params
+
+			continue
+		}
+
+		kind, ok := projectKeys[key]
+
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown key %q; supported keys are %s", file, key, strings.Join(projectKeyNames(), ", "))
+		}
+
+		normalized, err := normalizeProjectValue(kind, value, filepath.Dir(file))
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q %w", file, key, err)
+		}
+
+		p.settings[key] = normalized
+	}
+
+	return p, nil
+}
+
+// normalizeProjectValue checks value against kind and converts it to the
+// form its flag parses. Relative paths are resolved against dir.
+func normalizeProjectValue(kind projectKind, value any, dir string) (any, error) {
+	switch kind {
+	case kindString, kindPath, kindCaseMode:
+		str, ok := value.(string)
+
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+
+		if kind == kindCaseMode {
+			switch strings.ToLower(str) {
+			case "lower", "upper", "ignore":
+			default:
+				return nil, errors.New("must be lower, upper, or ignore")
+			}
+		}
+
+		if kind == kindPath && str != "" && !filepath.IsAbs(str) {
+			str = filepath.Join(dir, str)
+		}
+
+		return str, nil
+	case kindBool:
+		b, ok := value.(bool)
+
+		if !ok {
+			return nil, errors.New("must be true or false")
+		}
+
+		return b, nil
+	case kindInt:
+		switch n := value.(type) {
+		case int64:
+			if n >= 0 {
+				return n, nil
+			}
+		case uint64:
+			return n, nil
+		case int:
+			if n >= 0 {
+				return n, nil
+			}
+		}
+
+		return nil, errors.New("must be a non-negative integer")
+	case kindDuration:
+		str, ok := value.(string)
+
+		if !ok {
+			return nil, errors.New("must be a duration such as 30s")
+		}
+
+		if _, err := time.ParseDuration(str); err != nil {
+			return nil, errors.New("must be a duration such as 30s")
+		}
+
+		return str, nil
+	case kindList:
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+
+		items, ok := value.([]any)
+
+		if !ok {
+			return nil, errors.New("must be a list of strings")
+		}
+
+		list := make([]string, 0, len(items))
+
+		for _, item := range items {
+			str, ok := item.(string)
+
+			if !ok {
+				return nil, errors.New("must be a list of strings")
+			}
+
+			list = append(list, str)
+		}
+
+		return strings.Join(list, ","), nil
+	case kindHeaders:
+		headers, ok := value.(map[string]any)
+
+		if !ok {
+			return nil, errors.New("must be a mapping of header names to strings")
+		}
+
+		for _, header := range headers {
+			if _, ok := header.(string); !ok {
+				return nil, errors.New("must be a mapping of header names to strings")
+			}
+		}
+
+		data, err := json.Marshal(headers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return string(data), nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %d", kind)
+	}
+}
+
+// parseProjectParams checks that params is a mapping and normalizes its
+// values to the types --param produces, so numbers become float64.
+func parseProjectParams(value any) (map[string]any, error) {
+	if _, ok := value.(map[string]any); !ok {
+		return nil, errors.New("must be a mapping of parameter names to values")
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]any)
+
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+func projectKeyNames() []string {
+	names := make([]string, 0, len(projectKeys)+1)
+
+	for key := range projectKeys {
+		names = append(names, key)
+	}
+
+	names = append(names, projectParams)
+	sort.Strings(names)
+
+	return names
+}
+
+// ReportProject tells the user which project config is in effect and which
+// of its policies the user config locks. Commands that run scripts call it;
+// it reports once per process.
+func (s *Store) ReportProject(w io.Writer) {
+	if s.project == nil || s.projectReported {
+		return
+	}
+
+	s.projectReported = true
+
+	fmt.Fprintf(w, "Using project config %s\n", s.project.file)
+
+	for _, key := range s.project.ignored {
+		fmt.Fprintf(w, "warning: ignoring %s from %s; the user config locks it with %s\n", key, s.project.file, ProjectLockPolicies)
+	}
+}
+
+// DefaultParams returns a copy of the project's default runtime parameters.
+func (s *Store) DefaultParams() map[string]any {
+	params := make(map[string]any)
+
+	if s.project != nil {
+		for name, value := range s.project.params {
+			params[name] = value
+		}
+	}
+
+	return params
+}
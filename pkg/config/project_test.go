@@ -0,0 +1,274 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func writeProjectTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindProjectWalksUpAndNormalizesValues(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "queries", "scrapers")
+
+	writeProjectTestFile(t, filepath.Join(root, ProjectFile), `
+policy-fs-root: ./fixtures
+policy-http-allowed-hosts:
+  - api.example.com
+  - cdn.example.com
+policy-http-default-headers:
+  X-Trace: project
+policy-http-timeout: 5s
+browser-headless: true
+print-width: 120
+params:
+  env: staging
+  retries: 3
+`)
+
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := findProject(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.file != filepath.Join(root, ProjectFile) {
+		t.Fatalf("expected the project file in %s, got %+v", root, p)
+	}
+
+	want := map[string]any{
+		PolicyFSRoot:             filepath.Join(root, "fixtures"),
+		PolicyHTTPAllowedHosts:   "api.example.com,cdn.example.com",
+		PolicyHTTPDefaultHeaders: `{"X-Trace":"project"}`,
+		PolicyHTTPTimeout:        "5s",
+		ExecWithBrowserHeadless:  true,
+	}
+
+	for key, value := range want {
+		if p.settings[key] != value {
+			t.Fatalf("expected %s to be %v, got %v", key, value, p.settings[key])
+		}
+	}
+
+	if p.params["env"] != "staging" || p.params["retries"] != float64(3) {
+		t.Fatalf("unexpected params: %#v", p.params)
+	}
+}
+
+func TestFindProjectUsesModuleManifestSection(t *testing.T) {
+	root := t.TempDir()
+	module := filepath.Join(root, "module")
+
+	writeProjectTestFile(t, filepath.Join(root, ModuleFile), "name: outer\ncli:\n  policy-fs-read-only: true\n")
+	writeProjectTestFile(t, filepath.Join(module, ModuleFile), "name: inner\n")
+
+	p, err := findProject(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.file != filepath.Join(root, ModuleFile) || p.settings[PolicyFSReadOnly] != true {
+		t.Fatalf("expected the outer module's cli section, got %+v", p)
+	}
+
+	writeProjectTestFile(t, filepath.Join(module, ProjectFile), "policy-fs-read-only: false\n")
+
+	p, err = findProject(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.file != filepath.Join(module, ProjectFile) || p.settings[PolicyFSReadOnly] != false {
+		t.Fatalf("expected the nearer project file, got %+v", p)
+	}
+}
+
+func TestFindProjectRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		err      string
+	}{
+		{name: "unknown key", contents: "runtime: https://worker.example\n", err: `unknown key "runtime"`},
+		{name: "wrong type", contents: "policy-fs-read-only: yes please\n", err: "must be true or false"},
+		{name: "bad duration", contents: "policy-http-timeout: soon\n", err: "must be a duration"},
+		{name: "negative size", contents: "policy-http-max-request-size: -1\n", err: "non-negative integer"},
+		{name: "bad case mode", contents: "case-mode: title\n", err: "lower, upper, or ignore"},
+		{name: "params list", contents: "params: [1, 2]\n", err: "mapping of parameter names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeProjectTestFile(t, filepath.Join(dir, ProjectFile), tt.contents)
+
+			_, err := findProject(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.err) || !strings.Contains(err.Error(), ProjectFile) {
+				t.Fatalf("expected error with %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestProjectConfigLayersOverUserConfig(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	t.Setenv("HOME", home)
+	homedir.Reset()
+	t.Cleanup(homedir.Reset)
+
+	store, err := NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(PolicyFSRoot, "/srv/global"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(PolicyFSReadOnly, "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeProjectTestFile(t, filepath.Join(project, ProjectFile), "policy-http-max-redirects: 3\nparams:\n  env: local\n")
+	t.Chdir(project)
+
+	store, err = NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := store.Get(PolicyHTTPMaxRedirects); got != uint64(3) {
+		t.Fatalf("expected the project policy, got %v", got)
+	}
+	if got, _ := store.Get(PolicyFSReadOnly); got != "true" {
+		t.Fatalf("expected the user setting to be kept, got %v", got)
+	}
+	if got := store.DefaultParams()["env"]; got != "local" {
+		t.Fatalf("expected project params, got %v", got)
+	}
+
+	if err := store.Set(ExecRuntime, "builtin"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(PolicyHTTPMaxRedirects); got != uint64(3) {
+		t.Fatalf("expected the project to stay layered after set, got %v", got)
+	}
+
+	contents, err := os.ReadFile(filepath.Join(home, ".ferret", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "/srv/global") || strings.Contains(string(contents), "fixtures") {
+		t.Fatalf("expected project values to stay out of the user config:\n%s", contents)
+	}
+}
+
+func TestProjectConfigPoliciesOverrideUserConfigUnlessLocked(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	t.Setenv("HOME", home)
+	homedir.Reset()
+	t.Cleanup(homedir.Reset)
+
+	store, err := NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(PolicyFSRoot, "/srv/global"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(PolicyFSReadOnly, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UseProfile("ci"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(PolicyHTTPAllowLocalhost, "false"); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(project, ProjectFile)
+	writeProjectTestFile(t, file, `
+policy-fs-root: /srv/project
+policy-fs-read-only: false
+policy-http-allow-localhost: true
+`)
+	t.Chdir(project)
+
+	store, err = NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := store.Get(PolicyFSRoot); got != "/srv/project" {
+		t.Fatalf("expected the project filesystem root, got %v", got)
+	}
+	if err := store.UseProfile("ci"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(PolicyHTTPAllowLocalhost); got != true {
+		t.Fatalf("expected the project policy over the profile, got %v", got)
+	}
+
+	var report strings.Builder
+	store.ReportProject(&report)
+	store.ReportProject(&report)
+
+	if report.String() != "Using project config "+file+"\n" {
+		t.Fatalf("unexpected report:\n%s", report.String())
+	}
+
+	if err := store.UseProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ProjectLockPolicies, "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := store.Get(PolicyFSRoot); got != "/srv/global" {
+		t.Fatalf("expected the locked filesystem root, got %v", got)
+	}
+	if got, _ := store.Get(PolicyFSReadOnly); got != "true" {
+		t.Fatalf("expected the locked read-only policy, got %v", got)
+	}
+	if got, _ := store.Get(PolicyHTTPAllowLocalhost); got != true {
+		t.Fatalf("expected the project policy the user config does not set, got %v", got)
+	}
+
+	if err := store.UseProfile("ci"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(PolicyHTTPAllowLocalhost); got != "false" {
+		t.Fatalf("expected the locked profile policy, got %v", got)
+	}
+
+	report.Reset()
+	store.ReportProject(&report)
+
+	want := "Using project config " + file + "\n"
+	for _, key := range []string{PolicyFSReadOnly, PolicyFSRoot, PolicyHTTPAllowLocalhost} {
+		want += "warning: ignoring " + key + " from " + file + "; the user config locks it with " + ProjectLockPolicies + "\n"
+	}
+
+	if report.String() != want {
+		t.Fatalf("unexpected report:\n%s", report.String())
+	}
+}
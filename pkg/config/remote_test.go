@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+func TestRemoteConfigKeysReachRuntimeOptions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	homedir.Reset()
+	t.Cleanup(homedir.Reset)
+
+	store, err := NewStore("ferret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.GetRuntimeOptions().Remote; got.Retries != runtime.DefaultRemoteRetries {
+		t.Fatalf("expected default retries, got %+v", got)
+	}
+
+	if err := store.Set(RemoteToken, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(RemoteTimeout, "45s"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(RemoteRetries, "5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(RemoteCACert, "/etc/ferret/ca.pem"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := runtime.RemoteOptions{
+		Token:   "secret",
+		CACert:  "/etc/ferret/ca.pem",
+		Timeout: 45 * time.Second,
+		Retries: 5,
+	}
+
+	if got := store.GetRuntimeOptions().Remote; got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
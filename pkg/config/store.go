@@ -1,6 +1,7 @@
 package config
 
 import (
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -18,10 +19,17 @@ type (
 	}
 
 	Store struct {
-		appName   string
-		version   string
-		envPrefix string
-		v         *viper.Viper
+		appName    string
+		version    string
+		envPrefix  string
+		dir        string
+		v          *viper.Viper
+		profile    string
+		profileErr error
+		project    *project
+		// projectReported is set once the project config has been named
+		// on stderr.
+		projectReported bool
 	}
 )
 
@@ -42,6 +50,26 @@ func NewStore(appName, version string) (*Store, error) {
 		}
 	}
 
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The project config sits between the config file and the environment:
+	// it is merged into the file-backed layer on top of the user's settings.
+	project, err := findProject(cwd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if project != nil {
+		if err := project.merge(v); err != nil {
+			return nil, err
+		}
+	}
+
 	envPrefix := strings.ToUpper(appName)
 
 	// When we bind flags to environment variables expect that the
@@ -55,13 +83,25 @@ func NewStore(appName, version string) (*Store, error) {
 	// like --favorite-color which we fix in the bindFlags function
 	v.AutomaticEnv()
 
-	return &Store{appName, version, envPrefix, v}, nil
+	dir, err := configDir(appName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{appName: appName, version: version, envPrefix: envPrefix, dir: dir, v: v, project: project}, nil
 }
 
 func (s *Store) AppName() string {
 	return s.appName
 }
 
+// Dir returns the per-user directory that holds the config file and other
+// CLI state such as prompt history. It is empty for a zero Store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
 func (s *Store) AppVersion() string {
 	return s.version
 }
@@ -74,8 +114,12 @@ func (s *Store) Repo() string {
 	return "cli"
 }
 
-// Bind the current command's flags to viper
+// Bind the current command's flags to viper, after layering the profile
+// selected by --profile or the environment over the config file. An invalid
+// profile name leaves the base settings in place; ValidateProfile reports it.
 func (s *Store) BindFlags(cmd *cobra.Command) {
+	s.profileErr = s.UseProfile(s.profileFrom(cmd))
+
 	bindFlagsFor(s.v, cmd, s.envPrefix)
 }
 
@@ -132,6 +176,48 @@ func (s *Store) GetRuntimeOptions() runtime.Options {
 		opts.UserAgent = s.v.GetString(ExecUserAgent)
 	}
 
+	opts.Remote = s.GetRemoteOptions()
+
+	return opts
+}
+
+// GetRemoteOptions returns the authentication, TLS, timeout, and retry
+// settings used to talk to a remote runtime.
+func (s *Store) GetRemoteOptions() runtime.RemoteOptions {
+	opts := runtime.NewDefaultRemoteOptions()
+
+	if s.v.IsSet(RemoteToken) {
+		opts.Token = s.v.GetString(RemoteToken)
+	}
+
+	if s.v.IsSet(RemoteUsername) {
+		opts.Username = s.v.GetString(RemoteUsername)
+	}
+
+	if s.v.IsSet(RemotePassword) {
+		opts.Password = s.v.GetString(RemotePassword)
+	}
+
+	if s.v.IsSet(RemoteCACert) {
+		opts.CACert = s.v.GetString(RemoteCACert)
+	}
+
+	if s.v.IsSet(RemoteClientCert) {
+		opts.ClientCert = s.v.GetString(RemoteClientCert)
+	}
+
+	if s.v.IsSet(RemoteClientKey) {
+		opts.ClientKey = s.v.GetString(RemoteClientKey)
+	}
+
+	if s.v.IsSet(RemoteTimeout) {
+		opts.Timeout = s.v.GetDuration(RemoteTimeout)
+	}
+
+	if s.v.IsSet(RemoteRetries) {
+		opts.Retries = s.v.GetInt(RemoteRetries)
+	}
+
 	return opts
 }
 
@@ -165,58 +251,123 @@ func (s *Store) Get(key string) (interface{}, error) {
 	return s.v.Get(key), nil
 }
 
+// Set persists a configuration value in the active profile, or in the base
+// settings when no profile is active.
 func (s *Store) Set(key, val string) error {
 	if !isSupportedFlag(key) {
 		return ErrInvalidFlag
 	}
 
-	s.v.Set(key, val)
+	if s.profileErr != nil {
+		return s.profileErr
+	}
+
+	// Only the file is written, so that neither flag values nor the base
+	// settings merged under a profile in memory are copied into it.
+	persisted, err := s.persisted()
 
-	return s.v.WriteConfig()
+	if err != nil {
+		return err
+	}
+
+	settings := persisted.AllSettings()
+	s.section(settings)[key] = val
+
+	if err := s.write(settings); err != nil {
+		return err
+	}
+
+	return s.reload()
 }
 
-// Unset removes a persisted configuration value without affecting flags or
-// environment variables. Calling Unset for a supported key that is not present
-// in the config file is a no-op.
+// Unset removes a persisted configuration value, from the active profile when
+// there is one, without affecting flags or environment variables. Calling
+// Unset for a supported key that is not present in the config file is a no-op.
 func (s *Store) Unset(key string) error {
 	if !isSupportedFlag(key) {
 		return ErrInvalidFlag
 	}
 
-	configFile := s.v.ConfigFileUsed()
+	if s.profileErr != nil {
+		return s.profileErr
+	}
+
+	persisted, err := s.persisted()
+
+	if err != nil {
+		return err
+	}
+
+	fileKey := key
+
+	if s.profile != "" {
+		fileKey = s.profileKey(key)
+	}
+
+	if persisted.IsSet(fileKey) {
+		settings := persisted.AllSettings()
+		delete(s.section(settings), key)
+
+		if err := s.write(settings); err != nil {
+			return err
+		}
+	}
+
+	// Clear a value previously installed through Set, then reload only the
+	// file-backed layer so existing flag and environment bindings remain intact.
+	s.v.Set(key, nil)
+
+	return s.reload()
+}
+
+// persisted reads the config file alone, without flags, environment
+// variables, or the active profile.
+func (s *Store) persisted() (*viper.Viper, error) {
 	persisted := viper.New()
-	persisted.SetConfigFile(configFile)
+	persisted.SetConfigFile(s.v.ConfigFileUsed())
 
 	if err := persisted.ReadInConfig(); err != nil {
-		return err
+		return nil, err
 	}
 
-	if !persisted.IsSet(key) {
-		s.v.Set(key, nil)
+	return persisted, nil
+}
 
-		return s.v.ReadInConfig()
+// section returns the settings that keys of the active profile are written
+// to, creating the profile if needed.
+func (s *Store) section(settings map[string]any) map[string]any {
+	if s.profile == "" {
+		return settings
 	}
 
-	settings := persisted.AllSettings()
-	delete(settings, key)
+	profiles, ok := settings[profilesKey].(map[string]any)
+
+	if !ok {
+		profiles = make(map[string]any)
+		settings[profilesKey] = profiles
+	}
 
+	profile, ok := profiles[s.profile].(map[string]any)
+
+	if !ok {
+		profile = make(map[string]any)
+		profiles[s.profile] = profile
+	}
+
+	return profile
+}
+
+// write replaces the config file with settings.
+func (s *Store) write(settings map[string]any) error {
 	next := viper.New()
-	next.SetConfigFile(configFile)
+	next.SetConfigFile(s.v.ConfigFileUsed())
 	next.SetConfigType("yaml")
 
 	if err := next.MergeConfigMap(settings); err != nil {
 		return err
 	}
 
-	if err := next.WriteConfig(); err != nil {
-		return err
-	}
-
-	// Clear a value previously installed through Set, then reload only the
-	// file-backed layer so existing flag and environment bindings remain intact.
-	s.v.Set(key, nil)
-
-	return s.v.ReadInConfig()
+	return next.WriteConfig()
 }
 
 func (s *Store) List() []KV {
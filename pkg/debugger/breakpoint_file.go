@@ -0,0 +1,153 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MontFerret/ferret/v2"
+)
+
+// BreakpointFileName is the per-project file that "ferret debug" loads
+// breakpoints from when a prompt starts and saves them to when it quits.
+const BreakpointFileName = ".ferret-breakpoints"
+
+// ReadBreakpoints parses break and logpoint commands, one per line, in the
+// syntax accepted by ParseCommand. Blank lines and lines starting with # are
+// skipped.
+func ReadBreakpoints(r io.Reader) ([]Command, error) {
+	var commands []Command
+
+	scanner := bufio.NewScanner(r)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		command, err := ParseCommand(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		if command.Name != CommandBreak && command.Name != CommandLogpoint {
+			return nil, fmt.Errorf("line %d: expected a break or logpoint command, got %s", number, command.Name)
+		}
+
+		commands = append(commands, command)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+// LoadBreakpointFile reads the breakpoints saved at path. A missing file holds
+// no breakpoints.
+func LoadBreakpointFile(path string) ([]Command, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	commands, err := ReadBreakpoints(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return commands, nil
+}
+
+// ParseBreakpointFlag parses the value of a --break flag, which takes the
+// arguments of the break command, for example "--exact lib.fql:40 if x > 1".
+func ParseBreakpointFlag(value string) (Command, error) {
+	return ParseCommand(string(CommandBreak) + " " + value)
+}
+
+// FormatBreakpoint writes a breakpoint as the break or logpoint command that
+// recreates it. The requested location is used, so the command binds the same
+// way after the script is edited.
+func FormatBreakpoint(breakpoint ferret.DebugBreakpoint, rule *BreakpointRule) string {
+	location := ferret.DebugSourceLocation{
+		File:   breakpoint.File,
+		Line:   breakpoint.RequestedLine,
+		Column: breakpoint.RequestedColumn,
+	}
+
+	var saved BreakpointRule
+	if rule != nil {
+		saved = *rule
+	}
+
+	return formatBreakpointCommand(location, ferret.DebugBreakpointOptions{BindingMode: breakpoint.BindingMode}, saved)
+}
+
+func formatBreakpointCommand(location ferret.DebugSourceLocation, options ferret.DebugBreakpointOptions, rule BreakpointRule) string {
+	parts := []string{string(CommandBreak)}
+
+	if rule.IsLogpoint() {
+		parts[0] = string(CommandLogpoint)
+	}
+
+	switch options.BindingMode {
+	case ferret.DebugBreakpointBindExact:
+		parts = append(parts, "--exact")
+	case ferret.DebugBreakpointBindNextExecutableInFunction:
+		parts = append(parts, "--in-function")
+	}
+
+	target := formatSourceLocation(location.File, location.Line, location.Column)
+	if location.File == "" {
+		target = strings.TrimPrefix(target, ":")
+	}
+
+	parts = append(parts, target)
+
+	if rule.IsLogpoint() {
+		return strings.Join(append(parts, strconv.Quote(rule.LogMessage)), " ")
+	}
+
+	if rule.HitCount > 0 {
+		parts = append(parts, fmt.Sprintf("hits=%d", rule.HitCount))
+	}
+
+	if rule.Condition != "" {
+		parts = append(parts, "if", rule.Condition)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// saveBreakpoints writes the session's breakpoints to path, followed by the
+// lines that could not be set in this session.
+func saveBreakpoints(path string, session Session, rules BreakpointRules, retained []string) error {
+	var builder strings.Builder
+
+	for _, breakpoint := range session.Breakpoints() {
+		builder.WriteString(FormatBreakpoint(breakpoint, rules[breakpoint.ID]))
+		builder.WriteByte('\n')
+	}
+
+	for _, line := range retained {
+		builder.WriteString(line)
+		builder.WriteByte('\n')
+	}
+
+	return os.WriteFile(path, []byte(builder.String()), 0o644)
+}
@@ -0,0 +1,112 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+func TestReadBreakpoints(t *testing.T) {
+	commands, err := ReadBreakpoints(strings.NewReader("# saved\nbreak --exact 2 hits=3 if x > 1\n\nlogpoint lib.fql:4 \"x={{ x }}\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 2 || commands[0].Name != CommandBreak || commands[1].Name != CommandLogpoint {
+		t.Fatalf("unexpected commands: %#v", commands)
+	}
+
+	for _, command := range commands {
+		line := formatBreakpointCommand(command.Location, command.BreakpointOptions, command.BreakpointRule)
+		if reparsed, err := ParseCommand(line); err != nil || reparsed.BreakpointRule != command.BreakpointRule ||
+			reparsed.Location != command.Location || reparsed.BreakpointOptions != command.BreakpointOptions {
+			t.Fatalf("formatted %q does not round-trip: %#v, %v", line, reparsed, err)
+		}
+	}
+
+	if _, err := ReadBreakpoints(strings.NewReader("break 1\ncontinue\n")); err == nil || !strings.Contains(err.Error(), "line 2: expected a break or logpoint command") {
+		t.Fatalf("expected line error, got %v", err)
+	}
+}
+
+func TestScriptLoadsAndSavesBreakpointFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BreakpointFileName)
+	if err := os.WriteFile(path, []byte("# project breakpoints\nbreak 2 hits=2\nbreak lib.fql:4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	duplicate, err := ParseBreakpointFlag("2 hits=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact, err := ParseBreakpointFlag("--exact 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		rejectFile: "lib.fql",
+	}
+	var out bytes.Buffer
+
+	err = Script(context.Background(), session, source.New("demo.fql", "LET x = 1\nRETURN x"), strings.NewReader("delete 1\n"), &out, Options{
+		Breakpoints:    []Command{duplicate, exact},
+		BreakpointFile: path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "break --exact demo.fql:1\nbreak lib.fql:4\n"; got != want {
+		t.Fatalf("unexpected saved breakpoints: got %q, want %q", got, want)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"Breakpoint 1 set at demo.fql:2 (next-file).",
+		"Breakpoint 2 set at demo.fql:1 (exact).",
+		"Kept 1 breakpoint(s) from " + path + " that do not apply to this script.",
+		"Breakpoints saved to " + path + ".",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+}
+
+func TestScriptLeavesUnchangedBreakpointFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BreakpointFileName)
+	content := "# keep this comment\nbreak 2\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	session := &fakeSession{startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{})}
+	var out bytes.Buffer
+
+	err := Script(context.Background(), session, source.New("demo.fql", "LET x = 1\nRETURN x"), strings.NewReader("breakpoints\n"), &out, Options{BreakpointFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Fatalf("expected breakpoint file to be untouched, got %q", data)
+	}
+	if session.setBreakpointCalls != 1 {
+		t.Fatalf("expected saved breakpoint to be set, got %d calls", session.setBreakpointCalls)
+	}
+}
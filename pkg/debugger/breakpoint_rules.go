@@ -0,0 +1,307 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MontFerret/ferret/v2"
+)
+
+// BreakpointRule holds the prompt-side behavior attached to a core breakpoint.
+// The core engine only reports that a location was reached; conditions, hit
+// counts, and log messages are evaluated by the prompt when it fires.
+type BreakpointRule struct {
+	// Condition is a safe debugger expression that must be truthy to count a hit.
+	Condition string
+	// HitCount pauses only once the breakpoint has been hit this many times.
+	HitCount int
+	// LogMessage turns the breakpoint into a logpoint that never pauses.
+	// Expressions wrapped in {{ }} are evaluated and interpolated.
+	LogMessage string
+	// Hits counts how many times the breakpoint was reached with a truthy condition.
+	Hits int
+}
+
+// BreakpointRules maps core breakpoint IDs to their prompt-side rules.
+type BreakpointRules map[ferret.DebugBreakpointID]*BreakpointRule
+
+// IsZero reports whether the rule adds no behavior to a plain breakpoint.
+func (r BreakpointRule) IsZero() bool {
+	return r.Condition == "" && r.HitCount == 0 && r.LogMessage == ""
+}
+
+// IsLogpoint reports whether the rule logs a message instead of pausing.
+func (r BreakpointRule) IsLogpoint() bool {
+	return r.LogMessage != ""
+}
+
+// IsZero reports whether no rule can decline to pause.
+func (r BreakpointRules) IsZero() bool {
+	for _, rule := range r {
+		if rule != nil && !rule.IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resume runs a resume command and resolves the breakpoints it stops at.
+func resume(ctx context.Context, session Session, rules BreakpointRules, renderer *Renderer, command CommandName) (*ferret.DebugEvent, error) {
+	depth := -1
+
+	if !rules.IsZero() {
+		depth = frameDepth(session)
+	}
+
+	event, err := issue(ctx, session, command)
+
+	return resolveBreakpoint(ctx, session, rules, renderer, command, depth, event, err)
+}
+
+// resolveBreakpoint keeps resuming while every breakpoint hit by event has a
+// rule that declines to pause, so false conditions and logpoints behave as if
+// execution never stopped. A step interrupted by such a breakpoint goes on
+// until it reaches the frame it was aiming for; depth is the frame depth the
+// command started from, or -1 when it is unknown.
+func resolveBreakpoint(ctx context.Context, session Session, rules BreakpointRules, renderer *Renderer, command CommandName, depth int, event *ferret.DebugEvent, err error) (*ferret.DebugEvent, error) {
+	last := command
+
+	for err == nil && event != nil {
+		switch event.Reason {
+		case ferret.DebugReasonBreakpoint:
+			if shouldPause(ctx, session, rules, renderer, event.HitBreakpointIDs) {
+				return event, nil
+			}
+		case ferret.DebugReasonStep:
+			if command == CommandContinue {
+				return event, nil
+			}
+		default:
+			return event, nil
+		}
+
+		current := -1
+
+		if depth >= 0 {
+			current = frameDepth(session)
+		}
+
+		next, done := stepRemainder(command, last, depth, current)
+
+		if done {
+			if event.Reason == ferret.DebugReasonBreakpoint {
+				stepped := *event
+				stepped.Reason = ferret.DebugReasonStep
+				stepped.HitBreakpointIDs = nil
+				event = &stepped
+			}
+
+			return event, nil
+		}
+
+		last = next
+		event, err = issue(ctx, session, next)
+	}
+
+	return event, err
+}
+
+// stepRemainder returns the command that finishes command after the session
+// paused at current frame depth, having last issued last. It reports done
+// when the pause is where command would have stopped on its own.
+func stepRemainder(command, last CommandName, start, current int) (CommandName, bool) {
+	if command != CommandContinue && (start < 0 || current < 0) {
+		return "", true
+	}
+
+	switch command {
+	case CommandStep:
+		return "", true
+	case CommandNext:
+		if current > start {
+			return CommandOut, false
+		}
+
+		if current == start && last == CommandOut {
+			return CommandNext, false
+		}
+
+		return "", true
+	case CommandOut:
+		if current >= start {
+			return CommandOut, false
+		}
+
+		return "", true
+	default:
+		return CommandContinue, false
+	}
+}
+
+func issue(ctx context.Context, session Session, command CommandName) (*ferret.DebugEvent, error) {
+	switch command {
+	case CommandStep:
+		return session.Step(ctx)
+	case CommandNext:
+		return session.Next(ctx)
+	case CommandOut:
+		return session.Out(ctx)
+	default:
+		return session.Continue(ctx)
+	}
+}
+
+func frameDepth(session Session) int {
+	frames, err := session.Frames()
+
+	if err != nil {
+		return -1
+	}
+
+	return len(frames)
+}
+
+func shouldPause(ctx context.Context, session Session, rules BreakpointRules, renderer *Renderer, ids []ferret.DebugBreakpointID) bool {
+	if len(ids) == 0 {
+		return true
+	}
+
+	pause := false
+
+	for _, id := range ids {
+		rule := rules[id]
+
+		if rule == nil {
+			pause = true
+			continue
+		}
+
+		if rule.Condition != "" {
+			value, err := session.Evaluate(ctx, rule.Condition)
+
+			if err != nil {
+				renderer.Error(fmt.Sprintf("Breakpoint %d condition error", id), err)
+				pause = true
+				continue
+			}
+
+			if !isTruthy(value) {
+				continue
+			}
+		}
+
+		rule.Hits++
+
+		if rule.HitCount > 0 && rule.Hits < rule.HitCount {
+			continue
+		}
+
+		if rule.IsLogpoint() {
+			renderer.Logpoint(id, interpolateLogMessage(ctx, session, rule.LogMessage))
+			continue
+		}
+
+		pause = true
+	}
+
+	return pause
+}
+
+// interpolateLogMessage replaces every {{ expression }} in template with the
+// display value of the expression evaluated in the paused frame.
+func interpolateLogMessage(ctx context.Context, session Session, template string) string {
+	var out strings.Builder
+
+	for {
+		start := strings.Index(template, "{{")
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(template[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+
+		out.WriteString(template[:start])
+
+		expression := strings.TrimSpace(template[start+2 : start+2+end])
+		value, err := session.Evaluate(ctx, expression)
+
+		if err != nil {
+			fmt.Fprintf(&out, "<error: %s>", err)
+		} else {
+			out.WriteString(value.Display)
+		}
+
+		template = template[start+2+end+2:]
+	}
+
+	out.WriteString(template)
+
+	return out.String()
+}
+
+// isTruthy applies FQL truthiness to the value a condition evaluated to.
+// Values are displayed in their JSON form; values without one, such as
+// documents and elements, are objects and therefore true.
+func isTruthy(value ferret.DebugValue) bool {
+	var decoded any
+
+	if err := json.Unmarshal([]byte(value.Display), &decoded); err != nil {
+		display := strings.TrimSpace(value.Display)
+
+		return display != "" && display != "none"
+	}
+
+	switch typed := decoded.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case float64:
+		return typed != 0
+	case string:
+		return typed != ""
+	default:
+		return true
+	}
+}
+
+func describeBreakpointRule(rule *BreakpointRule) string {
+	if rule == nil || rule.IsZero() {
+		return "-"
+	}
+
+	parts := make([]string, 0, 2)
+
+	if rule.Condition != "" {
+		parts = append(parts, "if "+rule.Condition)
+	}
+
+	if rule.IsLogpoint() {
+		parts = append(parts, "log "+strconv.Quote(rule.LogMessage))
+	}
+
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func formatBreakpointHits(rule *BreakpointRule) string {
+	if rule == nil {
+		return "-"
+	}
+
+	if rule.HitCount > 0 {
+		return fmt.Sprintf("%d/%d", rule.Hits, rule.HitCount)
+	}
+
+	return strconv.Itoa(rule.Hits)
+}
@@ -16,6 +16,7 @@ const (
 	CommandEmpty       CommandName = ""
 	CommandHelp        CommandName = "help"
 	CommandBreak       CommandName = "break"
+	CommandLogpoint    CommandName = "logpoint"
 	CommandDelete      CommandName = "delete"
 	CommandBreakpoints CommandName = "breakpoints"
 	CommandContinue    CommandName = "continue"
@@ -26,6 +27,16 @@ const (
 	CommandWhere       CommandName = "where"
 	CommandLocals      CommandName = "locals"
 	CommandPrint       CommandName = "print"
+	CommandWatch       CommandName = "watch"
+	CommandUnwatch     CommandName = "unwatch"
+	CommandBreakChange CommandName = "break-on-change"
+	CommandList        CommandName = "list"
+	CommandUp          CommandName = "up"
+	CommandDown        CommandName = "down"
+	CommandFrame       CommandName = "frame"
+	CommandBack        CommandName = "back"
+	CommandReverse     CommandName = "reverse-continue"
+	CommandHistory     CommandName = "history"
 	CommandQuit        CommandName = "quit"
 )
 
@@ -34,11 +45,17 @@ type Command struct {
 	Argument          string
 	Location          ferret.DebugSourceLocation
 	BreakpointOptions ferret.DebugBreakpointOptions
+	BreakpointRule    BreakpointRule
 	BreakpointID      ferret.DebugBreakpointID
+	WatchID           int
+	// Count is the line for list, the number of frames for up and down, the
+	// frame index for frame, and the number of pauses for back.
+	Count int
 }
 
 var aliases = map[string]CommandName{
 	"b":    CommandBreak,
+	"lp":   CommandLogpoint,
 	"d":    CommandDelete,
 	"bp":   CommandBreakpoints,
 	"bl":   CommandBreakpoints,
@@ -51,6 +68,10 @@ var aliases = map[string]CommandName{
 	"p":    CommandPrint,
 	"e":    CommandPrint,
 	"eval": CommandPrint,
+	"boc":  CommandBreakChange,
+	"ls":   CommandList,
+	"f":    CommandFrame,
+	"rc":   CommandReverse,
 	"q":    CommandQuit,
 }
 
@@ -75,18 +96,28 @@ func ParseCommand(input string) (Command, error) {
 	command := Command{Name: commandName, Argument: argument}
 
 	switch commandName {
-	case CommandHelp, CommandBreakpoints, CommandContinue, CommandStep, CommandNext, CommandOut, CommandPause, CommandWhere, CommandLocals, CommandQuit:
+	case CommandHelp, CommandBreakpoints, CommandContinue, CommandStep, CommandNext, CommandOut, CommandPause, CommandWhere, CommandLocals, CommandReverse, CommandHistory, CommandQuit:
 		if argument != "" {
 			return Command{}, fmt.Errorf("%s does not accept arguments", commandName)
 		}
 	case CommandBreak:
-		location, options, err := parseBreakpoint(argument)
+		location, options, rule, err := parseBreakpoint(argument)
 		if err != nil {
 			return Command{}, err
 		}
 
 		command.Location = location
 		command.BreakpointOptions = options
+		command.BreakpointRule = rule
+	case CommandLogpoint:
+		location, options, rule, err := parseLogpoint(argument)
+		if err != nil {
+			return Command{}, err
+		}
+
+		command.Location = location
+		command.BreakpointOptions = options
+		command.BreakpointRule = rule
 	case CommandDelete:
 		id, err := parsePositiveNumber(argument, "usage: delete <breakpoint-id>")
 		if err != nil {
@@ -98,6 +129,50 @@ func ParseCommand(input string) (Command, error) {
 		if argument == "" {
 			return Command{}, fmt.Errorf("usage: print <expression>")
 		}
+	case CommandWatch:
+		// Without an expression, watch lists the current watches.
+	case CommandUnwatch:
+		id, err := parsePositiveNumber(argument, "usage: unwatch <watch-id>")
+		if err != nil {
+			return Command{}, err
+		}
+
+		command.WatchID = id
+	case CommandList:
+		if argument == "" {
+			break
+		}
+
+		line, err := parsePositiveNumber(argument, "usage: list [<line>]")
+		if err != nil {
+			return Command{}, err
+		}
+
+		command.Count = line
+	case CommandUp, CommandDown, CommandBack:
+		command.Count = 1
+
+		if argument == "" {
+			break
+		}
+
+		count, err := parsePositiveNumber(argument, fmt.Sprintf("usage: %s [<count>]", commandName))
+		if err != nil {
+			return Command{}, err
+		}
+
+		command.Count = count
+	case CommandFrame:
+		index, err := strconv.Atoi(argument)
+		if err != nil || index < 0 {
+			return Command{}, errors.New("usage: frame <index>")
+		}
+
+		command.Count = index
+	case CommandBreakChange:
+		if argument == "" || strings.ContainsFunc(argument, unicode.IsSpace) {
+			return Command{}, fmt.Errorf("usage: break-on-change <variable>")
+		}
 	default:
 		return Command{}, fmt.Errorf("unknown command: %s", name)
 	}
@@ -105,19 +180,97 @@ func ParseCommand(input string) (Command, error) {
 	return command, nil
 }
 
-const breakpointUsage = "usage: break [--exact|--next|--in-function] <line>[:<column>] or <file>:<line>[:<column>]"
+const (
+	breakpointUsage = "usage: break [--exact|--next|--in-function] <line>[:<column>] or <file>:<line>[:<column>] [hits=<n>] [if <condition>]"
+	logpointUsage   = "usage: logpoint [--exact|--next|--in-function] <location> \"<message with {{ expression }}>\""
+)
+
+func parseBreakpoint(argument string) (ferret.DebugSourceLocation, ferret.DebugBreakpointOptions, BreakpointRule, error) {
+	var rule BreakpointRule
 
-func parseBreakpoint(argument string) (ferret.DebugSourceLocation, ferret.DebugBreakpointOptions, error) {
+	target, condition, hasCondition := cutKeyword(argument, "if")
+	if hasCondition {
+		if condition == "" {
+			return ferret.DebugSourceLocation{}, ferret.DebugBreakpointOptions{}, rule, errors.New("break condition cannot be empty")
+		}
+
+		rule.Condition = condition
+	}
+
+	location, options, rest, err := parseBreakpointTarget(strings.Fields(target), breakpointUsage)
+	if err != nil {
+		return location, options, rule, err
+	}
+
+	for _, token := range rest {
+		value, ok := strings.CutPrefix(token, "hits=")
+		if !ok || rule.HitCount != 0 {
+			return ferret.DebugSourceLocation{}, options, rule, errors.New(breakpointUsage)
+		}
+
+		count, err := parsePositiveNumber(value, "break hits must be a positive number")
+		if err != nil {
+			return ferret.DebugSourceLocation{}, options, rule, err
+		}
+
+		rule.HitCount = count
+	}
+
+	return location, options, rule, nil
+}
+
+func parseLogpoint(argument string) (ferret.DebugSourceLocation, ferret.DebugBreakpointOptions, BreakpointRule, error) {
+	var rule BreakpointRule
+	tokens := strings.Fields(argument)
+	locationTokens := 0
+
+	// The message starts after the binding options and the location token.
+	for locationTokens < len(tokens) && strings.HasPrefix(tokens[locationTokens], "--") {
+		locationTokens++
+	}
+
+	locationTokens++
+	if locationTokens >= len(tokens) {
+		return ferret.DebugSourceLocation{}, ferret.DebugBreakpointOptions{}, rule, errors.New(logpointUsage)
+	}
+
+	location, options, _, err := parseBreakpointTarget(tokens[:locationTokens], logpointUsage)
+	if err != nil {
+		return location, options, rule, err
+	}
+
+	message := strings.TrimSpace(skipFields(argument, locationTokens))
+	if strings.HasPrefix(message, `"`) {
+		unquoted, err := strconv.Unquote(message)
+		if err != nil {
+			return ferret.DebugSourceLocation{}, options, rule, fmt.Errorf("invalid logpoint message: %w", err)
+		}
+
+		message = unquoted
+	}
+
+	if message == "" {
+		return ferret.DebugSourceLocation{}, options, rule, errors.New(logpointUsage)
+	}
+
+	rule.LogMessage = message
+
+	return location, options, rule, nil
+}
+
+// parseBreakpointTarget consumes binding options and exactly one location,
+// returning the remaining tokens for the caller to interpret.
+func parseBreakpointTarget(tokens []string, usage string) (ferret.DebugSourceLocation, ferret.DebugBreakpointOptions, []string, error) {
 	var location ferret.DebugSourceLocation
 	options := ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindNextExecutableInFile}
-	tokens := strings.Fields(argument)
 
 	if len(tokens) == 0 {
-		return location, options, errors.New(breakpointUsage)
+		return location, options, nil, errors.New(usage)
 	}
 
 	locationText := ""
 	modeSet := false
+	var rest []string
 
 	for _, token := range tokens {
 		var mode ferret.DebugBreakpointBindingMode
@@ -130,11 +283,12 @@ func parseBreakpoint(argument string) (ferret.DebugSourceLocation, ferret.DebugB
 			mode = ferret.DebugBreakpointBindNextExecutableInFunction
 		default:
 			if strings.HasPrefix(token, "--") {
-				return location, options, fmt.Errorf("unknown break option: %s", token)
+				return location, options, nil, fmt.Errorf("unknown break option: %s", token)
 			}
 
 			if locationText != "" {
-				return location, options, errors.New(breakpointUsage)
+				rest = append(rest, token)
+				continue
 			}
 
 			locationText = token
@@ -143,7 +297,7 @@ func parseBreakpoint(argument string) (ferret.DebugSourceLocation, ferret.DebugB
 		}
 
 		if modeSet {
-			return location, options, errors.New("break binding options are mutually exclusive")
+			return location, options, nil, errors.New("break binding options are mutually exclusive")
 		}
 
 		modeSet = true
@@ -151,15 +305,47 @@ func parseBreakpoint(argument string) (ferret.DebugSourceLocation, ferret.DebugB
 	}
 
 	if locationText == "" {
-		return location, options, errors.New(breakpointUsage)
+		return location, options, nil, errors.New(usage)
 	}
 
 	location, err := parseBreakpointLocation(locationText)
 	if err != nil {
-		return ferret.DebugSourceLocation{}, options, err
+		return ferret.DebugSourceLocation{}, options, nil, err
+	}
+
+	return location, options, rest, nil
+}
+
+// cutKeyword splits input around the first whitespace-delimited keyword,
+// preserving the original spacing of the text that follows it.
+func cutKeyword(input, keyword string) (string, string, bool) {
+	offset := 0
+
+	for _, field := range strings.Fields(input) {
+		index := strings.Index(input[offset:], field) + offset
+		offset = index + len(field)
+
+		if field == keyword {
+			return input[:index], strings.TrimSpace(input[offset:]), true
+		}
+	}
+
+	return input, "", false
+}
+
+// skipFields returns input after its first n whitespace-delimited fields.
+func skipFields(input string, n int) string {
+	offset := 0
+
+	for i, field := range strings.Fields(input) {
+		if i == n {
+			break
+		}
+
+		offset = strings.Index(input[offset:], field) + offset + len(field)
 	}
 
-	return location, options, nil
+	return input[offset:]
 }
 
 func parseBreakpointLocation(value string) (ferret.DebugSourceLocation, error) {
@@ -95,9 +95,68 @@ func TestParseCommand(t *testing.T) {
 				BreakpointOptions: ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindNextExecutableInFunction},
 			},
 		},
+		{
+			name:  "break with condition",
+			input: "break 12 if @limit > 3",
+			want: Command{
+				Name:              CommandBreak,
+				Argument:          "12 if @limit > 3",
+				Location:          ferret.DebugSourceLocation{Line: 12},
+				BreakpointOptions: ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindNextExecutableInFile},
+				BreakpointRule:    BreakpointRule{Condition: "@limit > 3"},
+			},
+		},
+		{
+			name:  "break with hit count and condition",
+			input: "break --exact 12:4 hits=5 if item.price > 10",
+			want: Command{
+				Name:              CommandBreak,
+				Argument:          "--exact 12:4 hits=5 if item.price > 10",
+				Location:          ferret.DebugSourceLocation{Line: 12, Column: 4},
+				BreakpointOptions: ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindExact},
+				BreakpointRule:    BreakpointRule{Condition: "item.price > 10", HitCount: 5},
+			},
+		},
+		{
+			name:  "logpoint quoted message",
+			input: `logpoint 12 "visiting {{ item.url }}"`,
+			want: Command{
+				Name:              CommandLogpoint,
+				Argument:          `12 "visiting {{ item.url }}"`,
+				Location:          ferret.DebugSourceLocation{Line: 12},
+				BreakpointOptions: ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindNextExecutableInFile},
+				BreakpointRule:    BreakpointRule{LogMessage: "visiting {{ item.url }}"},
+			},
+		},
+		{
+			name:  "logpoint alias with option",
+			input: "lp --in-function demo.fql:12 {{ item }}",
+			want: Command{
+				Name:              CommandLogpoint,
+				Argument:          "--in-function demo.fql:12 {{ item }}",
+				Location:          ferret.DebugSourceLocation{File: "demo.fql", Line: 12},
+				BreakpointOptions: ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindNextExecutableInFunction},
+				BreakpointRule:    BreakpointRule{LogMessage: "{{ item }}"},
+			},
+		},
 		{name: "delete", input: "delete 1", want: Command{Name: CommandDelete, Argument: "1", BreakpointID: ferret.DebugBreakpointID(1)}},
 		{name: "print expression", input: "print users[0].name + \" value\"", want: Command{Name: CommandPrint, Argument: `users[0].name + " value"`}},
 		{name: "help", input: "help", want: Command{Name: CommandHelp}},
+		{name: "watch expression", input: "watch total * 2", want: Command{Name: CommandWatch, Argument: "total * 2"}},
+		{name: "watch list", input: "watch", want: Command{Name: CommandWatch}},
+		{name: "unwatch", input: "unwatch 2", want: Command{Name: CommandUnwatch, Argument: "2", WatchID: 2}},
+		{name: "list", input: "list", want: Command{Name: CommandList}},
+		{name: "list line", input: "ls 12", want: Command{Name: CommandList, Argument: "12", Count: 12}},
+		{name: "up", input: "up", want: Command{Name: CommandUp, Count: 1}},
+		{name: "up count", input: "up 2", want: Command{Name: CommandUp, Argument: "2", Count: 2}},
+		{name: "down", input: "down", want: Command{Name: CommandDown, Count: 1}},
+		{name: "frame", input: "f 0", want: Command{Name: CommandFrame, Argument: "0", Count: 0}},
+		{name: "back", input: "back", want: Command{Name: CommandBack, Count: 1}},
+		{name: "back count", input: "back 3", want: Command{Name: CommandBack, Argument: "3", Count: 3}},
+		{name: "reverse continue alias", input: "rc", want: Command{Name: CommandReverse}},
+		{name: "history", input: "history", want: Command{Name: CommandHistory}},
+		{name: "break-on-change", input: "break-on-change total", want: Command{Name: CommandBreakChange, Argument: "total"}},
+		{name: "break-on-change alias", input: "boc @limit", want: Command{Name: CommandBreakChange, Argument: "@limit"}},
 		{name: "breakpoints", input: "breakpoints", want: Command{Name: CommandBreakpoints}},
 		{name: "continue", input: "continue", want: Command{Name: CommandContinue}},
 		{name: "step", input: "step", want: Command{Name: CommandStep}},
@@ -131,6 +190,12 @@ func TestParseCommand(t *testing.T) {
 		{name: "print eval alias", input: "eval user.name", want: Command{Name: CommandPrint, Argument: "user.name"}},
 		{name: "quit alias", input: "q", want: Command{Name: CommandQuit}},
 		{name: "invalid command", input: "wat", errHas: "unknown command: wat"},
+		{name: "zero list line", input: "list 0", errHas: "usage: list"},
+		{name: "invalid up count", input: "up two", errHas: "usage: up"},
+		{name: "invalid back count", input: "back 0", errHas: "usage: back"},
+		{name: "history with argument", input: "history 2", errHas: "does not accept arguments"},
+		{name: "missing frame index", input: "frame", errHas: "usage: frame"},
+		{name: "negative frame index", input: "frame -1", errHas: "usage: frame"},
 		{name: "missing break line", input: "break", errHas: "usage: break"},
 		{name: "invalid break line", input: "break file.fql:nope", errHas: "usage: break"},
 		{name: "zero break line", input: "break 0", errHas: "usage: break"},
@@ -141,6 +206,13 @@ func TestParseCommand(t *testing.T) {
 		{name: "missing location after option", input: "break --exact", errHas: "usage: break"},
 		{name: "extra break argument", input: "break 12 extra", errHas: "usage: break"},
 		{name: "path with whitespace", input: "break my file.fql:12", errHas: "usage: break"},
+		{name: "empty break condition", input: "break 12 if", errHas: "condition cannot be empty"},
+		{name: "zero break hits", input: "break 12 hits=0", errHas: "hits must be a positive number"},
+		{name: "duplicate break hits", input: "break 12 hits=2 hits=3", errHas: "usage: break"},
+		{name: "missing logpoint message", input: "logpoint 12", errHas: "usage: logpoint"},
+		{name: "invalid logpoint quote", input: `logpoint 12 "unterminated`, errHas: "invalid logpoint message"},
+		{name: "unwatch without id", input: "unwatch", errHas: "usage: unwatch"},
+		{name: "break-on-change expression", input: "boc total + 1", errHas: "usage: break-on-change"},
 		{name: "missing delete id", input: "delete", errHas: "usage: delete"},
 		{name: "missing print expression", input: "print", errHas: "usage: print"},
 		{name: "unexpected argument", input: "continue now", errHas: "continue does not accept arguments"},
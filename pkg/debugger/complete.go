@@ -0,0 +1,138 @@
+package debugger
+
+import (
+	"sort"
+	"strings"
+)
+
+// commandNames lists the names completed for the first word of a line.
+var commandNames = []CommandName{
+	CommandHelp,
+	CommandBreak,
+	CommandLogpoint,
+	CommandDelete,
+	CommandBreakpoints,
+	CommandContinue,
+	CommandStep,
+	CommandNext,
+	CommandOut,
+	CommandPause,
+	CommandWhere,
+	CommandUp,
+	CommandDown,
+	CommandFrame,
+	CommandList,
+	CommandLocals,
+	CommandPrint,
+	CommandWatch,
+	CommandUnwatch,
+	CommandBreakChange,
+	CommandBack,
+	CommandReverse,
+	CommandHistory,
+	CommandQuit,
+}
+
+// completer implements readline.AutoCompleteInterface. It completes command
+// names, and the names of local variables in the selected frame for commands
+// that take an expression.
+type completer struct {
+	session Session
+	state   *promptState
+}
+
+func newCompleter(session Session, state *promptState) *completer {
+	return &completer{session: session, state: state}
+}
+
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	input := string(line[:pos])
+
+	if command := strings.TrimLeft(input, " \t"); !strings.ContainsAny(command, " \t") {
+		candidates := make([]string, 0, len(commandNames))
+
+		for _, name := range commandNames {
+			candidates = append(candidates, string(name))
+		}
+
+		return completions(candidates, command, " "), len([]rune(command))
+	}
+
+	if !takesExpression(input) {
+		return nil, 0
+	}
+
+	prefix := input[strings.LastIndexFunc(input, isCompletionSeparator)+1:]
+
+	return completions(c.localNames(), prefix, ""), len([]rune(prefix))
+}
+
+func (c *completer) localNames() []string {
+	if c.state.location.Line == 0 {
+		return nil
+	}
+
+	locals, err := frameLocals(c.session, c.state.frame)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(locals))
+
+	for _, variable := range locals {
+		names = append(names, variable.Name)
+	}
+
+	return names
+}
+
+// takesExpression reports whether the command on the line is followed by an
+// expression, so its arguments complete to variable names.
+func takesExpression(input string) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return false
+	}
+
+	name := CommandName(strings.ToLower(fields[0]))
+	if alias, ok := aliases[string(name)]; ok {
+		name = alias
+	}
+
+	switch name {
+	case CommandPrint, CommandWatch, CommandBreakChange:
+		return true
+	default:
+		return false
+	}
+}
+
+// completions returns the remainder of every candidate that starts with
+// prefix, with suffix appended, sorted and without duplicates, as readline
+// expects.
+func completions(candidates []string, prefix, suffix string) [][]rune {
+	sort.Strings(candidates)
+
+	var result [][]rune
+	var previous string
+
+	for _, candidate := range candidates {
+		if candidate == previous || !strings.HasPrefix(candidate, prefix) {
+			continue
+		}
+
+		previous = candidate
+		result = append(result, []rune(candidate[len(prefix):]+suffix))
+	}
+
+	return result
+}
+
+func isCompletionSeparator(r rune) bool {
+	switch r {
+	case ' ', '\t', '(', ')', '[', ']', '{', '}', ',', '+', '-', '*', '/', '=', '!', '<', '>':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MontFerret/ferret/v2"
+)
+
+func TestCompleterCompletesCommandsAndLocals(t *testing.T) {
+	session := &fakeSession{
+		locals: []ferret.DebugVariable{
+			{Name: "user", Value: ferret.DebugValue{Display: "{}"}},
+			{Name: "users", Value: ferret.DebugValue{Display: "[]"}},
+			{Name: "@limit", Param: true, Value: ferret.DebugValue{Display: "10"}},
+		},
+	}
+	state := newPromptState("demo.fql", Options{})
+	state.location = ferret.DebugLocation{File: "demo.fql", Line: 1}
+	completer := newCompleter(session, state)
+
+	tests := []struct {
+		line   string
+		want   []string
+		length int
+	}{
+		{line: "br", want: []string{"eak ", "eak-on-change ", "eakpoints "}, length: 2},
+		{line: "break-on", want: []string{"-change "}, length: 8},
+		{line: "p us", want: []string{"er", "ers"}, length: 2},
+		{line: "print LENGTH(us", want: []string{"er", "ers"}, length: 2},
+		{line: "watch @l", want: []string{"imit"}, length: 2},
+		{line: "break us", want: nil, length: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			got, length := completer.Do([]rune(test.line), len([]rune(test.line)))
+
+			var suffixes []string
+			for _, suffix := range got {
+				suffixes = append(suffixes, string(suffix))
+			}
+
+			if !reflect.DeepEqual(suffixes, test.want) || length != test.length {
+				t.Fatalf("unexpected completion: got %q (%d), want %q (%d)", suffixes, length, test.want, test.length)
+			}
+		})
+	}
+
+	state.location = ferret.DebugLocation{}
+	if got, _ := completer.Do([]rune("p us"), 4); len(got) != 0 {
+		t.Fatalf("expected no locals without a paused location, got %q", got)
+	}
+}
@@ -0,0 +1,3 @@
+// Package dap serves a Ferret debug session over the Debug Adapter Protocol so
+// editors such as VS Code can drive breakpoints, stepping, and inspection.
+package dap
@@ -0,0 +1,52 @@
+package dap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/debugger"
+)
+
+// ListenAndServe accepts a single DAP client on a TCP address and serves the
+// session over that connection. The ready callback receives the bound address
+// before the listener starts accepting, which is useful with port 0.
+func ListenAndServe(ctx context.Context, address string, session debugger.Session, src *source.Source, ready func(net.Addr)) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Join(fmt.Errorf("listen on %s: %w", address, err), session.Close())
+	}
+	defer listener.Close()
+
+	if ready != nil {
+		ready(listener.Addr())
+	}
+
+	stopListener := context.AfterFunc(ctx, func() {
+		_ = listener.Close()
+	})
+
+	conn, err := listener.Accept()
+	stopListener()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = fmt.Errorf("accept DAP client: %w", err)
+		}
+
+		return errors.Join(err, session.Close())
+	}
+	defer conn.Close()
+
+	stopConn := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stopConn()
+
+	return Serve(ctx, session, src, conn, conn)
+}
@@ -0,0 +1,159 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const contentLengthHeader = "Content-Length"
+
+type (
+	request struct {
+		Seq       int             `json:"seq"`
+		Type      string          `json:"type"`
+		Command   string          `json:"command"`
+		Arguments json.RawMessage `json:"arguments,omitempty"`
+	}
+
+	response struct {
+		Seq        int    `json:"seq"`
+		Type       string `json:"type"`
+		RequestSeq int    `json:"request_seq"`
+		Success    bool   `json:"success"`
+		Command    string `json:"command"`
+		Message    string `json:"message,omitempty"`
+		Body       any    `json:"body,omitempty"`
+	}
+
+	event struct {
+		Seq   int    `json:"seq"`
+		Type  string `json:"type"`
+		Event string `json:"event"`
+		Body  any    `json:"body,omitempty"`
+	}
+)
+
+// reader decodes base protocol messages framed by a Content-Length header.
+type reader struct {
+	headers *textproto.Reader
+	body    *bufio.Reader
+}
+
+func newReader(r io.Reader) *reader {
+	body := bufio.NewReader(r)
+
+	return &reader{
+		headers: textproto.NewReader(body),
+		body:    body,
+	}
+}
+
+func (r *reader) Read() (request, error) {
+	var req request
+
+	header, err := r.headers.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return req, io.EOF
+		}
+
+		return req, fmt.Errorf("read DAP header: %w", err)
+	}
+
+	value := strings.TrimSpace(header.Get(contentLengthHeader))
+	if value == "" {
+		return req, fmt.Errorf("read DAP header: missing %s", contentLengthHeader)
+	}
+
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 {
+		return req, fmt.Errorf("read DAP header: invalid %s %q", contentLengthHeader, value)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.body, data); err != nil {
+		return req, fmt.Errorf("read DAP message: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, fmt.Errorf("decode DAP message: %w", err)
+	}
+
+	if req.Type != "request" {
+		return req, fmt.Errorf("decode DAP message: unexpected message type %q", req.Type)
+	}
+
+	return req, nil
+}
+
+// writer serializes responses and events. Events are produced by resume
+// goroutines, so writes and sequence numbers are guarded by a mutex.
+type writer struct {
+	mu  sync.Mutex
+	out io.Writer
+	seq int
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{out: w}
+}
+
+func (w *writer) Response(req request, body any, err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+	res := response{
+		Seq:        w.seq,
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+
+	if err != nil {
+		res.Message = err.Error()
+		res.Body = nil
+	}
+
+	return w.write(res)
+}
+
+func (w *writer) Event(name string, body any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+
+	return w.write(event{
+		Seq:   w.seq,
+		Type:  "event",
+		Event: name,
+		Body:  body,
+	})
+}
+
+func (w *writer) write(message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encode DAP message: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w.out, "%s: %d\r\n\r\n", contentLengthHeader, len(data)); err != nil {
+		return fmt.Errorf("write DAP header: %w", err)
+	}
+
+	if _, err := w.out.Write(data); err != nil {
+		return fmt.Errorf("write DAP message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,649 @@
+package dap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/diagnostics"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/debugger"
+)
+
+const (
+	threadID   = 1
+	threadName = "main"
+
+	localsReference = 1
+	paramsReference = 2
+)
+
+var (
+	errNotStarted = errors.New("program has not started")
+	errRunning    = errors.New("program is running")
+	errFinished   = errors.New("program has finished")
+)
+
+type server struct {
+	session  debugger.Session
+	mainFile string
+	writer   *writer
+
+	mu          sync.Mutex
+	resumes     sync.WaitGroup
+	lineBase    int
+	columnBase  int
+	launched    bool
+	configured  bool
+	started     bool
+	running     bool
+	finished    bool
+	stopOnEntry bool
+	breakpoints map[string][]ferret.DebugBreakpointID
+}
+
+type handlerResult struct {
+	body any
+	next func()
+}
+
+// Serve speaks the Debug Adapter Protocol over in and out until the client
+// disconnects or closes the stream. The session is always closed on return.
+func Serve(ctx context.Context, session debugger.Session, src *source.Source, in io.Reader, out io.Writer) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s := &server{
+		session:     session,
+		writer:      newWriter(out),
+		lineBase:    1,
+		columnBase:  1,
+		breakpoints: make(map[string][]ferret.DebugBreakpointID),
+	}
+
+	if src != nil {
+		s.mainFile = src.Name()
+	}
+
+	defer func() {
+		cancel()
+		s.resumes.Wait()
+		err = errors.Join(err, session.Close())
+	}()
+
+	messages := newReader(in)
+
+	for {
+		req, readErr := messages.Read()
+
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+
+		if readErr != nil {
+			return readErr
+		}
+
+		quit, handleErr := s.handle(ctx, cancel, req)
+
+		if handleErr != nil {
+			return handleErr
+		}
+
+		if quit {
+			return nil
+		}
+	}
+}
+
+func (s *server) handle(ctx context.Context, cancel context.CancelFunc, req request) (bool, error) {
+	var (
+		result handlerResult
+		err    error
+		quit   bool
+	)
+
+	switch req.Command {
+	case "initialize":
+		result, err = s.initialize(req)
+	case "launch", "attach":
+		result, err = s.launch(ctx, req)
+	case "configurationDone":
+		result = s.configurationDone(ctx)
+	case "setBreakpoints":
+		result, err = s.setBreakpoints(req)
+	case "setExceptionBreakpoints", "setFunctionBreakpoints":
+		result.body = setBreakpointsResponse{Breakpoints: []breakpoint{}}
+	case "threads":
+		result.body = threadsResponse{Threads: []thread{{ID: threadID, Name: threadName}}}
+	case "stackTrace":
+		result, err = s.stackTrace(req)
+	case "scopes":
+		result, err = s.scopes(req)
+	case "variables":
+		result, err = s.variables(req)
+	case "evaluate":
+		result, err = s.evaluate(ctx, req)
+	case "continue":
+		result, err = s.resumeRequest(ctx, s.session.Continue)
+		result.body = continueResponse{AllThreadsContinued: true}
+	case "next":
+		result, err = s.resumeRequest(ctx, s.session.Next)
+	case "stepIn":
+		result, err = s.resumeRequest(ctx, s.session.Step)
+	case "stepOut":
+		result, err = s.resumeRequest(ctx, s.session.Out)
+	case "pause":
+		err = s.session.Pause()
+	case "terminate":
+		cancel()
+		result.next = func() {
+			s.finish()
+			_ = s.writer.Event("terminated", nil)
+		}
+	case "disconnect":
+		cancel()
+		quit = true
+	default:
+		err = fmt.Errorf("unsupported request: %s", req.Command)
+	}
+
+	if writeErr := s.writer.Response(req, result.body, err); writeErr != nil {
+		return true, writeErr
+	}
+
+	if err == nil && result.next != nil {
+		result.next()
+	}
+
+	return quit, nil
+}
+
+func (s *server) initialize(req request) (handlerResult, error) {
+	var args initializeArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	s.mu.Lock()
+	if args.LinesStartAt1 != nil && !*args.LinesStartAt1 {
+		s.lineBase = 0
+	}
+	if args.ColumnsStartAt1 != nil && !*args.ColumnsStartAt1 {
+		s.columnBase = 0
+	}
+	s.mu.Unlock()
+
+	return handlerResult{
+		body: capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsTerminateRequest:         true,
+		},
+		next: func() {
+			_ = s.writer.Event("initialized", nil)
+		},
+	}, nil
+}
+
+func (s *server) launch(ctx context.Context, req request) (handlerResult, error) {
+	var args launchArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	s.mu.Lock()
+	s.launched = true
+	s.stopOnEntry = args.StopOnEntry
+	s.mu.Unlock()
+
+	return handlerResult{next: func() { s.startWhenReady(ctx) }}, nil
+}
+
+func (s *server) configurationDone(ctx context.Context) handlerResult {
+	s.mu.Lock()
+	s.configured = true
+	s.mu.Unlock()
+
+	return handlerResult{next: func() { s.startWhenReady(ctx) }}
+}
+
+// startWhenReady starts the program once the client has both launched and
+// finished configuration, so initial breakpoints are bound before execution.
+func (s *server) startWhenReady(ctx context.Context) {
+	s.mu.Lock()
+	if !s.launched || !s.configured || s.started {
+		s.mu.Unlock()
+		return
+	}
+
+	s.started = true
+	stopOnEntry := s.stopOnEntry
+	s.mu.Unlock()
+
+	s.resume(ctx, func(ctx context.Context) (*ferret.DebugEvent, error) {
+		event, err := s.session.Start(ctx)
+		if err != nil || stopOnEntry || event == nil || event.Reason != ferret.DebugReasonEntry {
+			return event, err
+		}
+
+		return s.session.Continue(ctx)
+	})
+}
+
+func (s *server) setBreakpoints(req request) (handlerResult, error) {
+	var args setBreakpointsArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	requested := args.Breakpoints
+	if requested == nil {
+		for _, line := range args.Lines {
+			requested = append(requested, sourceBreakpoint{Line: line})
+		}
+	}
+
+	file := s.sessionPath(args.Source)
+	if file == "" {
+		return handlerResult{}, errors.New("breakpoint source path is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The session is not safe for concurrent use while a resume is in flight.
+	if s.running {
+		return handlerResult{}, errRunning
+	}
+
+	for _, id := range s.breakpoints[file] {
+		if err := s.session.DeleteBreakpoint(id); err != nil {
+			return handlerResult{}, err
+		}
+	}
+
+	delete(s.breakpoints, file)
+
+	result := setBreakpointsResponse{Breakpoints: make([]breakpoint, 0, len(requested))}
+	clientSource := s.clientSource(file)
+
+	for _, item := range requested {
+		location := ferret.DebugSourceLocation{
+			File:   file,
+			Line:   s.sessionLine(item.Line),
+			Column: s.sessionColumn(item.Column),
+		}
+
+		bound, err := s.session.SetBreakpointAt(location, ferret.DebugBreakpointOptions{
+			BindingMode: ferret.DebugBreakpointBindNextExecutableInFile,
+		})
+
+		if err != nil {
+			result.Breakpoints = append(result.Breakpoints, breakpoint{
+				Verified: false,
+				Message:  err.Error(),
+				Source:   clientSource,
+				Line:     item.Line,
+			})
+
+			continue
+		}
+
+		s.breakpoints[file] = append(s.breakpoints[file], bound.ID)
+		result.Breakpoints = append(result.Breakpoints, s.clientBreakpoint(bound, clientSource))
+	}
+
+	return handlerResult{body: result}, nil
+}
+
+func (s *server) stackTrace(req request) (handlerResult, error) {
+	var args stackTraceArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	if err := s.requirePaused(); err != nil {
+		return handlerResult{}, err
+	}
+
+	frames, err := s.session.Frames()
+	if err != nil {
+		return handlerResult{}, err
+	}
+
+	start := min(max(args.StartFrame, 0), len(frames))
+	end := len(frames)
+
+	if args.Levels > 0 {
+		end = min(start+args.Levels, end)
+	}
+
+	result := stackTraceResponse{
+		StackFrames: make([]stackFrame, 0, end-start),
+		TotalFrames: len(frames),
+	}
+
+	for i := start; i < end; i++ {
+		frame := frames[i]
+		result.StackFrames = append(result.StackFrames, stackFrame{
+			ID:     i + 1,
+			Name:   frame.Name,
+			Source: s.clientSource(frame.Location.File),
+			Line:   s.clientLine(frame.Location.Line),
+			Column: s.clientColumn(frame.Location.Column),
+		})
+	}
+
+	return handlerResult{body: result}, nil
+}
+
+func (s *server) scopes(req request) (handlerResult, error) {
+	var args scopesArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	if err := s.requirePaused(); err != nil {
+		return handlerResult{}, err
+	}
+
+	// The session exposes variables for the innermost frame only.
+	if args.FrameID != 1 {
+		return handlerResult{body: scopesResponse{Scopes: []scope{}}}, nil
+	}
+
+	return handlerResult{body: scopesResponse{Scopes: []scope{
+		{Name: "Locals", PresentationHint: "locals", VariablesReference: localsReference},
+		{Name: "Params", PresentationHint: "arguments", VariablesReference: paramsReference},
+	}}}, nil
+}
+
+func (s *server) variables(req request) (handlerResult, error) {
+	var args variablesArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	if err := s.requirePaused(); err != nil {
+		return handlerResult{}, err
+	}
+
+	if args.VariablesReference != localsReference && args.VariablesReference != paramsReference {
+		return handlerResult{body: variablesResponse{Variables: []variable{}}}, nil
+	}
+
+	locals, err := s.session.Locals()
+	if err != nil {
+		return handlerResult{}, err
+	}
+
+	params := args.VariablesReference == paramsReference
+	result := variablesResponse{Variables: make([]variable, 0, len(locals))}
+
+	for _, local := range locals {
+		if local.Param != params {
+			continue
+		}
+
+		result.Variables = append(result.Variables, variable{
+			Name:  local.Name,
+			Value: local.Value.Display,
+		})
+	}
+
+	return handlerResult{body: result}, nil
+}
+
+func (s *server) evaluate(ctx context.Context, req request) (handlerResult, error) {
+	var args evaluateArguments
+	if err := decodeArguments(req, &args); err != nil {
+		return handlerResult{}, err
+	}
+
+	if err := s.requirePaused(); err != nil {
+		return handlerResult{}, err
+	}
+
+	value, err := s.session.Evaluate(ctx, args.Expression)
+	if err != nil {
+		return handlerResult{}, err
+	}
+
+	return handlerResult{body: evaluateResponse{Result: value.Display}}, nil
+}
+
+func (s *server) resumeRequest(ctx context.Context, action func(context.Context) (*ferret.DebugEvent, error)) (handlerResult, error) {
+	if err := s.requirePaused(); err != nil {
+		return handlerResult{}, err
+	}
+
+	return handlerResult{next: func() { s.resume(ctx, action) }}, nil
+}
+
+// resume runs a blocking session call in the background so the request loop
+// stays responsive to pause and disconnect while the program executes.
+func (s *server) resume(ctx context.Context, action func(context.Context) (*ferret.DebugEvent, error)) {
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+
+	s.resumes.Add(1)
+
+	go func() {
+		defer s.resumes.Done()
+
+		event, err := action(ctx)
+
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.report(event, err)
+	}()
+}
+
+func (s *server) report(event *ferret.DebugEvent, err error) {
+	if err != nil {
+		_ = s.writer.Event("output", outputEvent{Category: "stderr", Output: fmt.Sprintf("Debugger error: %s\n", err)})
+		_ = s.writer.Event("stopped", stoppedEvent{
+			Reason:            "exception",
+			Description:       "Debugger error",
+			Text:              err.Error(),
+			ThreadID:          threadID,
+			AllThreadsStopped: true,
+		})
+
+		return
+	}
+
+	if event == nil {
+		_ = s.writer.Event("output", outputEvent{Category: "console", Output: "Debugger returned no event.\n"})
+		return
+	}
+
+	switch event.Reason {
+	case ferret.DebugReasonCompleted:
+		if event.Output != nil {
+			_ = s.writer.Event("output", outputEvent{Category: "stdout", Output: string(event.Output.Content) + "\n"})
+		}
+
+		s.exit(0)
+	case ferret.DebugReasonTerminated:
+		if event.Error != nil {
+			_ = s.writer.Event("output", outputEvent{Category: "stderr", Output: diagnostics.Format(event.Error) + "\n"})
+		}
+
+		s.exit(1)
+	case ferret.DebugReasonRuntimeError:
+		stopped := stoppedEvent{
+			Reason:            "exception",
+			Description:       "Paused on runtime error",
+			ThreadID:          threadID,
+			AllThreadsStopped: true,
+		}
+
+		if event.Error != nil {
+			stopped.Text = diagnostics.Format(event.Error)
+		}
+
+		_ = s.writer.Event("stopped", stopped)
+	default:
+		stopped := stoppedEvent{
+			Reason:            stopReason(event.Reason),
+			ThreadID:          threadID,
+			AllThreadsStopped: true,
+		}
+
+		for _, id := range event.HitBreakpointIDs {
+			stopped.HitBreakpointIDs = append(stopped.HitBreakpointIDs, int(id))
+		}
+
+		_ = s.writer.Event("stopped", stopped)
+	}
+}
+
+func (s *server) exit(code int) {
+	s.finish()
+	_ = s.writer.Event("exited", exitedEvent{ExitCode: code})
+	_ = s.writer.Event("terminated", nil)
+}
+
+func (s *server) finish() {
+	s.mu.Lock()
+	s.finished = true
+	s.mu.Unlock()
+}
+
+func (s *server) requirePaused() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case !s.started:
+		return errNotStarted
+	case s.finished:
+		return errFinished
+	case s.running:
+		return errRunning
+	default:
+		return nil
+	}
+}
+
+func (s *server) clientBreakpoint(bound ferret.DebugBreakpoint, src *sourceRef) breakpoint {
+	result := breakpoint{
+		ID:       int(bound.ID),
+		Verified: bound.Bound,
+		Source:   src,
+		Line:     s.clientLine(bound.RequestedLine),
+		Column:   s.clientColumn(bound.RequestedColumn),
+	}
+
+	if bound.Bound {
+		result.Line = s.clientLine(bound.Line)
+		result.Column = s.clientColumn(bound.Column)
+	} else {
+		result.Message = "no executable location found"
+	}
+
+	return result
+}
+
+// sessionPath maps a client source to the file name known by the session.
+// Clients send absolute paths, while the main script keeps the name it was
+// opened with on the command line.
+func (s *server) sessionPath(src sourceRef) string {
+	path := src.Path
+	if path == "" {
+		path = src.Name
+	}
+
+	if path == "" || s.mainFile == "" {
+		return path
+	}
+
+	if path == s.mainFile || absPath(path) == absPath(s.mainFile) {
+		return s.mainFile
+	}
+
+	return path
+}
+
+func (s *server) clientSource(file string) *sourceRef {
+	if file == "" {
+		return nil
+	}
+
+	return &sourceRef{Name: filepath.Base(file), Path: absPath(file)}
+}
+
+func (s *server) sessionLine(line int) int {
+	return line + 1 - s.lineBase
+}
+
+func (s *server) clientLine(line int) int {
+	if line <= 0 {
+		return line
+	}
+
+	return line - 1 + s.lineBase
+}
+
+func (s *server) sessionColumn(column int) int {
+	if column <= 0 && s.columnBase == 1 {
+		return 0
+	}
+
+	return column + 1 - s.columnBase
+}
+
+func (s *server) clientColumn(column int) int {
+	if column <= 0 {
+		return column
+	}
+
+	return column - 1 + s.columnBase
+}
+
+func stopReason(reason ferret.DebugReason) string {
+	switch reason {
+	case ferret.DebugReasonEntry:
+		return "entry"
+	case ferret.DebugReasonBreakpoint:
+		return "breakpoint"
+	case ferret.DebugReasonPause:
+		return "pause"
+	default:
+		return "step"
+	}
+}
+
+func decodeArguments(req request, target any) error {
+	if len(req.Arguments) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(req.Arguments, target); err != nil {
+		return fmt.Errorf("invalid %s arguments: %w", req.Command, err)
+	}
+
+	return nil
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+}
@@ -0,0 +1,399 @@
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+func TestServeDrivesSessionThroughProtocol(t *testing.T) {
+	session := &fakeSession{
+		startEvent: &ferret.DebugEvent{
+			Reason:   ferret.DebugReasonEntry,
+			Location: ferret.DebugLocation{File: "demo.fql", Line: 1, Column: 1},
+		},
+		resumeEvent: &ferret.DebugEvent{
+			Reason: ferret.DebugReasonCompleted,
+			Output: &encoding.Output{Content: []byte("3")},
+		},
+		frames: []ferret.DebugFrame{{Name: "<main>", Location: ferret.DebugLocation{File: "demo.fql", Line: 2, Column: 3}}},
+		locals: []ferret.DebugVariable{
+			{Name: "x", Value: ferret.DebugValue{Display: "1"}},
+			{Name: "limit", Param: true, Value: ferret.DebugValue{Display: "2"}},
+		},
+		evaluation: ferret.DebugValue{Display: "3"},
+	}
+	client := startServer(t, session, source.New("demo.fql", "LET x = 1\nRETURN x + @limit"))
+
+	client.send("initialize", map[string]any{"adapterID": "ferret"})
+	response := client.expectResponse("initialize")
+	if body := response["body"].(map[string]any); body["supportsConfigurationDoneRequest"] != true {
+		t.Fatalf("unexpected capabilities: %#v", body)
+	}
+	client.expectEvent("initialized")
+
+	client.send("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": "demo.fql"},
+		"breakpoints": []map[string]any{{"line": 2}},
+	})
+	response = client.expectResponse("setBreakpoints")
+	breakpoints := response["body"].(map[string]any)["breakpoints"].([]any)
+	if len(breakpoints) != 1 || breakpoints[0].(map[string]any)["verified"] != true {
+		t.Fatalf("unexpected breakpoints: %#v", breakpoints)
+	}
+	if session.breakpointLocation != (ferret.DebugSourceLocation{File: "demo.fql", Line: 2}) {
+		t.Fatalf("unexpected breakpoint location: %#v", session.breakpointLocation)
+	}
+
+	client.send("launch", map[string]any{"stopOnEntry": true})
+	client.expectResponse("launch")
+	client.send("configurationDone", nil)
+	client.expectResponse("configurationDone")
+
+	stopped := client.expectEvent("stopped")
+	if body := stopped["body"].(map[string]any); body["reason"] != "entry" {
+		t.Fatalf("unexpected stopped event: %#v", body)
+	}
+
+	client.send("stackTrace", map[string]any{"threadId": 1})
+	frames := client.expectResponse("stackTrace")["body"].(map[string]any)["stackFrames"].([]any)
+	if len(frames) != 1 || frames[0].(map[string]any)["line"] != float64(2) {
+		t.Fatalf("unexpected stack frames: %#v", frames)
+	}
+
+	client.send("variables", map[string]any{"variablesReference": paramsReference})
+	variables := client.expectResponse("variables")["body"].(map[string]any)["variables"].([]any)
+	if len(variables) != 1 || variables[0].(map[string]any)["name"] != "limit" {
+		t.Fatalf("unexpected variables: %#v", variables)
+	}
+
+	client.send("evaluate", map[string]any{"expression": "x + @limit", "context": "repl"})
+	if result := client.expectResponse("evaluate")["body"].(map[string]any)["result"]; result != "3" {
+		t.Fatalf("unexpected evaluation: %#v", result)
+	}
+
+	client.send("continue", map[string]any{"threadId": 1})
+	client.expectResponse("continue")
+	if output := client.expectEvent("output")["body"].(map[string]any)["output"]; output != "3\n" {
+		t.Fatalf("unexpected output: %#v", output)
+	}
+	client.expectEvent("exited")
+	client.expectEvent("terminated")
+
+	client.send("disconnect", nil)
+	client.expectResponse("disconnect")
+
+	if err := client.wait(); err != nil {
+		t.Fatal(err)
+	}
+	if session.startCalls != 1 || session.continueCalls != 1 || session.closeCalls != 1 {
+		t.Fatalf("unexpected session calls: %#v", session)
+	}
+}
+
+func TestServeContinuesPastEntryWithoutStopOnEntry(t *testing.T) {
+	session := &fakeSession{
+		startEvent: &ferret.DebugEvent{Reason: ferret.DebugReasonEntry},
+		resumeEvent: &ferret.DebugEvent{
+			Reason:           ferret.DebugReasonBreakpoint,
+			Location:         ferret.DebugLocation{File: "demo.fql", Line: 2},
+			HitBreakpointIDs: []ferret.DebugBreakpointID{4},
+		},
+	}
+	client := startServer(t, session, source.New("demo.fql", "RETURN 1"))
+
+	client.send("configurationDone", nil)
+	client.expectResponse("configurationDone")
+	client.send("launch", nil)
+	client.expectResponse("launch")
+
+	body := client.expectEvent("stopped")["body"].(map[string]any)
+	if body["reason"] != "breakpoint" || fmt.Sprint(body["hitBreakpointIds"]) != "[4]" {
+		t.Fatalf("unexpected stopped event: %#v", body)
+	}
+	if session.continueCalls != 1 {
+		t.Fatalf("expected entry to be skipped, got %d continue calls", session.continueCalls)
+	}
+
+	client.close()
+	if err := client.wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServeRejectsInspectionBeforeStartAndUnknownRequests(t *testing.T) {
+	session := &fakeSession{}
+	client := startServer(t, session, source.New("demo.fql", "RETURN 1"))
+
+	client.send("stackTrace", map[string]any{"threadId": 1})
+	if response := client.expectResponse("stackTrace"); response["success"] != false || response["message"] != errNotStarted.Error() {
+		t.Fatalf("unexpected response: %#v", response)
+	}
+
+	client.send("readMemory", nil)
+	if response := client.expectResponse("readMemory"); response["success"] != false ||
+		!strings.Contains(response["message"].(string), "unsupported request") {
+		t.Fatalf("unexpected response: %#v", response)
+	}
+
+	client.close()
+	if err := client.wait(); err != nil {
+		t.Fatal(err)
+	}
+	if session.framesCalls != 0 || session.closeCalls != 1 {
+		t.Fatalf("unexpected session calls: %#v", session)
+	}
+}
+
+func TestServeReplacesBreakpointsPerSource(t *testing.T) {
+	session := &fakeSession{}
+	client := startServer(t, session, source.New("demo.fql", "RETURN 1"))
+
+	for _, lines := range [][]int{{1, 3}, {5}} {
+		items := make([]map[string]any, 0, len(lines))
+		for _, line := range lines {
+			items = append(items, map[string]any{"line": line})
+		}
+
+		client.send("setBreakpoints", map[string]any{"source": map[string]any{"path": "demo.fql"}, "breakpoints": items})
+		client.expectResponse("setBreakpoints")
+	}
+
+	client.close()
+	if err := client.wait(); err != nil {
+		t.Fatal(err)
+	}
+	if session.deleteCalls != 2 || len(session.breakpoints) != 1 || session.breakpoints[0].RequestedLine != 5 {
+		t.Fatalf("unexpected breakpoints: %#v", session.breakpoints)
+	}
+}
+
+func TestReaderRejectsMissingContentLength(t *testing.T) {
+	_, err := newReader(strings.NewReader("X-Other: 1\r\n\r\n{}")).Read()
+	if err == nil || !strings.Contains(err.Error(), "missing Content-Length") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type testClient struct {
+	t      *testing.T
+	seq    int
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	reader *textproto.Reader
+	done   chan error
+}
+
+func startServer(t *testing.T, session *fakeSession, src *source.Source) *testClient {
+	t.Helper()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	out := bufio.NewReader(clientReader)
+	client := &testClient{
+		t:      t,
+		in:     clientWriter,
+		out:    out,
+		reader: textproto.NewReader(out),
+		done:   make(chan error, 1),
+	}
+
+	go func() {
+		err := Serve(context.Background(), session, src, serverReader, serverWriter)
+		_ = serverWriter.Close()
+		client.done <- err
+	}()
+
+	return client
+}
+
+func (c *testClient) send(command string, arguments any) {
+	c.t.Helper()
+
+	c.seq++
+	message := map[string]any{"seq": c.seq, "type": "request", "command": command}
+	if arguments != nil {
+		message["arguments"] = arguments
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) read() map[string]any {
+	c.t.Helper()
+
+	header, err := c.reader.ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.out, data); err != nil {
+		c.t.Fatal(err)
+	}
+
+	var message map[string]any
+	if err := json.Unmarshal(data, &message); err != nil {
+		c.t.Fatal(err)
+	}
+
+	return message
+}
+
+func (c *testClient) expectResponse(command string) map[string]any {
+	c.t.Helper()
+
+	message := c.read()
+	if message["type"] != "response" || message["command"] != command {
+		c.t.Fatalf("expected %s response, got %#v", command, message)
+	}
+
+	return message
+}
+
+func (c *testClient) expectEvent(name string) map[string]any {
+	c.t.Helper()
+
+	message := c.read()
+	if message["type"] != "event" || message["event"] != name {
+		c.t.Fatalf("expected %s event, got %#v", name, message)
+	}
+
+	return message
+}
+
+func (c *testClient) close() {
+	_ = c.in.Close()
+}
+
+func (c *testClient) wait() error {
+	c.close()
+	return <-c.done
+}
+
+type fakeSession struct {
+	mu                 sync.Mutex
+	startEvent         *ferret.DebugEvent
+	resumeEvent        *ferret.DebugEvent
+	frames             []ferret.DebugFrame
+	locals             []ferret.DebugVariable
+	breakpoints        []ferret.DebugBreakpoint
+	evaluation         ferret.DebugValue
+	breakpointLocation ferret.DebugSourceLocation
+	nextBreakpointID   ferret.DebugBreakpointID
+	startCalls         int
+	continueCalls      int
+	deleteCalls        int
+	framesCalls        int
+	closeCalls         int
+}
+
+func (f *fakeSession) Start(context.Context) (*ferret.DebugEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.startCalls++
+	return f.startEvent, nil
+}
+
+func (f *fakeSession) Continue(context.Context) (*ferret.DebugEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.continueCalls++
+	return f.resumeEvent, nil
+}
+
+func (f *fakeSession) Step(context.Context) (*ferret.DebugEvent, error) {
+	return f.resumeEvent, nil
+}
+
+func (f *fakeSession) Next(context.Context) (*ferret.DebugEvent, error) {
+	return f.resumeEvent, nil
+}
+
+func (f *fakeSession) Out(context.Context) (*ferret.DebugEvent, error) {
+	return f.resumeEvent, nil
+}
+
+func (f *fakeSession) Pause() error {
+	return nil
+}
+
+func (f *fakeSession) SetBreakpointAt(location ferret.DebugSourceLocation, options ferret.DebugBreakpointOptions) (ferret.DebugBreakpoint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextBreakpointID++
+	f.breakpointLocation = location
+	breakpoint := ferret.DebugBreakpoint{
+		ID:            f.nextBreakpointID,
+		File:          location.File,
+		RequestedLine: location.Line,
+		Line:          location.Line,
+		BindingMode:   options.BindingMode,
+		Bound:         true,
+	}
+	f.breakpoints = append(f.breakpoints, breakpoint)
+	return breakpoint, nil
+}
+
+func (f *fakeSession) DeleteBreakpoint(id ferret.DebugBreakpointID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleteCalls++
+	for i, breakpoint := range f.breakpoints {
+		if breakpoint.ID == id {
+			f.breakpoints = append(f.breakpoints[:i], f.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("unknown breakpoint")
+}
+
+func (f *fakeSession) Breakpoints() []ferret.DebugBreakpoint {
+	return f.breakpoints
+}
+
+func (f *fakeSession) Frames() ([]ferret.DebugFrame, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.framesCalls++
+	return f.frames, nil
+}
+
+func (f *fakeSession) Locals() ([]ferret.DebugVariable, error) {
+	return f.locals, nil
+}
+
+func (f *fakeSession) Evaluate(context.Context, string) (ferret.DebugValue, error) {
+	return f.evaluation, nil
+}
+
+func (f *fakeSession) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closeCalls++
+	return nil
+}
@@ -0,0 +1,141 @@
+package dap
+
+type (
+	initializeArguments struct {
+		ClientID        string `json:"clientID"`
+		AdapterID       string `json:"adapterID"`
+		LinesStartAt1   *bool  `json:"linesStartAt1"`
+		ColumnsStartAt1 *bool  `json:"columnsStartAt1"`
+		PathFormat      string `json:"pathFormat"`
+	}
+
+	launchArguments struct {
+		NoDebug     bool `json:"noDebug"`
+		StopOnEntry bool `json:"stopOnEntry"`
+	}
+
+	capabilities struct {
+		SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+		SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+		SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers"`
+	}
+
+	sourceRef struct {
+		Name string `json:"name,omitempty"`
+		Path string `json:"path,omitempty"`
+	}
+
+	sourceBreakpoint struct {
+		Line   int `json:"line"`
+		Column int `json:"column,omitempty"`
+	}
+
+	setBreakpointsArguments struct {
+		Source      sourceRef          `json:"source"`
+		Breakpoints []sourceBreakpoint `json:"breakpoints"`
+		Lines       []int              `json:"lines"`
+	}
+
+	breakpoint struct {
+		ID       int        `json:"id,omitempty"`
+		Verified bool       `json:"verified"`
+		Message  string     `json:"message,omitempty"`
+		Source   *sourceRef `json:"source,omitempty"`
+		Line     int        `json:"line,omitempty"`
+		Column   int        `json:"column,omitempty"`
+	}
+
+	setBreakpointsResponse struct {
+		Breakpoints []breakpoint `json:"breakpoints"`
+	}
+
+	thread struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	threadsResponse struct {
+		Threads []thread `json:"threads"`
+	}
+
+	stackTraceArguments struct {
+		ThreadID   int `json:"threadId"`
+		StartFrame int `json:"startFrame"`
+		Levels     int `json:"levels"`
+	}
+
+	stackFrame struct {
+		ID     int        `json:"id"`
+		Name   string     `json:"name"`
+		Source *sourceRef `json:"source,omitempty"`
+		Line   int        `json:"line"`
+		Column int        `json:"column"`
+	}
+
+	stackTraceResponse struct {
+		StackFrames []stackFrame `json:"stackFrames"`
+		TotalFrames int          `json:"totalFrames"`
+	}
+
+	scopesArguments struct {
+		FrameID int `json:"frameId"`
+	}
+
+	scope struct {
+		Name               string `json:"name"`
+		PresentationHint   string `json:"presentationHint,omitempty"`
+		VariablesReference int    `json:"variablesReference"`
+		Expensive          bool   `json:"expensive"`
+	}
+
+	scopesResponse struct {
+		Scopes []scope `json:"scopes"`
+	}
+
+	variablesArguments struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+
+	variable struct {
+		Name               string `json:"name"`
+		Value              string `json:"value"`
+		VariablesReference int    `json:"variablesReference"`
+	}
+
+	variablesResponse struct {
+		Variables []variable `json:"variables"`
+	}
+
+	evaluateArguments struct {
+		Expression string `json:"expression"`
+		FrameID    int    `json:"frameId"`
+		Context    string `json:"context"`
+	}
+
+	evaluateResponse struct {
+		Result             string `json:"result"`
+		VariablesReference int    `json:"variablesReference"`
+	}
+
+	continueResponse struct {
+		AllThreadsContinued bool `json:"allThreadsContinued"`
+	}
+
+	stoppedEvent struct {
+		Reason            string `json:"reason"`
+		Description       string `json:"description,omitempty"`
+		ThreadID          int    `json:"threadId"`
+		AllThreadsStopped bool   `json:"allThreadsStopped"`
+		Text              string `json:"text,omitempty"`
+		HitBreakpointIDs  []int  `json:"hitBreakpointIds,omitempty"`
+	}
+
+	outputEvent struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}
+
+	exitedEvent struct {
+		ExitCode int `json:"exitCode"`
+	}
+)
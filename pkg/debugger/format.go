@@ -0,0 +1,235 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/MontFerret/ferret/v2"
+)
+
+// Format selects how a Renderer writes debugger output.
+type Format string
+
+const (
+	// FormatText writes human-readable prompt output.
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line for every pause, listing,
+	// evaluation, and message.
+	FormatJSON Format = "json"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unknown debugger format %q; expected text or json", value)
+	}
+}
+
+type (
+	jsonLocation struct {
+		File   string `json:"file"`
+		Line   int    `json:"line,omitempty"`
+		Column int    `json:"column,omitempty"`
+	}
+
+	jsonEvent struct {
+		Type        string             `json:"type"`
+		Reason      ferret.DebugReason `json:"reason"`
+		Location    *jsonLocation      `json:"location,omitempty"`
+		Breakpoints []int              `json:"breakpoints,omitempty"`
+		Error       string             `json:"error,omitempty"`
+		Result      json.RawMessage    `json:"result,omitempty"`
+	}
+
+	jsonBreakpoint struct {
+		Type      string        `json:"type,omitempty"`
+		ID        int           `json:"id"`
+		Requested jsonLocation  `json:"requested"`
+		Bound     *jsonLocation `json:"bound,omitempty"`
+		Mode      string        `json:"mode"`
+		Condition string        `json:"condition,omitempty"`
+		HitCount  int           `json:"hitCount,omitempty"`
+		Hits      int           `json:"hits,omitempty"`
+		Log       string        `json:"log,omitempty"`
+	}
+
+	jsonBreakpoints struct {
+		Type        string           `json:"type"`
+		Breakpoints []jsonBreakpoint `json:"breakpoints"`
+	}
+
+	jsonFrame struct {
+		Name     string       `json:"name"`
+		Location jsonLocation `json:"location"`
+	}
+
+	jsonFrames struct {
+		Type     string      `json:"type"`
+		Frames   []jsonFrame `json:"frames"`
+		Selected int         `json:"selected"`
+	}
+
+	jsonSelectedFrame struct {
+		Type  string    `json:"type"`
+		Index int       `json:"index"`
+		Frame jsonFrame `json:"frame"`
+	}
+
+	jsonSourceLine struct {
+		Line    int    `json:"line"`
+		Text    string `json:"text"`
+		Current bool   `json:"current,omitempty"`
+	}
+
+	jsonListing struct {
+		Type  string           `json:"type"`
+		File  string           `json:"file"`
+		Lines []jsonSourceLine `json:"lines"`
+	}
+
+	jsonVariable struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Param bool   `json:"param,omitempty"`
+	}
+
+	jsonLocals struct {
+		Type   string         `json:"type"`
+		Locals []jsonVariable `json:"locals"`
+	}
+
+	jsonEvaluation struct {
+		Type       string `json:"type"`
+		Expression string `json:"expression"`
+		Value      string `json:"value"`
+	}
+
+	jsonWatch struct {
+		ID            int    `json:"id"`
+		Expression    string `json:"expression"`
+		Value         string `json:"value,omitempty"`
+		Previous      string `json:"previous,omitempty"`
+		Changed       bool   `json:"changed,omitempty"`
+		BreakOnChange bool   `json:"breakOnChange,omitempty"`
+		Error         string `json:"error,omitempty"`
+	}
+
+	jsonWatches struct {
+		Type    string      `json:"type"`
+		Watches []jsonWatch `json:"watches"`
+	}
+
+	jsonWatchChange struct {
+		Type       string `json:"type"`
+		ID         int    `json:"id"`
+		Expression string `json:"expression"`
+		Previous   string `json:"previous"`
+		Value      string `json:"value"`
+	}
+
+	jsonSnapshot struct {
+		Type  string    `json:"type"`
+		ID    int       `json:"id"`
+		Back  int       `json:"back"`
+		Event jsonEvent `json:"event"`
+	}
+
+	jsonHistoryEntry struct {
+		ID       int                `json:"id"`
+		Reason   ferret.DebugReason `json:"reason"`
+		Location *jsonLocation      `json:"location,omitempty"`
+		Current  bool               `json:"current,omitempty"`
+		Present  bool               `json:"present,omitempty"`
+	}
+
+	jsonHistory struct {
+		Type      string             `json:"type"`
+		Snapshots []jsonHistoryEntry `json:"snapshots"`
+	}
+
+	jsonLog struct {
+		Type       string `json:"type"`
+		Breakpoint int    `json:"breakpoint"`
+		Message    string `json:"message"`
+	}
+
+	jsonCommand struct {
+		Type    string `json:"type"`
+		Command string `json:"command"`
+	}
+
+	jsonMessage struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+)
+
+func newJSONLocation(location ferret.DebugLocation) *jsonLocation {
+	if location.File == "" && location.Line == 0 {
+		return nil
+	}
+
+	return &jsonLocation{File: location.File, Line: location.Line, Column: location.Column}
+}
+
+func newJSONFrame(frame ferret.DebugFrame) jsonFrame {
+	return jsonFrame{
+		Name:     frame.Name,
+		Location: jsonLocation{File: frame.Location.File, Line: frame.Location.Line, Column: frame.Location.Column},
+	}
+}
+
+func newJSONEvent(event *ferret.DebugEvent) jsonEvent {
+	record := jsonEvent{
+		Type:     "event",
+		Reason:   event.Reason,
+		Location: newJSONLocation(event.Location),
+	}
+
+	for _, id := range event.HitBreakpointIDs {
+		record.Breakpoints = append(record.Breakpoints, int(id))
+	}
+
+	if event.Error != nil {
+		record.Error = event.Error.Error()
+	}
+
+	if event.Output != nil {
+		if json.Valid(event.Output.Content) {
+			record.Result = event.Output.Content
+		} else {
+			record.Result, _ = json.Marshal(string(event.Output.Content))
+		}
+	}
+
+	return record
+}
+
+func newJSONBreakpoint(breakpoint ferret.DebugBreakpoint, rule *BreakpointRule) jsonBreakpoint {
+	record := jsonBreakpoint{
+		ID: int(breakpoint.ID),
+		Requested: jsonLocation{
+			File:   breakpoint.File,
+			Line:   breakpoint.RequestedLine,
+			Column: breakpoint.RequestedColumn,
+		},
+		Mode: formatBindingMode(breakpoint.BindingMode),
+	}
+
+	if breakpoint.Bound {
+		record.Bound = &jsonLocation{File: breakpoint.File, Line: breakpoint.Line, Column: breakpoint.Column}
+	}
+
+	if rule != nil {
+		record.Condition = rule.Condition
+		record.HitCount = rule.HitCount
+		record.Hits = rule.Hits
+		record.Log = rule.LogMessage
+	}
+
+	return record
+}
@@ -0,0 +1,282 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MontFerret/ferret/v2"
+)
+
+// DefaultSnapshots is the number of pauses the prompt keeps for back and
+// reverse-continue unless Options asks for another size.
+const DefaultSnapshots = 100
+
+// Snapshot is the program state at a pause, kept so the prompt can step back
+// to it after the program moved on.
+type Snapshot struct {
+	// ID numbers the pauses of a session from 1. It keeps counting when old
+	// snapshots are dropped.
+	ID     int
+	Event  ferret.DebugEvent
+	Frames []ferret.DebugFrame
+	// Locals are the variables of the innermost frame.
+	Locals []ferret.DebugVariable
+}
+
+// History is a bounded record of the pauses of a debug session, oldest
+// first. The prompt is either at the present, where the program itself is,
+// or viewing one of the snapshots.
+type History struct {
+	limit     int
+	snapshots []*Snapshot
+	nextID    int
+	// live reports whether the latest snapshot is the pause the program is
+	// stopped at, rather than one before it ended.
+	live bool
+	// cursor is the index of the snapshot being viewed, or -1 at the present.
+	cursor int
+}
+
+// NewHistory keeps up to limit snapshots. A limit of zero or less records
+// nothing.
+func NewHistory(limit int) *History {
+	return &History{limit: limit, cursor: -1}
+}
+
+func (h *History) Enabled() bool {
+	return h.limit > 0
+}
+
+// Record returns to the present and, when event is a pause, captures the
+// program state at it, dropping the oldest snapshot once the history is full.
+func (h *History) Record(session Session, event *ferret.DebugEvent) {
+	h.cursor = -1
+	h.live = isPausedEvent(event)
+
+	if !h.live || !h.Enabled() {
+		return
+	}
+
+	h.nextID++
+	snapshot := &Snapshot{ID: h.nextID, Event: *event}
+
+	// A snapshot without frames or locals still shows where the program was.
+	snapshot.Frames, _ = session.Frames()
+	snapshot.Locals, _ = session.Locals()
+
+	h.snapshots = append(h.snapshots, snapshot)
+
+	if len(h.snapshots) > h.limit {
+		h.snapshots = append(h.snapshots[:0], h.snapshots[len(h.snapshots)-h.limit:]...)
+	}
+}
+
+func (h *History) Snapshots() []*Snapshot {
+	return h.snapshots
+}
+
+// Current returns the snapshot being viewed, or nil at the present.
+func (h *History) Current() *Snapshot {
+	if h.cursor < 0 {
+		return nil
+	}
+
+	return h.snapshots[h.cursor]
+}
+
+// Present returns the snapshot of the pause the program is stopped at, or
+// nil when the program has ended.
+func (h *History) Present() *Snapshot {
+	if !h.live || len(h.snapshots) == 0 {
+		return nil
+	}
+
+	return h.snapshots[len(h.snapshots)-1]
+}
+
+// Distance returns how many pauses the viewed snapshot lies before the
+// present.
+func (h *History) Distance() int {
+	return h.present() - h.position()
+}
+
+// Back moves count pauses back, stopping at the oldest snapshot. It reports
+// false when there is nothing before the current position.
+func (h *History) Back(count int) bool {
+	position := h.position()
+	if position == 0 || len(h.snapshots) == 0 {
+		return false
+	}
+
+	h.cursor = max(position-count, 0)
+
+	return true
+}
+
+// Forward moves count pauses forward. Reaching the present stops viewing.
+func (h *History) Forward(count int) {
+	h.seek(h.position() + count)
+}
+
+// BackTo moves to the latest earlier snapshot accepted by match, or to the
+// oldest one when none is. It reports false when there is nothing before the
+// current position.
+func (h *History) BackTo(match func(*Snapshot) bool) bool {
+	position := h.position()
+	if position == 0 || len(h.snapshots) == 0 {
+		return false
+	}
+
+	h.cursor = 0
+
+	for i := position - 1; i >= 0; i-- {
+		if match(h.snapshots[i]) {
+			h.cursor = i
+			break
+		}
+	}
+
+	return true
+}
+
+// ForwardTo moves to the next snapshot accepted by match, or to the present
+// when none is.
+func (h *History) ForwardTo(match func(*Snapshot) bool) {
+	present := h.present()
+
+	for i := h.position() + 1; i < present; i++ {
+		if match(h.snapshots[i]) {
+			h.cursor = i
+			return
+		}
+	}
+
+	h.cursor = -1
+}
+
+// present is the position of the program itself: the latest snapshot while
+// it is paused, or one past it after it ended.
+func (h *History) present() int {
+	if h.live {
+		return len(h.snapshots) - 1
+	}
+
+	return len(h.snapshots)
+}
+
+func (h *History) position() int {
+	if h.cursor < 0 {
+		return h.present()
+	}
+
+	return h.cursor
+}
+
+func (h *History) seek(position int) {
+	if position >= h.present() {
+		h.cursor = -1
+		return
+	}
+
+	h.cursor = max(position, 0)
+}
+
+// snapshotSession answers inspection requests from a snapshot and passes
+// everything else, such as breakpoint changes, to the live session.
+type snapshotSession struct {
+	Session
+	snapshot *Snapshot
+}
+
+func (s *snapshotSession) Frames() ([]ferret.DebugFrame, error) {
+	return s.snapshot.Frames, nil
+}
+
+func (s *snapshotSession) Locals() ([]ferret.DebugVariable, error) {
+	return s.snapshot.Locals, nil
+}
+
+// Evaluate returns the recorded value of a variable. Other expressions cannot
+// be evaluated, because the program has moved on since the snapshot.
+func (s *snapshotSession) Evaluate(_ context.Context, expression string) (ferret.DebugValue, error) {
+	name := strings.TrimSpace(expression)
+
+	for _, variable := range s.snapshot.Locals {
+		if variable.Name == name {
+			return variable.Value, nil
+		}
+	}
+
+	return ferret.DebugValue{}, fmt.Errorf("%q was not recorded in snapshot %d; only local variables can be printed from the history", name, s.snapshot.ID)
+}
+
+// timeTravel runs back, reverse-continue, and history, and the resume
+// commands while a snapshot is viewed. Resuming from a snapshot moves forward
+// through the history instead of running the program: step, next, and out
+// move one pause, and continue moves to the next breakpoint or the present.
+func timeTravel(session Session, renderer *Renderer, state *promptState, command Command) {
+	history := state.history
+
+	if !history.Enabled() {
+		renderer.Failure("Time travel is disabled; no pauses are recorded.")
+		return
+	}
+
+	matchBreakpoint := func(snapshot *Snapshot) bool {
+		return atBreakpoint(session.Breakpoints(), state.rules, snapshot.Event.Location)
+	}
+
+	switch command.Name {
+	case CommandHistory:
+		renderer.History(history.Snapshots(), history.Current(), history.Present())
+		return
+	case CommandBack:
+		if !history.Back(command.Count) {
+			renderer.Failure("No earlier pauses recorded.")
+			return
+		}
+	case CommandReverse:
+		if !history.BackTo(matchBreakpoint) {
+			renderer.Failure("No earlier pauses recorded.")
+			return
+		}
+	case CommandContinue:
+		history.ForwardTo(matchBreakpoint)
+	default:
+		history.Forward(1)
+	}
+
+	state.frame = 0
+
+	if snapshot := history.Current(); snapshot != nil {
+		state.location = snapshot.Event.Location
+		renderer.Snapshot(snapshot, history.Distance())
+		return
+	}
+
+	state.location = ferret.DebugLocation{}
+	renderer.Message("Back at the present.")
+
+	if present := history.Present(); present != nil {
+		state.location = present.Event.Location
+		renderer.Event(&present.Event)
+	}
+}
+
+// atBreakpoint reports whether a breakpoint, not counting logpoints, is bound
+// at location.
+func atBreakpoint(breakpoints []ferret.DebugBreakpoint, rules BreakpointRules, location ferret.DebugLocation) bool {
+	for _, breakpoint := range breakpoints {
+		if rule := rules[breakpoint.ID]; rule != nil && rule.IsLogpoint() {
+			continue
+		}
+
+		if breakpoint.Bound && breakpoint.File == location.File && breakpoint.Line == location.Line &&
+			(breakpoint.Column == 0 || breakpoint.Column == location.Column) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -23,9 +23,81 @@ const (
 	replStateTerminated
 )
 
-func Start(ctx context.Context, session Session, src *source.Source) error {
+// Options configures what a prompt sets up before the program starts and
+// what it keeps between sessions.
+type Options struct {
+	// Format selects the output of scripted sessions. The interactive prompt
+	// always writes text.
+	Format Format
+	// HistoryFile keeps interactive prompt history between sessions when set.
+	HistoryFile string
+	// Breakpoints are break and logpoint commands set before the program
+	// starts.
+	Breakpoints []Command
+	// BreakpointFile is loaded before the program starts and rewritten when
+	// the prompt quits, if breakpoints were added or deleted in between.
+	BreakpointFile string
+	// Snapshots is the number of pauses kept for back and reverse-continue.
+	// Zero disables time travel.
+	Snapshots int
+}
+
+// promptState is what the prompt remembers between commands.
+type promptState struct {
+	mainFile string
+	rules    BreakpointRules
+	watches  *WatchList
+	// initial holds the breakpoints to set before the program starts.
+	initial        []Command
+	breakpointFile string
+	// retained keeps breakpoint file lines that could not be set in this
+	// session, such as breakpoints in other scripts, so saving preserves them.
+	retained []string
+	// changed reports whether breakpoints were added or deleted since the
+	// breakpoint file was loaded.
+	changed bool
+	// frame is the stack index selected with up, down, or frame. It is reset
+	// to the innermost frame whenever the program pauses.
+	frame int
+	// location is where the program last paused, or where the viewed
+	// snapshot paused, or zero when neither applies.
+	location ferret.DebugLocation
+	history  *History
+}
+
+func newPromptState(mainFile string, opts Options) *promptState {
+	return &promptState{
+		mainFile:       mainFile,
+		rules:          make(BreakpointRules),
+		watches:        new(WatchList),
+		initial:        opts.Breakpoints,
+		breakpointFile: opts.BreakpointFile,
+		history:        NewHistory(opts.Snapshots),
+	}
+}
+
+func (s *promptState) paused(session Session, event *ferret.DebugEvent) {
+	if event == nil {
+		return
+	}
+
+	s.history.Record(session, event)
+	s.frame = 0
+	s.location = ferret.DebugLocation{}
+
+	if isPausedEvent(event) {
+		s.location = event.Location
+	}
+}
+
+// Start runs an interactive prompt on the terminal.
+func Start(ctx context.Context, session Session, src *source.Source, opts Options) error {
+	state := newPromptState(src.Name(), opts)
+
 	rl, err := readline.NewEx(&readline.Config{
 		Prompt:          "(fdb) ",
+		HistoryFile:     opts.HistoryFile,
+		AutoComplete:    newCompleter(session, state),
 		InterruptPrompt: "^C",
 		EOFPrompt:       "\n",
 		Stdin:           os.Stdin,
@@ -38,26 +110,55 @@ func Start(ctx context.Context, session Session, src *source.Source) error {
 	}
 	defer rl.Close()
 
-	return Run(ctx, session, src, rl, rl.Stdout())
+	return run(ctx, session, state, rl, NewRenderer(rl.Stdout(), src), false)
 }
 
-func Run(ctx context.Context, session Session, src *source.Source, input LineReader, out io.Writer) (err error) {
+func Run(ctx context.Context, session Session, src *source.Source, input LineReader, out io.Writer) error {
+	return run(ctx, session, newPromptState(src.Name(), Options{}), input, NewRenderer(out, src), false)
+}
+
+// Script replays debugger commands from input without a terminal and exits
+// when the script ends. Each command is echoed before it runs so the output
+// reads like a transcript. It returns ErrScriptFailed when any command
+// reported an error.
+func Script(ctx context.Context, session Session, src *source.Source, input io.Reader, out io.Writer, opts Options) error {
+	renderer := NewRenderer(out, src)
+	if opts.Format == FormatJSON {
+		renderer = NewJSONRenderer(out, src)
+	}
+
+	if err := run(ctx, session, newPromptState(src.Name(), opts), NewScriptReader(input), renderer, true); err != nil {
+		return err
+	}
+
+	if failures := renderer.Failures(); failures > 0 {
+		return fmt.Errorf("%w: %d command(s) reported errors", ErrScriptFailed, failures)
+	}
+
+	return nil
+}
+
+func run(ctx context.Context, session Session, prompt *promptState, input LineReader, renderer *Renderer, echo bool) (err error) {
 	defer func() {
 		err = errors.Join(err, session.Close())
 	}()
 
-	renderer := NewRenderer(out, src)
+	renderer.Message("Ferret debugger started.")
+	setInitialBreakpoints(session, renderer, prompt)
 
-	fmt.Fprintln(out, "Ferret debugger started.")
 	event, err := session.Start(ctx)
+	event, err = resolveBreakpoint(ctx, session, prompt.rules, renderer, CommandContinue, -1, event, err)
 	if err != nil {
 		return err
 	}
 
 	renderer.Event(event)
+	prompt.paused(session, event)
 	state := nextReplState(replStateReady, event)
 
-	fmt.Fprintln(out, `Type "help" for available commands.`)
+	if !echo {
+		renderer.Message(`Type "help" for available commands.`)
+	}
 
 	var repeatCommand Command
 
@@ -69,7 +170,8 @@ func Run(ctx context.Context, session Session, src *source.Source, input LineRea
 		}
 
 		if errors.Is(readErr, io.EOF) {
-			fmt.Fprintln(out, "Debug session terminated.")
+			saveBreakpointFile(session, renderer, prompt)
+			renderer.Message("Debug session terminated.")
 			return nil
 		}
 
@@ -77,10 +179,14 @@ func Run(ctx context.Context, session Session, src *source.Source, input LineRea
 			return readErr
 		}
 
+		if echo {
+			renderer.Command(line)
+		}
+
 		command, parseErr := ParseCommand(line)
 
 		if parseErr != nil {
-			fmt.Fprintln(out, parseErr)
+			renderer.Failure(parseErr.Error())
 			continue
 		}
 
@@ -96,30 +202,50 @@ func Run(ctx context.Context, session Session, src *source.Source, input LineRea
 			repeatCommand = Command{}
 		}
 
-		if message := unavailableCommandMessage(state, command.Name); message != "" {
-			fmt.Fprintln(out, message)
+		if message := unavailableCommandMessage(state, prompt.history.Current() != nil, command.Name); message != "" {
+			renderer.Failure(message)
 			continue
 		}
 
-		quit, event := executeCommand(ctx, session, src.Name(), renderer, command)
+		quit, event := executeCommand(ctx, session, renderer, prompt, command)
 		state = nextReplState(state, event)
+		prompt.paused(session, event)
+
+		if isPausedEvent(event) && len(prompt.watches.Items()) > 0 {
+			prompt.watches.Refresh(ctx, session)
+			renderer.Watches(prompt.watches.Items())
+		}
 
 		if quit {
-			fmt.Fprintln(out, "Debug session terminated.")
+			saveBreakpointFile(session, renderer, prompt)
+			renderer.Message("Debug session terminated.")
 			return nil
 		}
 	}
 }
 
-func executeCommand(ctx context.Context, session Session, mainFile string, renderer *Renderer, command Command) (bool, *ferret.DebugEvent) {
+func executeCommand(ctx context.Context, session Session, renderer *Renderer, state *promptState, command Command) (bool, *ferret.DebugEvent) {
+	rules := state.rules
+	watches := state.watches
+
+	if snapshot := state.history.Current(); snapshot != nil {
+		switch command.Name {
+		case CommandContinue, CommandStep, CommandNext, CommandOut:
+			timeTravel(session, renderer, state, command)
+			return false, nil
+		}
+
+		session = &snapshotSession{Session: session, snapshot: snapshot}
+	}
+
 	switch command.Name {
 	case CommandHelp:
 		renderer.Help()
-	case CommandBreak:
+	case CommandBreak, CommandLogpoint:
 		location := command.Location
 
 		if location.File == "" {
-			location.File = mainFile
+			location.File = state.mainFile
 		}
 
 		breakpoint, err := session.SetBreakpointAt(location, command.BreakpointOptions)
@@ -127,37 +253,47 @@ func executeCommand(ctx context.Context, session Session, mainFile string, rende
 		if err != nil {
 			renderer.Error("Breakpoint error", err)
 		} else {
-			renderer.BreakpointSet(breakpoint)
+			rule := command.BreakpointRule
+			rules[breakpoint.ID] = &rule
+			state.changed = true
+			renderer.BreakpointSet(breakpoint, &rule)
 		}
 	case CommandDelete:
 		if err := session.DeleteBreakpoint(command.BreakpointID); err != nil {
 			if errors.Is(err, runtime.ErrNotFound) {
-				fmt.Fprintf(renderer.out, "Unknown breakpoint: %d\n", command.BreakpointID)
+				renderer.Failure(fmt.Sprintf("Unknown breakpoint: %d", command.BreakpointID))
 			} else {
 				renderer.Error("Delete breakpoint error", err)
 			}
 		} else {
-			fmt.Fprintf(renderer.out, "Breakpoint %d deleted.\n", command.BreakpointID)
+			delete(rules, command.BreakpointID)
+			state.changed = true
+			renderer.Message(fmt.Sprintf("Breakpoint %d deleted.", command.BreakpointID))
 		}
 	case CommandBreakpoints:
-		renderer.Breakpoints(session.Breakpoints())
+		renderer.Breakpoints(session.Breakpoints(), rules)
 	case CommandContinue:
-		event, err := session.Continue(ctx)
+		if watches.HasDataBreakpoints() {
+			event, err := continueUntilChange(ctx, session, rules, watches, renderer)
+			return false, renderResume(event, err, renderer)
+		}
+
+		event, err := resume(ctx, session, rules, renderer, CommandContinue)
 		return false, renderResume(event, err, renderer)
 	case CommandStep:
-		event, err := session.Step(ctx)
+		event, err := resume(ctx, session, rules, renderer, CommandStep)
 		return false, renderResume(event, err, renderer)
 	case CommandNext:
-		event, err := session.Next(ctx)
+		event, err := resume(ctx, session, rules, renderer, CommandNext)
 		return false, renderResume(event, err, renderer)
 	case CommandOut:
-		event, err := session.Out(ctx)
+		event, err := resume(ctx, session, rules, renderer, CommandOut)
 		return false, renderResume(event, err, renderer)
 	case CommandPause:
 		if err := session.Pause(); err != nil {
 			renderer.Error("Pause error", err)
 		} else {
-			fmt.Fprintln(renderer.out, "Pause requested.")
+			renderer.Message("Pause requested.")
 		}
 	case CommandWhere:
 		frames, err := session.Frames()
@@ -165,10 +301,18 @@ func executeCommand(ctx context.Context, session Session, mainFile string, rende
 		if err != nil {
 			renderer.Error("Stack error", err)
 		} else {
-			renderer.Frames(frames)
+			renderer.Frames(frames, state.frame)
 		}
+	case CommandUp:
+		selectFrame(session, renderer, state, state.frame+command.Count, command.Name)
+	case CommandDown:
+		selectFrame(session, renderer, state, state.frame-command.Count, command.Name)
+	case CommandFrame:
+		selectFrame(session, renderer, state, command.Count, command.Name)
+	case CommandList:
+		listSource(session, renderer, state, command.Count)
 	case CommandLocals:
-		locals, err := session.Locals()
+		locals, err := frameLocals(session, state.frame)
 
 		if err != nil {
 			renderer.Error("Locals error", err)
@@ -176,13 +320,34 @@ func executeCommand(ctx context.Context, session Session, mainFile string, rende
 			renderer.Locals(locals)
 		}
 	case CommandPrint:
-		value, err := session.Evaluate(ctx, command.Argument)
+		value, err := evaluateInFrame(ctx, session, state.frame, command.Argument)
 
 		if err != nil {
 			renderer.Error("Evaluation error", err)
 		} else {
-			renderer.Evaluation(value)
+			renderer.Evaluation(command.Argument, value)
+		}
+	case CommandWatch:
+		if command.Argument == "" {
+			renderer.Watches(watches.Items())
+			break
+		}
+
+		watch := watches.Add(command.Argument, false)
+		watch.evaluate(ctx, session)
+		renderer.Watches([]*Watch{watch})
+	case CommandBreakChange:
+		watch := watches.Add(command.Argument, true)
+		watch.evaluate(ctx, session)
+		renderer.Watches([]*Watch{watch})
+	case CommandUnwatch:
+		if watches.Remove(command.WatchID) {
+			renderer.Message(fmt.Sprintf("Watch %d removed.", command.WatchID))
+		} else {
+			renderer.Failure(fmt.Sprintf("Unknown watch: %d", command.WatchID))
 		}
+	case CommandBack, CommandReverse, CommandHistory:
+		timeTravel(session, renderer, state, command)
 	case CommandQuit:
 		return true, nil
 	}
@@ -190,6 +355,176 @@ func executeCommand(ctx context.Context, session Session, mainFile string, rende
 	return false, nil
 }
 
+// setInitialBreakpoints sets the breakpoints from the breakpoint file, then
+// the ones passed in Options that the file does not already contain. A file
+// that cannot be read is reported and never overwritten.
+func setInitialBreakpoints(session Session, renderer *Renderer, state *promptState) {
+	var saved []Command
+
+	if state.breakpointFile != "" {
+		commands, err := LoadBreakpointFile(state.breakpointFile)
+		if err != nil {
+			renderer.Error("Breakpoint file error", err)
+			state.breakpointFile = ""
+		}
+
+		saved = commands
+	}
+
+	seen := make(map[string]bool)
+
+	set := func(command Command, fromFile bool) {
+		location := command.Location
+		if location.File == "" {
+			location.File = state.mainFile
+		}
+
+		line := formatBreakpointCommand(location, command.BreakpointOptions, command.BreakpointRule)
+		if seen[line] {
+			return
+		}
+
+		seen[line] = true
+
+		breakpoint, err := session.SetBreakpointAt(location, command.BreakpointOptions)
+		if err != nil {
+			if fromFile {
+				state.retained = append(state.retained, line)
+			} else {
+				renderer.Error("Breakpoint error", err)
+			}
+
+			return
+		}
+
+		rule := command.BreakpointRule
+		state.rules[breakpoint.ID] = &rule
+		state.changed = state.changed || !fromFile
+		renderer.BreakpointSet(breakpoint, &rule)
+	}
+
+	for _, command := range saved {
+		set(command, true)
+	}
+
+	for _, command := range state.initial {
+		set(command, false)
+	}
+
+	if len(state.retained) > 0 {
+		renderer.Message(fmt.Sprintf("Kept %d breakpoint(s) from %s that do not apply to this script.", len(state.retained), state.breakpointFile))
+	}
+}
+
+func saveBreakpointFile(session Session, renderer *Renderer, state *promptState) {
+	if state.breakpointFile == "" || !state.changed {
+		return
+	}
+
+	if err := saveBreakpoints(state.breakpointFile, session, state.rules, state.retained); err != nil {
+		renderer.Error("Breakpoint file error", err)
+		return
+	}
+
+	renderer.Message(fmt.Sprintf("Breakpoints saved to %s.", state.breakpointFile))
+}
+
+// selectFrame makes the frame at index the target of locals, print, and list.
+// up and down stop at the ends of the stack; frame rejects unknown indexes.
+func selectFrame(session Session, renderer *Renderer, state *promptState, index int, name CommandName) {
+	frames, err := session.Frames()
+	if err != nil {
+		renderer.Error("Stack error", err)
+		return
+	}
+
+	if len(frames) == 0 {
+		renderer.Failure("No stack frames available.")
+		return
+	}
+
+	switch {
+	case name == CommandFrame && index >= len(frames):
+		renderer.Failure(fmt.Sprintf("Unknown frame: %d", index))
+		return
+	case name == CommandUp && state.frame == len(frames)-1:
+		renderer.Failure("Already at the outermost frame.")
+		return
+	case name == CommandDown && state.frame == 0:
+		renderer.Failure("Already at the innermost frame.")
+		return
+	}
+
+	index = min(max(index, 0), len(frames)-1)
+	state.frame = index
+	renderer.FrameSelected(index, frames[index])
+}
+
+// listSource shows the source around line, or around the selected frame when
+// line is 0.
+func listSource(session Session, renderer *Renderer, state *promptState, line int) {
+	current := state.location
+
+	if state.frame > 0 {
+		frames, err := session.Frames()
+		if err != nil {
+			renderer.Error("Stack error", err)
+			return
+		}
+
+		if state.frame < len(frames) {
+			current = frames[state.frame].Location
+		}
+	}
+
+	if current.File != "" && current.File != state.mainFile {
+		current = ferret.DebugLocation{}
+	}
+
+	if line == 0 {
+		line = current.Line
+	}
+
+	if line == 0 {
+		renderer.Failure("No current location; use list <line>.")
+		return
+	}
+
+	renderer.Listing(line, current.Line)
+}
+
+// frameLocals and evaluateInFrame inspect the selected frame. Sessions that do
+// not implement FrameInspector can only inspect the innermost frame.
+func frameLocals(session Session, frame int) ([]ferret.DebugVariable, error) {
+	if frame == 0 {
+		return session.Locals()
+	}
+
+	inspector, ok := session.(FrameInspector)
+	if !ok {
+		return nil, errFrameInspection(frame)
+	}
+
+	return inspector.FrameLocals(frame)
+}
+
+func evaluateInFrame(ctx context.Context, session Session, frame int, expression string) (ferret.DebugValue, error) {
+	if frame == 0 {
+		return session.Evaluate(ctx, expression)
+	}
+
+	inspector, ok := session.(FrameInspector)
+	if !ok {
+		return ferret.DebugValue{}, errFrameInspection(frame)
+	}
+
+	return inspector.EvaluateInFrame(ctx, frame, expression)
+}
+
+func errFrameInspection(frame int) error {
+	return fmt.Errorf("this session can only inspect the innermost frame; frame %d is selected (use \"frame 0\")", frame)
+}
+
 func renderResume(event *ferret.DebugEvent, err error, renderer *Renderer) *ferret.DebugEvent {
 	if err != nil {
 		renderer.Error("Debugger error", err)
@@ -217,16 +552,23 @@ func nextReplState(current replState, event *ferret.DebugEvent) replState {
 
 func isRepeatableCommand(name CommandName) bool {
 	switch name {
-	case CommandContinue, CommandStep, CommandNext, CommandOut:
+	case CommandContinue, CommandStep, CommandNext, CommandOut, CommandBack, CommandReverse:
 		return true
 	default:
 		return false
 	}
 }
 
-func unavailableCommandMessage(state replState, name CommandName) string {
+// unavailableCommandMessage explains why a command cannot run in state.
+// Viewing a snapshot keeps inspection and resume commands available after the
+// program ended, because they read and move through the history.
+func unavailableCommandMessage(state replState, viewing bool, name CommandName) string {
+	if viewing && name != CommandPause {
+		return ""
+	}
+
 	switch name {
-	case CommandContinue, CommandStep, CommandNext, CommandOut, CommandPause, CommandWhere, CommandLocals, CommandPrint:
+	case CommandContinue, CommandStep, CommandNext, CommandOut, CommandPause, CommandWhere, CommandUp, CommandDown, CommandFrame, CommandLocals, CommandPrint:
 	default:
 		return ""
 	}
@@ -3,6 +3,7 @@ package debugger
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"strings"
@@ -383,6 +384,433 @@ func TestRunResumeErrorPreservesState(t *testing.T) {
 	}
 }
 
+func TestRunConditionalBreakpointSkipsFalsyHits(t *testing.T) {
+	hit := debugEvent(ferret.DebugReasonBreakpoint, "demo.fql", 2, source.Span{})
+	hit.HitBreakpointIDs = []ferret.DebugBreakpointID{1}
+
+	session := &fakeSession{
+		startEvent:     debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		continueEvents: []*ferret.DebugEvent{hit, hit, hit},
+		continueEvent:  debugEvent(ferret.DebugReasonCompleted, "", 0, source.Span{}),
+		evaluations: []ferret.DebugValue{
+			{Display: "false"},
+			{Display: "true"},
+			{Display: "true"},
+		},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "FOR x IN 1..3\nRETURN x"), &fakeLineReader{
+		results: []lineResult{
+			{line: "break 2 hits=2 if x > 0"},
+			{line: "continue"},
+			{line: "breakpoints"},
+			{line: "q"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.continueCalls != 3 || session.evaluateCalls != 3 {
+		t.Fatalf("unexpected session calls: %#v", session)
+	}
+	if session.expression != "x > 0" {
+		t.Fatalf("unexpected condition: %q", session.expression)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"Breakpoint 1: if x > 0, from hit 2.",
+		"Paused on breakpoint 1 at demo.fql:2:1",
+		"2/2",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+}
+
+func TestRunNextFinishesStepOverDeclinedBreakpoint(t *testing.T) {
+	main := ferret.DebugFrame{Name: "main"}
+	inc := ferret.DebugFrame{Name: "inc"}
+	hit := debugEvent(ferret.DebugReasonBreakpoint, "demo.fql", 2, source.Span{})
+	hit.HitBreakpointIDs = []ferret.DebugBreakpointID{1}
+
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 5, source.Span{}),
+		nextEvents: []*ferret.DebugEvent{
+			hit,
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 6, source.Span{}),
+		},
+		outEvents:     []*ferret.DebugEvent{debugEvent(ferret.DebugReasonStep, "demo.fql", 5, source.Span{})},
+		continueEvent: debugEvent(ferret.DebugReasonCompleted, "", 0, source.Span{}),
+		frameStacks: [][]ferret.DebugFrame{
+			{main},
+			{inc, main},
+			{main},
+			{main},
+		},
+		evaluation: ferret.DebugValue{Display: "false"},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "FUNC inc(x) (\n  RETURN x + 1\n)\nLET y = 1\nLET z = inc(y)\nRETURN z"), &fakeLineReader{
+		results: []lineResult{
+			{line: "break 2 if x > 5"},
+			{line: "next"},
+			{line: "q"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.nextCalls != 2 || session.outCalls != 1 || session.continueCalls != 0 {
+		t.Fatalf("expected next to finish in the calling frame: %#v", session)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "Paused after step at demo.fql:6:1") {
+		t.Fatalf("expected step pause in %q", got)
+	}
+	if strings.Contains(got, "Paused on breakpoint") {
+		t.Fatalf("declined breakpoint should not pause: %q", got)
+	}
+}
+
+func TestRunStepEndsOnDeclinedBreakpoint(t *testing.T) {
+	hit := debugEvent(ferret.DebugReasonBreakpoint, "demo.fql", 2, source.Span{})
+	hit.HitBreakpointIDs = []ferret.DebugBreakpointID{1}
+
+	session := &fakeSession{
+		startEvent:    debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		stepEvents:    []*ferret.DebugEvent{hit},
+		continueEvent: debugEvent(ferret.DebugReasonCompleted, "", 0, source.Span{}),
+		frames:        []ferret.DebugFrame{{Name: "main"}},
+		evaluation:    ferret.DebugValue{Display: "0"},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "LET x = 0\nRETURN x"), &fakeLineReader{
+		results: []lineResult{
+			{line: "break 2 if x"},
+			{line: "step"},
+			{line: "q"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.stepCalls != 1 || session.continueCalls != 0 {
+		t.Fatalf("expected the step to end where it stopped: %#v", session)
+	}
+	if !strings.Contains(out.String(), "Paused after step at demo.fql:2:1") {
+		t.Fatalf("expected step pause in %q", out.String())
+	}
+}
+
+func TestIsTruthyUsesFQLTruthiness(t *testing.T) {
+	tests := map[string]bool{
+		"true":          true,
+		"false":         false,
+		"null":          false,
+		"0":             false,
+		"0.5":           true,
+		`""`:            false,
+		`"false"`:       true,
+		`"0"`:           true,
+		"[]":            true,
+		`{}`:            true,
+		"<HTMLElement>": true,
+		"":              false,
+	}
+
+	for display, want := range tests {
+		if got := isTruthy(ferret.DebugValue{Display: display}); got != want {
+			t.Fatalf("isTruthy(%q) = %v, want %v", display, got, want)
+		}
+	}
+}
+
+func TestRunLogpointLogsWithoutPausing(t *testing.T) {
+	hit := debugEvent(ferret.DebugReasonBreakpoint, "demo.fql", 2, source.Span{})
+	hit.HitBreakpointIDs = []ferret.DebugBreakpointID{1}
+
+	session := &fakeSession{
+		startEvent:     debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		continueEvents: []*ferret.DebugEvent{hit, hit},
+		continueEvent:  debugEvent(ferret.DebugReasonCompleted, "", 0, source.Span{}),
+		evaluations:    []ferret.DebugValue{{Display: "1"}, {Display: "2"}},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "FOR x IN 1..2\nRETURN x"), &fakeLineReader{
+		results: []lineResult{
+			{line: `lp 2 "x is {{ x }}"`},
+			{line: "continue"},
+			{line: "q"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.continueCalls != 3 {
+		t.Fatalf("unexpected session calls: %#v", session)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"Logpoint 1: x is 1",
+		"Logpoint 1: x is 2",
+		"Program completed.",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+	if strings.Contains(got, "Paused on breakpoint") {
+		t.Fatalf("logpoint should not pause: %q", got)
+	}
+}
+
+func TestRunFrameNavigationScopesInspection(t *testing.T) {
+	src := source.New("demo.fql", "FUNC inc(x) (\n  RETURN x + 1\n)\nLET y = 1\nRETURN inc(y)")
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonBreakpoint, "demo.fql", 2, source.Span{Start: 15, End: 27}),
+		frames: []ferret.DebugFrame{
+			{Name: "inc", Location: ferret.DebugLocation{File: "demo.fql", Line: 2, Column: 3}},
+			{Name: "<main>", Location: ferret.DebugLocation{File: "demo.fql", Line: 5, Column: 8}},
+		},
+		locals: []ferret.DebugVariable{{Name: "x", Value: ferret.DebugValue{Display: "1"}}},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, src, &fakeLineReader{results: []lineResult{
+		{line: "list"},
+		{line: "down"},
+		{line: "up"},
+		{line: "up"},
+		{line: "where"},
+		{line: "list"},
+		{line: "locals"},
+		{line: "print y"},
+		{line: "frame 5"},
+		{line: "frame 0"},
+		{line: "locals"},
+		{line: "q"},
+	}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.localsCalls != 1 || session.evaluateCalls != 0 {
+		t.Fatalf("expected only the innermost frame to be inspected: %#v", session)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"=> 2 |   RETURN x + 1",
+		"Already at the innermost frame.",
+		"#1 <main> at demo.fql:5:8",
+		"Already at the outermost frame.",
+		"  #0 inc at demo.fql:2:3",
+		"* #1 <main> at demo.fql:5:8",
+		"   2 |   RETURN x + 1\n   3 | )\n   4 | LET y = 1\n=> 5 | RETURN inc(y)",
+		"Locals error: this session can only inspect the innermost frame; frame 1 is selected",
+		"Evaluation error: this session can only inspect the innermost frame; frame 1 is selected",
+		"Unknown frame: 5",
+		"#0 inc at demo.fql:2:3",
+		"x = 1",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+}
+
+func TestRunListRequiresLocationAfterCompletion(t *testing.T) {
+	session := &fakeSession{
+		startEvent: &ferret.DebugEvent{Reason: ferret.DebugReasonCompleted},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "LET x = 1\nRETURN x"), &fakeLineReader{results: []lineResult{
+		{line: "list"},
+		{line: "list 2"},
+		{line: "list 9"},
+		{line: "up"},
+	}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"No current location; use list <line>.",
+		"   1 | LET x = 1\n   2 | RETURN x\n",
+		"Line 9 is out of range; demo.fql has 2 lines.",
+		"Program has completed.",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+}
+
+func TestScriptJSONEmitsOneRecordPerOutput(t *testing.T) {
+	session := &fakeSession{
+		startEvent:    debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		continueEvent: debugEvent(ferret.DebugReasonPause, "demo.fql", 2, source.Span{}),
+		locals:        []ferret.DebugVariable{{Name: "x", Value: ferret.DebugValue{Display: "1"}}},
+		evaluation:    ferret.DebugValue{Display: "2"},
+	}
+	script := strings.NewReader("# regression check\nbreak 2\n\ncontinue\nlocals\nprint x + 1\n")
+	var out bytes.Buffer
+
+	if err := Script(context.Background(), session, source.New("demo.fql", "LET x = 1\nRETURN x"), script, &out, Options{Format: FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	if session.closeCalls != 1 {
+		t.Fatalf("expected session to be closed: %#v", session)
+	}
+
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		types = append(types, record["type"].(string))
+	}
+
+	expected := []string{
+		"message", "event",
+		"command", "breakpoint",
+		"command", "event",
+		"command", "locals",
+		"command", "evaluation",
+		"message",
+	}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected record types: %v", types)
+	}
+
+	for _, expected := range []string{
+		`{"type":"command","command":"break 2"}`,
+		`{"type":"locals","locals":[{"name":"x","value":"1"}]}`,
+		`{"type":"evaluation","expression":"x + 1","value":"2"}`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected %s in %s", expected, out.String())
+		}
+	}
+}
+
+func TestScriptReportsFailedCommands(t *testing.T) {
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+	}
+	var out bytes.Buffer
+
+	err := Script(context.Background(), session, source.New("demo.fql", "RETURN 1"), strings.NewReader("wat\ndelete 9\n"), &out, Options{})
+	if !errors.Is(err, ErrScriptFailed) {
+		t.Fatalf("expected script failure, got %v", err)
+	}
+
+	got := out.String()
+	for _, expected := range []string{"(fdb) wat", "unknown command: wat", "(fdb) delete 9", "Unknown breakpoint: 9", "Debug session terminated."} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+	if strings.Contains(got, `Type "help"`) {
+		t.Fatalf("scripted sessions should not print the prompt hint: %q", got)
+	}
+}
+
+func TestRunWatchesRefreshOnEveryPause(t *testing.T) {
+	session := &fakeSession{
+		startEvent:    debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		continueEvent: debugEvent(ferret.DebugReasonStep, "demo.fql", 2, source.Span{}),
+		evaluations:   []ferret.DebugValue{{Display: "1"}, {Display: "1"}, {Display: "3"}},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "VAR total = 1\ntotal = total + 2"), &fakeLineReader{
+		results: []lineResult{
+			{line: "watch total"},
+			{line: "next"},
+			{line: "next"},
+			{line: "unwatch 1"},
+			{line: "unwatch 1"},
+			{line: "watch"},
+			{line: "q"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.evaluateCalls != 3 {
+		t.Fatalf("expected one evaluation per pause: %#v", session)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"  1  total = 1",
+		"* 1  total = 3  (was 1)",
+		"Watch 1 removed.",
+		"Unknown watch: 1",
+		"No watches.",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+}
+
+func TestRunBreakOnChangeStepsUntilValueChanges(t *testing.T) {
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		stepEvents: []*ferret.DebugEvent{
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 2, source.Span{}),
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 3, source.Span{}),
+		},
+		continueEvent: debugEvent(ferret.DebugReasonCompleted, "", 0, source.Span{}),
+		evaluations: []ferret.DebugValue{
+			{Display: "0"},
+			{Display: "0"},
+			{Display: "0"},
+			{Display: "3"},
+			{Display: "3"},
+		},
+	}
+	var out bytes.Buffer
+
+	err := Run(context.Background(), session, source.New("demo.fql", "VAR total = 0\nLET x = 3\ntotal = x"), &fakeLineReader{
+		results: []lineResult{
+			{line: "boc total"},
+			{line: "continue"},
+			{line: "q"},
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.stepCalls != 2 || session.continueCalls != 0 {
+		t.Fatalf("expected continue to step until the value changed: %#v", session)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		"Watch 1: total changed from 0 to 3",
+		"Paused after step at demo.fql:3:1",
+		"* 1  total = 3  (was 0) [break-on-change]",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q in %q", expected, got)
+		}
+	}
+}
+
 type lineResult struct {
 	line string
 	err  error
@@ -402,19 +830,116 @@ func (f *fakeLineReader) Readline() (string, error) {
 	return result.line, result.err
 }
 
+func TestScriptStepsBackThroughHistory(t *testing.T) {
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		stepEvents: []*ferret.DebugEvent{
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 2, source.Span{}),
+			{Reason: ferret.DebugReasonCompleted},
+		},
+		locals:     []ferret.DebugVariable{{Name: "x", Value: ferret.DebugValue{Display: "1"}}},
+		stepLocals: [][]ferret.DebugVariable{{{Name: "x", Value: ferret.DebugValue{Display: "2"}}}},
+	}
+	script := strings.NewReader("step\nstep\nback\nprint x\nback\nprint x\nlocals\nstep\nstep\n")
+	var out bytes.Buffer
+
+	if err := Script(context.Background(), session, source.New("demo.fql", "LET x = 1\nLET y = x + 1\nRETURN y"), script, &out, Options{Snapshots: 10}); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out.String())
+	}
+	if session.stepCalls != 2 || session.evaluateCalls != 0 {
+		t.Fatalf("expected history to be served without the live session: %#v", session)
+	}
+
+	got := out.String()
+	expected := []string{
+		"Program completed.",
+		"(fdb) back", "Viewing pause #2, 1 before the present.",
+		"(fdb) print x", "2",
+		"(fdb) back", "Viewing pause #1, 2 before the present.",
+		"(fdb) print x", "1",
+		"(fdb) locals", "x = 1",
+		"(fdb) step", "Viewing pause #2, 1 before the present.",
+		"(fdb) step", "Back at the present.",
+	}
+	offset := 0
+	for _, text := range expected {
+		index := strings.Index(got[offset:], text)
+		if index < 0 {
+			t.Fatalf("expected %q after offset %d in %q", text, offset, got)
+		}
+		offset += index + len(text)
+	}
+}
+
+func TestScriptReverseContinueStopsAtBreakpoints(t *testing.T) {
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+		stepEvents: []*ferret.DebugEvent{
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 2, source.Span{}),
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 3, source.Span{}),
+			debugEvent(ferret.DebugReasonStep, "demo.fql", 4, source.Span{}),
+		},
+	}
+	script := strings.NewReader("break 2\nstep\nstep\nstep\nrc\nhistory\ncontinue\nrc\nrc\n")
+	var out bytes.Buffer
+
+	if err := Script(context.Background(), session, source.New("demo.fql", "LET a = 1\nLET b = 2\nLET c = 3\nRETURN a"), script, &out, Options{Format: FormatJSON, Snapshots: 10}); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out.String())
+	}
+	if session.continueCalls != 0 {
+		t.Fatalf("expected continue to move through the history: %#v", session)
+	}
+
+	got := out.String()
+	for _, expected := range []string{
+		`{"type":"snapshot","id":2,"back":2,"event":{"type":"event","reason":"step","location":{"file":"demo.fql","line":2,"column":1}}}`,
+		`{"id":2,"reason":"step","location":{"file":"demo.fql","line":2,"column":1},"current":true}`,
+		`{"id":4,"reason":"step","location":{"file":"demo.fql","line":4,"column":1},"present":true}`,
+		`{"type":"message","message":"Back at the present."}`,
+		`{"type":"snapshot","id":1,"back":3,`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %s in %s", expected, got)
+		}
+	}
+}
+
+func TestScriptRejectsTimeTravelWhenDisabled(t *testing.T) {
+	session := &fakeSession{
+		startEvent: debugEvent(ferret.DebugReasonEntry, "demo.fql", 1, source.Span{}),
+	}
+	var out bytes.Buffer
+
+	err := Script(context.Background(), session, source.New("demo.fql", "RETURN 1"), strings.NewReader("back\n"), &out, Options{})
+	if !errors.Is(err, ErrScriptFailed) {
+		t.Fatalf("expected script failure, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Time travel is disabled") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
 type fakeSession struct {
 	startEvent         *ferret.DebugEvent
 	continueEvent      *ferret.DebugEvent
+	continueEvents     []*ferret.DebugEvent
+	stepEvents         []*ferret.DebugEvent
+	nextEvents         []*ferret.DebugEvent
+	outEvents          []*ferret.DebugEvent
+	stepLocals         [][]ferret.DebugVariable
 	locals             []ferret.DebugVariable
 	frames             []ferret.DebugFrame
+	frameStacks        [][]ferret.DebugFrame
 	breakpoints        []ferret.DebugBreakpoint
 	evaluation         ferret.DebugValue
+	evaluations        []ferret.DebugValue
 	continueErr        error
 	evaluateErr        error
 	expression         string
 	closeErr           error
 	breakpointLocation ferret.DebugSourceLocation
 	breakpointOptions  ferret.DebugBreakpointOptions
+	rejectFile         string
 	startCalls         int
 	continueCalls      int
 	stepCalls          int
@@ -437,21 +962,45 @@ func (f *fakeSession) Start(context.Context) (*ferret.DebugEvent, error) {
 
 func (f *fakeSession) Continue(context.Context) (*ferret.DebugEvent, error) {
 	f.continueCalls++
+	if len(f.continueEvents) > 0 {
+		event := f.continueEvents[0]
+		f.continueEvents = f.continueEvents[1:]
+		return event, f.continueErr
+	}
 	return f.continueEvent, f.continueErr
 }
 
 func (f *fakeSession) Step(context.Context) (*ferret.DebugEvent, error) {
 	f.stepCalls++
+	if len(f.stepLocals) > 0 {
+		f.locals = f.stepLocals[0]
+		f.stepLocals = f.stepLocals[1:]
+	}
+	if len(f.stepEvents) > 0 {
+		event := f.stepEvents[0]
+		f.stepEvents = f.stepEvents[1:]
+		return event, nil
+	}
 	return f.continueEvent, nil
 }
 
 func (f *fakeSession) Next(context.Context) (*ferret.DebugEvent, error) {
 	f.nextCalls++
+	if len(f.nextEvents) > 0 {
+		event := f.nextEvents[0]
+		f.nextEvents = f.nextEvents[1:]
+		return event, nil
+	}
 	return f.continueEvent, nil
 }
 
 func (f *fakeSession) Out(context.Context) (*ferret.DebugEvent, error) {
 	f.outCalls++
+	if len(f.outEvents) > 0 {
+		event := f.outEvents[0]
+		f.outEvents = f.outEvents[1:]
+		return event, nil
+	}
 	return f.continueEvent, nil
 }
 
@@ -464,6 +1013,9 @@ func (f *fakeSession) SetBreakpointAt(location ferret.DebugSourceLocation, optio
 	f.setBreakpointCalls++
 	f.breakpointLocation = location
 	f.breakpointOptions = options
+	if location.File != "" && location.File == f.rejectFile {
+		return ferret.DebugBreakpoint{}, ferruntime.Errorf(ferruntime.ErrNotFound, "source %s", location.File)
+	}
 	breakpoint := ferret.DebugBreakpoint{
 		ID:              ferret.DebugBreakpointID(len(f.breakpoints) + 1),
 		File:            location.File,
@@ -496,6 +1048,10 @@ func (f *fakeSession) Breakpoints() []ferret.DebugBreakpoint {
 
 func (f *fakeSession) Frames() ([]ferret.DebugFrame, error) {
 	f.framesCalls++
+	if len(f.frameStacks) > 0 {
+		f.frames = f.frameStacks[0]
+		f.frameStacks = f.frameStacks[1:]
+	}
 	return f.frames, nil
 }
 
@@ -507,6 +1063,11 @@ func (f *fakeSession) Locals() ([]ferret.DebugVariable, error) {
 func (f *fakeSession) Evaluate(_ context.Context, expression string) (ferret.DebugValue, error) {
 	f.evaluateCalls++
 	f.expression = expression
+	if len(f.evaluations) > 0 {
+		value := f.evaluations[0]
+		f.evaluations = f.evaluations[1:]
+		return value, f.evaluateErr
+	}
 	return f.evaluation, f.evaluateErr
 }
 
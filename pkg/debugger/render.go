@@ -1,6 +1,7 @@
 package debugger
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -12,11 +13,18 @@ import (
 	"github.com/MontFerret/ferret/v2/pkg/source"
 )
 
+// listingContext is the number of lines list shows on each side of a line.
+const listingContext = 5
+
 const helpText = `Commands:
   break, b <location>           Set at next executable location in file
   break --exact <location>      Set only at the exact executable location
   break --next <location>       Set at next executable location in file
   break --in-function <location> Set at next executable location in function
+  break <location> if <expr>    Pause only when the expression is truthy
+  break <location> hits=<n>     Pause from the n-th hit onward
+  logpoint, lp <location> "<message>"
+                                Log a message without pausing; {{ expr }} is interpolated
   breakpoints, bp, bl           List breakpoints
   delete, d <id>                Delete breakpoint
   continue, c                   Resume execution
@@ -25,28 +33,92 @@ const helpText = `Commands:
   out                           Step out of current frame
   pause                         Request pause after the next resume
   where, w, bt                  Show stack trace
-  locals, l                     Show local variables
-  print, p, eval, e <expr>      Evaluate a safe expression (no calls, queries, or mutation)
+  up [<n>]                      Select the calling frame
+  down [<n>]                    Select the called frame
+  frame, f <index>              Select a frame by its index in the stack trace
+  list, ls [<line>]             Show source around the selected frame or a line
+  locals, l                     Show local variables of the selected frame
+  print, p, eval, e <expr>      Evaluate a safe expression in the selected frame (no calls, queries, or mutation)
+  watch [<expr>]                Re-evaluate an expression on every pause, or list watches
+  unwatch <id>                  Remove a watch
+  break-on-change, boc <var>    Pause a continue when the variable's value changes
+  back [<n>]                    View the state n pauses earlier; step, next, out, and continue move forward again
+  reverse-continue, rc          Go back to the latest earlier pause at a breakpoint
+  history                       List the recorded pauses
   quit, q                       Stop debugging and exit
 
 Locations: 12, 12:4, file.fql:12, file.fql:12:4`
 
 type Renderer struct {
-	out    io.Writer
-	source *source.Source
+	out      io.Writer
+	source   *source.Source
+	format   Format
+	failures int
 }
 
 func NewRenderer(out io.Writer, src *source.Source) *Renderer {
-	return &Renderer{out: out, source: src}
+	return &Renderer{out: out, source: src, format: FormatText}
+}
+
+// NewJSONRenderer creates a renderer that writes one JSON object per line
+// instead of prompt text.
+func NewJSONRenderer(out io.Writer, src *source.Source) *Renderer {
+	return &Renderer{out: out, source: src, format: FormatJSON}
+}
+
+// Failures reports how many errors the renderer has written.
+func (r *Renderer) Failures() int {
+	return r.failures
 }
 
 func (r *Renderer) Help() {
+	if r.format == FormatJSON {
+		r.emit(jsonMessage{Type: "help", Message: helpText})
+		return
+	}
+
 	fmt.Fprintln(r.out, helpText)
 }
 
+// Command echoes a command read from a script.
+func (r *Renderer) Command(line string) {
+	if r.format == FormatJSON {
+		r.emit(jsonCommand{Type: "command", Command: line})
+		return
+	}
+
+	fmt.Fprintf(r.out, "(fdb) %s\n", line)
+}
+
+func (r *Renderer) Message(message string) {
+	if r.format == FormatJSON {
+		r.emit(jsonMessage{Type: "message", Message: message})
+		return
+	}
+
+	fmt.Fprintln(r.out, message)
+}
+
+// Failure reports a command that could not run, such as a parse error.
+func (r *Renderer) Failure(message string) {
+	r.failures++
+
+	if r.format == FormatJSON {
+		r.emit(jsonMessage{Type: "error", Message: message})
+		return
+	}
+
+	fmt.Fprintln(r.out, message)
+}
+
 func (r *Renderer) Event(event *ferret.DebugEvent) {
 	if event == nil {
-		fmt.Fprintln(r.out, "Debugger returned no event.")
+		r.Message("Debugger returned no event.")
+		return
+	}
+
+	if r.format == FormatJSON {
+		r.emit(newJSONEvent(event))
 		return
 	}
 
@@ -88,7 +160,22 @@ func (r *Renderer) Event(event *ferret.DebugEvent) {
 	}
 }
 
-func (r *Renderer) BreakpointSet(breakpoint ferret.DebugBreakpoint) {
+func (r *Renderer) BreakpointSet(breakpoint ferret.DebugBreakpoint, rule *BreakpointRule) {
+	if r.format == FormatJSON {
+		record := newJSONBreakpoint(breakpoint, rule)
+		record.Type = "breakpoint"
+		r.emit(record)
+		return
+	}
+
+	r.breakpointSet(breakpoint)
+
+	if rule != nil && !rule.IsZero() {
+		r.breakpointRule(breakpoint.ID, rule)
+	}
+}
+
+func (r *Renderer) breakpointSet(breakpoint ferret.DebugBreakpoint) {
 	requested := formatSourceLocation(breakpoint.File, breakpoint.RequestedLine, breakpoint.RequestedColumn)
 	mode := formatBindingMode(breakpoint.BindingMode)
 
@@ -106,14 +193,48 @@ func (r *Renderer) BreakpointSet(breakpoint ferret.DebugBreakpoint) {
 	fmt.Fprintf(r.out, "Breakpoint %d set at %s (requested %s, %s).\n", breakpoint.ID, bound, requested, mode)
 }
 
-func (r *Renderer) Breakpoints(breakpoints []ferret.DebugBreakpoint) {
+func (r *Renderer) breakpointRule(id ferret.DebugBreakpointID, rule *BreakpointRule) {
+	parts := make([]string, 0, 2)
+
+	if description := describeBreakpointRule(rule); description != "-" {
+		parts = append(parts, description)
+	}
+
+	if rule.HitCount > 0 {
+		parts = append(parts, fmt.Sprintf("from hit %d", rule.HitCount))
+	}
+
+	fmt.Fprintf(r.out, "Breakpoint %d: %s.\n", id, strings.Join(parts, ", "))
+}
+
+func (r *Renderer) Logpoint(id ferret.DebugBreakpointID, message string) {
+	if r.format == FormatJSON {
+		r.emit(jsonLog{Type: "log", Breakpoint: int(id), Message: message})
+		return
+	}
+
+	fmt.Fprintf(r.out, "Logpoint %d: %s\n", id, message)
+}
+
+func (r *Renderer) Breakpoints(breakpoints []ferret.DebugBreakpoint, rules BreakpointRules) {
+	if r.format == FormatJSON {
+		records := make([]jsonBreakpoint, 0, len(breakpoints))
+
+		for _, breakpoint := range breakpoints {
+			records = append(records, newJSONBreakpoint(breakpoint, rules[breakpoint.ID]))
+		}
+
+		r.emit(jsonBreakpoints{Type: "breakpoints", Breakpoints: records})
+		return
+	}
+
 	if len(breakpoints) == 0 {
 		fmt.Fprintln(r.out, "No breakpoints.")
 		return
 	}
 
 	table := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
-	fmt.Fprintln(table, "ID\tRequested\tBound\tMode\tState")
+	fmt.Fprintln(table, "ID\tRequested\tBound\tMode\tState\tHits\tCondition")
 
 	for _, breakpoint := range breakpoints {
 		requested := formatSourceLocation(breakpoint.File, breakpoint.RequestedLine, breakpoint.RequestedColumn)
@@ -125,24 +246,118 @@ func (r *Renderer) Breakpoints(breakpoints []ferret.DebugBreakpoint) {
 			state = "bound"
 		}
 
-		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\n", breakpoint.ID, requested, bound, formatBindingMode(breakpoint.BindingMode), state)
+		rule := rules[breakpoint.ID]
+
+		fmt.Fprintf(
+			table,
+			"%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			breakpoint.ID,
+			requested,
+			bound,
+			formatBindingMode(breakpoint.BindingMode),
+			state,
+			formatBreakpointHits(rule),
+			describeBreakpointRule(rule),
+		)
 	}
 
 	_ = table.Flush()
 }
 
-func (r *Renderer) Frames(frames []ferret.DebugFrame) {
+// Frames prints the stack trace and marks the selected frame with "*".
+func (r *Renderer) Frames(frames []ferret.DebugFrame, selected int) {
+	if r.format == FormatJSON {
+		records := make([]jsonFrame, 0, len(frames))
+
+		for _, frame := range frames {
+			records = append(records, newJSONFrame(frame))
+		}
+
+		r.emit(jsonFrames{Type: "frames", Frames: records, Selected: selected})
+		return
+	}
+
 	if len(frames) == 0 {
 		fmt.Fprintln(r.out, "No stack frames available.")
 		return
 	}
 
 	for i, frame := range frames {
-		fmt.Fprintf(r.out, "#%d %s at %s\n", i, frame.Name, formatLocation(frame.Location))
+		marker := " "
+		if i == selected {
+			marker = "*"
+		}
+
+		fmt.Fprintf(r.out, "%s #%d %s at %s\n", marker, i, frame.Name, formatLocation(frame.Location))
+	}
+}
+
+func (r *Renderer) FrameSelected(index int, frame ferret.DebugFrame) {
+	if r.format == FormatJSON {
+		r.emit(jsonSelectedFrame{Type: "frame", Index: index, Frame: newJSONFrame(frame)})
+		return
+	}
+
+	fmt.Fprintf(r.out, "#%d %s at %s\n", index, frame.Name, formatLocation(frame.Location))
+	r.snippet(frame.Location)
+}
+
+// Listing prints the source lines around line and marks current with "=>".
+// Pass 0 as current when no line should be marked.
+func (r *Renderer) Listing(line, current int) {
+	if r.source == nil {
+		r.Failure("No source available.")
+		return
+	}
+
+	lines := strings.Split(r.source.Content(), "\n")
+	if line > len(lines) {
+		r.Failure(fmt.Sprintf("Line %d is out of range; %s has %d lines.", line, r.source.Name(), len(lines)))
+		return
+	}
+
+	first := max(line-listingContext, 1)
+	last := min(line+listingContext, len(lines))
+
+	if r.format == FormatJSON {
+		record := jsonListing{Type: "listing", File: r.source.Name()}
+
+		for number := first; number <= last; number++ {
+			record.Lines = append(record.Lines, jsonSourceLine{
+				Line:    number,
+				Text:    strings.TrimRight(lines[number-1], "\r"),
+				Current: number == current,
+			})
+		}
+
+		r.emit(record)
+		return
+	}
+
+	width := len(strconv.Itoa(last))
+
+	for number := first; number <= last; number++ {
+		marker := "  "
+		if number == current {
+			marker = "=>"
+		}
+
+		fmt.Fprintf(r.out, "%s %*d | %s\n", marker, width, number, strings.TrimRight(lines[number-1], "\r"))
 	}
 }
 
 func (r *Renderer) Locals(variables []ferret.DebugVariable) {
+	if r.format == FormatJSON {
+		records := make([]jsonVariable, 0, len(variables))
+
+		for _, variable := range variables {
+			records = append(records, jsonVariable{Name: variable.Name, Value: variable.Value.Display, Param: variable.Param})
+		}
+
+		r.emit(jsonLocals{Type: "locals", Locals: records})
+		return
+	}
+
 	locals := make([]ferret.DebugVariable, 0, len(variables))
 	params := make([]ferret.DebugVariable, 0, len(variables))
 
@@ -170,7 +385,140 @@ func (r *Renderer) Locals(variables []ferret.DebugVariable) {
 	}
 }
 
-func (r *Renderer) Evaluation(value ferret.DebugValue) {
+// Watches prints the watch list. Values that changed since the previous pause
+// are marked with "*" and show the value they replaced.
+func (r *Renderer) Watches(watches []*Watch) {
+	if r.format == FormatJSON {
+		records := make([]jsonWatch, 0, len(watches))
+
+		for _, watch := range watches {
+			record := jsonWatch{
+				ID:            watch.ID,
+				Expression:    watch.Expression,
+				Value:         watch.Value,
+				Changed:       watch.Changed,
+				BreakOnChange: watch.BreakOnChange,
+			}
+
+			if watch.Changed {
+				record.Previous = watch.Previous
+			}
+
+			if watch.Err != nil {
+				record.Error = watch.Err.Error()
+			}
+
+			records = append(records, record)
+		}
+
+		r.emit(jsonWatches{Type: "watches", Watches: records})
+		return
+	}
+
+	if len(watches) == 0 {
+		fmt.Fprintln(r.out, "No watches.")
+		return
+	}
+
+	fmt.Fprintln(r.out, "Watches:")
+	table := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+
+	for _, watch := range watches {
+		marker := " "
+		details := ""
+
+		if watch.Changed {
+			marker = "*"
+			details = fmt.Sprintf("(was %s)", watch.Previous)
+		}
+
+		if watch.BreakOnChange {
+			details = strings.TrimSpace(details + " [break-on-change]")
+		}
+
+		fmt.Fprintf(table, "%s %d\t%s = %s\t%s\n", marker, watch.ID, watch.Expression, watch.display(), details)
+	}
+
+	_ = table.Flush()
+}
+
+// Snapshot announces a recorded pause the prompt moved back to, distance
+// pauses before the present.
+func (r *Renderer) Snapshot(snapshot *Snapshot, distance int) {
+	if r.format == FormatJSON {
+		r.emit(jsonSnapshot{Type: "snapshot", ID: snapshot.ID, Back: distance, Event: newJSONEvent(&snapshot.Event)})
+		return
+	}
+
+	fmt.Fprintf(r.out, "Viewing pause #%d, %d before the present.\n", snapshot.ID, distance)
+	r.Event(&snapshot.Event)
+}
+
+// History lists the recorded pauses, oldest first, and marks the viewed one
+// with "*". The present is marked with "*" while no snapshot is viewed.
+func (r *Renderer) History(snapshots []*Snapshot, current, present *Snapshot) {
+	if current == nil {
+		current = present
+	}
+
+	if r.format == FormatJSON {
+		records := make([]jsonHistoryEntry, 0, len(snapshots))
+
+		for _, snapshot := range snapshots {
+			records = append(records, jsonHistoryEntry{
+				ID:       snapshot.ID,
+				Reason:   snapshot.Event.Reason,
+				Location: newJSONLocation(snapshot.Event.Location),
+				Current:  snapshot == current,
+				Present:  snapshot == present,
+			})
+		}
+
+		r.emit(jsonHistory{Type: "history", Snapshots: records})
+		return
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Fprintln(r.out, "No pauses recorded.")
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		marker := " "
+		if snapshot == current {
+			marker = "*"
+		}
+
+		suffix := ""
+		if snapshot == present {
+			suffix = " (present)"
+		}
+
+		fmt.Fprintf(r.out, "%s #%d %s at %s%s\n", marker, snapshot.ID, snapshot.Event.Reason, formatLocation(snapshot.Event.Location), suffix)
+	}
+}
+
+func (r *Renderer) WatchChanged(change WatchChange) {
+	if r.format == FormatJSON {
+		r.emit(jsonWatchChange{
+			Type:       "change",
+			ID:         change.Watch.ID,
+			Expression: change.Watch.Expression,
+			Previous:   change.Previous,
+			Value:      change.Value,
+		})
+		return
+	}
+
+	fmt.Fprintf(r.out, "Watch %d: %s changed from %s to %s\n", change.Watch.ID, change.Watch.Expression, change.Previous, change.Value)
+}
+
+func (r *Renderer) Evaluation(expression string, value ferret.DebugValue) {
+	if r.format == FormatJSON {
+		r.emit(jsonEvaluation{Type: "evaluation", Expression: expression, Value: value.Display})
+		return
+	}
+
 	fmt.Fprintln(r.out, value.Display)
 }
 
@@ -179,7 +527,16 @@ func (r *Renderer) Error(prefix string, err error) {
 		return
 	}
 
-	fmt.Fprintf(r.out, "%s: %s\n", prefix, err)
+	r.Failure(fmt.Sprintf("%s: %s", prefix, err))
+}
+
+func (r *Renderer) emit(record any) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		data, _ = json.Marshal(jsonMessage{Type: "error", Message: err.Error()})
+	}
+
+	fmt.Fprintf(r.out, "%s\n", data)
 }
 
 func (r *Renderer) error(err error) {
@@ -46,8 +46,10 @@ func TestRendererCollectionsAndErrors(t *testing.T) {
 	renderer.Breakpoints([]ferret.DebugBreakpoint{
 		{ID: 1, File: "demo.fql", RequestedLine: 4, RequestedColumn: 3, Line: 7, Column: 5, BindingMode: ferret.DebugBreakpointBindNextExecutableInFile, Bound: true},
 		{ID: 2, File: "other.fql", RequestedLine: 9, BindingMode: ferret.DebugBreakpointBindExact},
+	}, BreakpointRules{
+		1: {Condition: "user.active", HitCount: 3, Hits: 1},
 	})
-	renderer.Frames([]ferret.DebugFrame{{Name: "normalize", Location: ferret.DebugLocation{File: "demo.fql", Line: 7, Column: 3}}})
+	renderer.Frames([]ferret.DebugFrame{{Name: "normalize", Location: ferret.DebugLocation{File: "demo.fql", Line: 7, Column: 3}}}, 0)
 	renderer.Locals([]ferret.DebugVariable{
 		{Name: "user", Value: ferret.DebugValue{Display: `{"name": "Ada"}`}},
 		{Name: "@limit", Param: true, Value: ferret.DebugValue{Display: "10"}},
@@ -57,7 +59,8 @@ func TestRendererCollectionsAndErrors(t *testing.T) {
 
 	got := out.String()
 	for _, expected := range []string{
-		"Requested", "Bound", "Mode", "State",
+		"Requested", "Bound", "Mode", "State", "Hits", "Condition",
+		"1/3", "if user.active",
 		"demo.fql:4:3", "demo.fql:7:5", "other.fql:9", "next-file", "exact", "bound", "unbound",
 		"#0 normalize at demo.fql:7:3",
 		"Locals:", `user = {"name": "Ada"}`, "Params:", "@limit = 10",
@@ -96,13 +99,13 @@ func TestRendererBreakpointHitsAndSetMessages(t *testing.T) {
 		Column:          4,
 		BindingMode:     ferret.DebugBreakpointBindNextExecutableInFunction,
 		Bound:           true,
-	})
+	}, nil)
 	renderer.BreakpointSet(ferret.DebugBreakpoint{
 		ID:            9,
 		File:          "demo.fql",
 		RequestedLine: 20,
 		BindingMode:   ferret.DebugBreakpointBindExact,
-	})
+	}, nil)
 
 	got := out.String()
 	for _, expected := range []string{
@@ -122,8 +125,8 @@ func TestRendererEmptyCollections(t *testing.T) {
 	var out bytes.Buffer
 	renderer := NewRenderer(&out, nil)
 
-	renderer.Breakpoints(nil)
-	renderer.Frames(nil)
+	renderer.Breakpoints(nil, nil)
+	renderer.Frames(nil, 0)
 	renderer.Locals(nil)
 
 	got := out.String()
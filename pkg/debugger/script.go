@@ -0,0 +1,41 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrScriptFailed indicates a scripted session ran to the end but at least
+// one command reported an error.
+var ErrScriptFailed = errors.New("debugger script failed")
+
+// ScriptReader feeds debugger commands from a script. Blank lines and lines
+// starting with # are skipped, so an empty line never repeats a resume
+// command the way it does at the interactive prompt.
+type ScriptReader struct {
+	scanner *bufio.Scanner
+}
+
+func NewScriptReader(r io.Reader) *ScriptReader {
+	return &ScriptReader{scanner: bufio.NewScanner(r)}
+}
+
+func (s *ScriptReader) Readline() (string, error) {
+	for s.scanner.Scan() {
+		line := strings.TrimSpace(s.scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		return line, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
+}
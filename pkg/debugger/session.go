@@ -23,6 +23,14 @@ type (
 		Close() error
 	}
 
+	// FrameInspector is implemented by sessions that can inspect frames other
+	// than the innermost one. Frame 0 is the innermost frame, as returned first
+	// by Frames.
+	FrameInspector interface {
+		FrameLocals(frame int) ([]ferret.DebugVariable, error)
+		EvaluateInFrame(ctx context.Context, frame int, expression string) (ferret.DebugValue, error)
+	}
+
 	LineReader interface {
 		Readline() (string, error)
 	}
@@ -0,0 +1,195 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MontFerret/ferret/v2"
+)
+
+// Watch is an expression re-evaluated every time the program pauses. A watch
+// created with break-on-change also pauses a continue as soon as its value
+// differs from the previous step.
+type Watch struct {
+	ID         int
+	Expression string
+	// BreakOnChange turns the watch into a data breakpoint.
+	BreakOnChange bool
+	// Value is the display value from the latest evaluation.
+	Value string
+	// Previous is the display value before the latest change.
+	Previous string
+	// Err holds the latest evaluation error, for example when the variable is
+	// out of scope in the current frame.
+	Err error
+	// Changed reports whether the value differs from the previous pause.
+	Changed bool
+	known   bool
+
+	// stepValue is the baseline a data breakpoint compares against on every
+	// step, independent of the value shown at the previous pause.
+	stepValue string
+	stepKnown bool
+}
+
+// WatchChange describes a data breakpoint whose value changed between steps.
+type WatchChange struct {
+	Watch    *Watch
+	Previous string
+	Value    string
+}
+
+// WatchList holds the watches of a debugger prompt in creation order.
+type WatchList struct {
+	items  []*Watch
+	nextID int
+}
+
+func (l *WatchList) Add(expression string, breakOnChange bool) *Watch {
+	l.nextID++
+	watch := &Watch{ID: l.nextID, Expression: expression, BreakOnChange: breakOnChange}
+	l.items = append(l.items, watch)
+
+	return watch
+}
+
+func (l *WatchList) Remove(id int) bool {
+	for i, watch := range l.items {
+		if watch.ID == id {
+			l.items = append(l.items[:i], l.items[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *WatchList) Items() []*Watch {
+	return l.items
+}
+
+// HasDataBreakpoints reports whether any watch pauses on change.
+func (l *WatchList) HasDataBreakpoints() bool {
+	for _, watch := range l.items {
+		if watch.BreakOnChange {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Refresh re-evaluates every watch in the paused frame.
+func (l *WatchList) Refresh(ctx context.Context, session Session) {
+	for _, watch := range l.items {
+		watch.evaluate(ctx, session)
+	}
+}
+
+// changedDataBreakpoints re-evaluates only data breakpoints and returns the
+// ones whose value changed since the previous step. Failed evaluations keep
+// the last known value, so leaving and re-entering a scope is not a change.
+func (l *WatchList) changedDataBreakpoints(ctx context.Context, session Session) []WatchChange {
+	var changes []WatchChange
+
+	for _, watch := range l.items {
+		if !watch.BreakOnChange {
+			continue
+		}
+
+		value, err := session.Evaluate(ctx, watch.Expression)
+		if err != nil {
+			continue
+		}
+
+		if watch.stepKnown && value.Display != watch.stepValue {
+			changes = append(changes, WatchChange{Watch: watch, Previous: watch.stepValue, Value: value.Display})
+		}
+
+		watch.stepValue = value.Display
+		watch.stepKnown = true
+	}
+
+	return changes
+}
+
+// evaluate updates the value shown at a pause and marks it changed when it
+// differs from the previous pause.
+func (w *Watch) evaluate(ctx context.Context, session Session) {
+	value, err := session.Evaluate(ctx, w.Expression)
+
+	w.Err = err
+	w.Changed = false
+
+	if err != nil {
+		return
+	}
+
+	if w.known && value.Display != w.Value {
+		w.Previous = w.Value
+		w.Changed = true
+	}
+
+	w.Value = value.Display
+	w.known = true
+	w.stepValue = value.Display
+	w.stepKnown = true
+}
+
+func (w *Watch) display() string {
+	if w.Err != nil {
+		return fmt.Sprintf("<error: %s>", w.Err)
+	}
+
+	if !w.known {
+		return "<unavailable>"
+	}
+
+	return w.Value
+}
+
+// continueUntilChange steps through the program while data breakpoints are
+// active and pauses at the first step that changes one of them. Regular
+// breakpoints and terminal events still stop the loop.
+func continueUntilChange(ctx context.Context, session Session, rules BreakpointRules, watches *WatchList, renderer *Renderer) (*ferret.DebugEvent, error) {
+	watches.changedDataBreakpoints(ctx, session)
+
+	for {
+		event, err := session.Step(ctx)
+
+		if err != nil || event == nil {
+			return event, err
+		}
+
+		switch event.Reason {
+		case ferret.DebugReasonBreakpoint:
+			if shouldPause(ctx, session, rules, renderer, event.HitBreakpointIDs) {
+				return event, nil
+			}
+		case ferret.DebugReasonStep, ferret.DebugReasonEntry:
+		default:
+			return event, nil
+		}
+
+		if changes := watches.changedDataBreakpoints(ctx, session); len(changes) > 0 {
+			for _, change := range changes {
+				renderer.WatchChanged(change)
+			}
+
+			return event, nil
+		}
+	}
+}
+
+func isPausedEvent(event *ferret.DebugEvent) bool {
+	if event == nil {
+		return false
+	}
+
+	switch event.Reason {
+	case ferret.DebugReasonCompleted, ferret.DebugReasonTerminated:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,247 @@
+// Package har records HTTP exchanges in the HTTP Archive (HAR 1.2) format and
+// serves them back, so scripts can run against captured responses without
+// the network.
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MontFerret/cli/v2/pkg/output"
+)
+
+// Ext is the extension of HAR files.
+const Ext = ".har"
+
+// DefaultFile is the file a recording is written to when a directory is given.
+const DefaultFile = "recording" + Ext
+
+// Doer sends HTTP requests. It is implemented by *http.Client and by the
+// runtime's HTTP client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type File struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the duration of the exchange in milliseconds.
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	Cache    struct{} `json:"cache"`
+	Timings  Timings  `json:"timings"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	Cookies     []NameValue `json:"cookies"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	Cookies     []NameValue `json:"cookies"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding is "base64" when Text holds binary data.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding is "base64" when Text holds binary data.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Body returns the decoded content of the response.
+func (c Content) Body() ([]byte, error) {
+	return decodeText(c.Text, c.Encoding)
+}
+
+// Body returns the decoded request body, or nil when there is none.
+func (r Request) Body() ([]byte, error) {
+	if r.PostData == nil {
+		return nil, nil
+	}
+
+	return decodeText(r.PostData.Text, r.PostData.Encoding)
+}
+
+// Load reads the entries of a HAR file, or of every HAR file in a directory
+// in name order.
+func Load(path string) ([]Entry, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("read HTTP fixtures: %w", err)
+	}
+
+	files := []string{path}
+
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*"+Ext))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no %s files found in %s", Ext, path)
+		}
+
+		sort.Strings(files)
+	}
+
+	var entries []Entry
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("read HTTP fixtures: %w", err)
+		}
+
+		var doc File
+
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("HAR file %s: %w", file, err)
+		}
+
+		entries = append(entries, doc.Log.Entries...)
+	}
+
+	return entries, nil
+}
+
+// Save writes entries as a HAR file. A path without the .har extension is
+// taken as a directory and the file is written to DefaultFile inside it.
+func Save(path, version string, entries []Entry) error {
+	if !strings.EqualFold(filepath.Ext(path), Ext) {
+		path = filepath.Join(path, DefaultFile)
+	}
+
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	doc := File{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "ferret", Version: version},
+		Entries: entries,
+	}}
+
+	return output.WriteFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(doc)
+	})
+}
+
+// encodeText returns data as HAR text, base64 encoded when it is not valid
+// UTF-8.
+func encodeText(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(data), "base64"
+}
+
+func decodeText(text, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(text), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(text)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+func nameValues(header http.Header) []NameValue {
+	res := make([]NameValue, 0, len(header))
+	names := make([]string, 0, len(header))
+
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			res = append(res, NameValue{Name: name, Value: value})
+		}
+	}
+
+	return res
+}
+
+// readBody reads and replaces body so that it can be read again.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	closeErr := (*body).Close()
+	*body = io.NopCloser(bytes.NewReader(data))
+
+	return data, errors.Join(err, closeErr)
+}
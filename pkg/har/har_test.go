@@ -0,0 +1,243 @@
+package har
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+
+		switch r.URL.Path {
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0xff, 0x00, 0xfe})
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(r.Method + " " + r.URL.String() + " " + string(body) + " #" + string('0'+rune(n))))
+		}
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder(server.Client(), false)
+	recorded := []string{
+		send(t, recorder, http.MethodGet, server.URL+"/page?id=1", ""),
+		send(t, recorder, http.MethodGet, server.URL+"/page?id=1", ""),
+		send(t, recorder, http.MethodPost, server.URL+"/search", "q=ferret"),
+		send(t, recorder, http.MethodGet, server.URL+"/binary", ""),
+	}
+
+	dir := t.TempDir()
+	if err := Save(dir, "test", recorder.Entries()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 4 || entries[2].Request.PostData == nil || entries[3].Response.Content.Encoding != "base64" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	replayer, err := NewReplayer(entries, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	before := hits.Load()
+	replayed := []string{
+		send(t, replayer, http.MethodGet, server.URL+"/page?id=1", ""),
+		send(t, replayer, http.MethodGet, server.URL+"/page?id=1", ""),
+		send(t, replayer, http.MethodPost, server.URL+"/search", "q=other"),
+		send(t, replayer, http.MethodGet, server.URL+"/binary", ""),
+	}
+
+	if hits.Load() != before {
+		t.Fatal("expected replay not to reach the server")
+	}
+
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Fatalf("response %d: expected %q, got %q", i, recorded[i], replayed[i])
+		}
+	}
+
+	// Responses to a repeated request are served in order, then the last again.
+	if got := send(t, replayer, http.MethodGet, server.URL+"/page?id=1", ""); got != recorded[1] {
+		t.Fatalf("expected the last response again, got %q", got)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/page?id=2", nil)
+	if _, err := replayer.Do(req); !errors.Is(err, ErrUnmatched) {
+		t.Fatalf("expected unmatched error, got %v", err)
+	}
+}
+
+func TestRecorder_RedactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", Path: "/", HttpOnly: true})
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	record := func(keepSecrets bool) Entry {
+		recorder := NewRecorder(server.Client(), keepSecrets)
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		req.AddCookie(&http.Cookie{Name: "id", Value: "42"})
+		req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+		resp, err := recorder.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+
+		return recorder.Entries()[0]
+	}
+
+	redacted := record(false)
+
+	if got := header(redacted.Request.Headers, "Authorization"); got != "Bearer REDACTED" {
+		t.Fatalf("unexpected Authorization: %q", got)
+	}
+	if got := header(redacted.Request.Headers, "Cookie"); got != "id=REDACTED; theme=REDACTED" {
+		t.Fatalf("unexpected Cookie: %q", got)
+	}
+	if got := header(redacted.Response.Headers, "Set-Cookie"); got != "session=REDACTED; Path=/; HttpOnly" {
+		t.Fatalf("unexpected Set-Cookie: %q", got)
+	}
+	if redacted.Request.Cookies[0] != (NameValue{Name: "id", Value: Redacted}) || redacted.Response.Cookies[0] != (NameValue{Name: "session", Value: Redacted}) {
+		t.Fatalf("unexpected cookies: %+v, %+v", redacted.Request.Cookies, redacted.Response.Cookies)
+	}
+
+	kept := record(true)
+
+	if header(kept.Request.Headers, "Authorization") != "Bearer token" || kept.Response.Cookies[0].Value != "s3cr3t" {
+		t.Fatalf("expected secrets to be kept: %+v", kept)
+	}
+}
+
+func header(headers []NameValue, name string) string {
+	for _, header := range headers {
+		if header.Name == name {
+			return header.Value
+		}
+	}
+
+	return ""
+}
+
+func TestReplayer_Match(t *testing.T) {
+	entries := []Entry{
+		entry(http.MethodGet, "https://example.com/items?page=1", "", "items"),
+		entry(http.MethodPost, "https://example.com/search", "q=a", "a"),
+		entry(http.MethodPost, "https://example.com/search", "q=b", "b"),
+	}
+
+	match, err := ParseMatch([]string{"method", "path", "body"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replayer, err := NewReplayer(entries, match, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := send(t, replayer, http.MethodGet, "https://example.com/items?page=2", ""); got != "items" {
+		t.Fatalf("expected the query to be ignored, got %q", got)
+	}
+
+	if got := send(t, replayer, http.MethodPost, "https://example.com/search", "q=b"); got != "b" {
+		t.Fatalf("expected the body to select the response, got %q", got)
+	}
+
+	if _, err := ParseMatch([]string{"headers"}); err == nil || !strings.Contains(err.Error(), "unknown request match") {
+		t.Fatalf("expected unknown match error, got %v", err)
+	}
+}
+
+func TestReplayer_SendsUnmatchedRequestsToNext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("live"))
+	}))
+	defer server.Close()
+
+	replayer, err := NewReplayer(nil, nil, server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := send(t, replayer, http.MethodGet, server.URL, ""); got != "live" {
+		t.Fatalf("expected live response, got %q", got)
+	}
+}
+
+func TestSave_WritesFileOrDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "capture.har")
+
+	if err := Save(file, "test", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entries, err := Load(file); err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty recording, got %v, %v", entries, err)
+	}
+
+	if _, err := Load(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no .har files") {
+		t.Fatalf("expected missing files error, got %v", err)
+	}
+}
+
+func send(t *testing.T, client Doer, method, url, body string) string {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func entry(method, url, body, response string) Entry {
+	e := Entry{
+		Request:  Request{Method: method, URL: url},
+		Response: Response{Status: http.StatusOK, Content: Content{Text: response}},
+	}
+
+	if body != "" {
+		e.Request.PostData = &PostData{Text: body}
+	}
+
+	return e
+}
@@ -0,0 +1,199 @@
+package har
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces secrets in recordings.
+const Redacted = "REDACTED"
+
+// Recorder sends requests through another client and keeps every exchange.
+// Failed requests, which have no response, are not kept. Unless it keeps
+// secrets, the credentials and cookies of every exchange are redacted, since
+// recordings are meant to be committed and shared.
+type Recorder struct {
+	next        Doer
+	keepSecrets bool
+	mu          sync.Mutex
+	entries     []Entry
+}
+
+// NewRecorder records the exchanges of next. keepSecrets stores the
+// Authorization, Proxy-Authorization, Cookie, and Set-Cookie headers and the
+// cookie values as they were sent instead of redacting them.
+func NewRecorder(next Doer, keepSecrets bool) *Recorder {
+	return &Recorder{next: next, keepSecrets: keepSecrets}
+}
+
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("record request: %w", err)
+	}
+
+	started := time.Now()
+	resp, err := r.next.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("record response: %w", err)
+	}
+
+	elapsed := float64(time.Since(started).Microseconds()) / 1000
+	entry := Entry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Request:         newRequest(req, reqBody),
+		Response:        newResponse(resp, respBody),
+		Timings:         Timings{Wait: elapsed},
+	}
+
+	if !r.keepSecrets {
+		redact(&entry)
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Entries returns the exchanges recorded so far, in the order they finished.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Entry(nil), r.entries...)
+}
+
+func newRequest(req *http.Request, body []byte) Request {
+	res := Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: httpVersion(req.Proto),
+		Headers:     nameValues(req.Header),
+		QueryString: nameValues(http.Header(req.URL.Query())),
+		Cookies:     []NameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	for _, cookie := range req.Cookies() {
+		res.Cookies = append(res.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	if body != nil {
+		text, encoding := encodeText(body)
+		res.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Text: text, Encoding: encoding}
+	}
+
+	return res
+}
+
+func newResponse(resp *http.Response, body []byte) Response {
+	text, encoding := encodeText(body)
+	res := Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: httpVersion(resp.Proto),
+		Headers:     nameValues(resp.Header),
+		Cookies:     []NameValue{},
+		Content: Content{
+			Size:     len(body),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	for _, cookie := range resp.Cookies() {
+		res.Cookies = append(res.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	return res
+}
+
+// redact replaces credentials and cookie values in entry, keeping the
+// authorization scheme, cookie names, and cookie attributes so a recording
+// still shows what was sent.
+func redact(entry *Entry) {
+	for i, header := range entry.Request.Headers {
+		switch http.CanonicalHeaderKey(header.Name) {
+		case "Authorization", "Proxy-Authorization":
+			entry.Request.Headers[i].Value = redactCredentials(header.Value)
+		case "Cookie":
+			entry.Request.Headers[i].Value = redactCookies(header.Value, "; ")
+		}
+	}
+
+	for i, header := range entry.Response.Headers {
+		if http.CanonicalHeaderKey(header.Name) == "Set-Cookie" {
+			entry.Response.Headers[i].Value = redactCookies(header.Value, "")
+		}
+	}
+
+	for i := range entry.Request.Cookies {
+		entry.Request.Cookies[i].Value = Redacted
+	}
+
+	for i := range entry.Response.Cookies {
+		entry.Response.Cookies[i].Value = Redacted
+	}
+}
+
+// redactCredentials keeps the scheme of an Authorization value, such as
+// "Bearer", and replaces the rest.
+func redactCredentials(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " " + Redacted
+	}
+
+	return Redacted
+}
+
+// redactCookies replaces the values of the name=value pairs in a Cookie
+// header, separated by "; ", or the leading pair of a Set-Cookie header when
+// separator is empty, where the rest are attributes.
+func redactCookies(value, separator string) string {
+	if separator == "" {
+		pair, attributes, hasAttributes := strings.Cut(value, ";")
+		name, _, _ := strings.Cut(pair, "=")
+
+		if hasAttributes {
+			return name + "=" + Redacted + ";" + attributes
+		}
+
+		return name + "=" + Redacted
+	}
+
+	pairs := strings.Split(value, ";")
+
+	for i, pair := range pairs {
+		name, _, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		pairs[i] = name + "=" + Redacted
+	}
+
+	return strings.Join(pairs, separator)
+}
+
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+
+	return proto
+}
@@ -0,0 +1,191 @@
+package har
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Match is a part of a request that must equal the recorded one for the
+// recorded response to be served.
+type Match string
+
+const (
+	MatchMethod Match = "method"
+	// MatchURL compares the full URL, including the query.
+	MatchURL Match = "url"
+	// MatchPath compares the URL without its query.
+	MatchPath Match = "path"
+	MatchBody Match = "body"
+)
+
+// DefaultMatch is how requests are matched unless configured otherwise.
+var DefaultMatch = []Match{MatchMethod, MatchURL}
+
+// ErrUnmatched is returned in strict mode for a request without a recorded
+// response.
+var ErrUnmatched = errors.New("no recorded response matches the request")
+
+// ParseMatch parses match names such as "method" or "url".
+func ParseMatch(names []string) ([]Match, error) {
+	res := make([]Match, 0, len(names))
+
+	for _, name := range names {
+		switch m := Match(strings.ToLower(strings.TrimSpace(name))); m {
+		case MatchMethod, MatchURL, MatchPath, MatchBody:
+			res = append(res, m)
+		default:
+			return nil, fmt.Errorf("unknown request match %q; expected method, url, path, or body", name)
+		}
+	}
+
+	return res, nil
+}
+
+// Replayer answers requests with recorded responses. A request matching
+// several recorded exchanges gets their responses in recorded order, then
+// the last one again. Unmatched requests go to the next client, or fail with
+// ErrUnmatched when there is none.
+type Replayer struct {
+	next    Doer
+	match   []Match
+	mu      sync.Mutex
+	entries map[string][]Entry
+	served  map[string]int
+}
+
+// NewReplayer serves entries for requests matched by match, DefaultMatch
+// when empty. next may be nil to fail every unmatched request.
+func NewReplayer(entries []Entry, match []Match, next Doer) (*Replayer, error) {
+	if len(match) == 0 {
+		match = DefaultMatch
+	}
+
+	r := &Replayer{
+		next:    next,
+		match:   match,
+		entries: make(map[string][]Entry),
+		served:  make(map[string]int),
+	}
+
+	for _, entry := range entries {
+		u, err := url.Parse(entry.Request.URL)
+
+		if err != nil {
+			return nil, fmt.Errorf("recorded request %s: %w", entry.Request.URL, err)
+		}
+
+		body, err := entry.Request.Body()
+
+		if err != nil {
+			return nil, fmt.Errorf("recorded request %s: %w", entry.Request.URL, err)
+		}
+
+		key := r.key(entry.Request.Method, u, body)
+		r.entries[key] = append(r.entries[key], entry)
+	}
+
+	return r, nil
+}
+
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("replay request: %w", err)
+	}
+
+	entry, ok := r.take(r.key(req.Method, req.URL, body))
+
+	if !ok {
+		if r.next == nil {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrUnmatched)
+		}
+
+		return r.next.Do(req)
+	}
+
+	return newHTTPResponse(req, entry)
+}
+
+// take returns the entry to serve for key.
+func (r *Replayer) take(key string) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.entries[key]
+
+	if len(entries) == 0 {
+		return Entry{}, false
+	}
+
+	i := min(r.served[key], len(entries)-1)
+	r.served[key]++
+
+	return entries[i], true
+}
+
+func (r *Replayer) key(method string, u *url.URL, body []byte) string {
+	parts := make([]string, 0, len(r.match))
+
+	for _, m := range r.match {
+		switch m {
+		case MatchMethod:
+			parts = append(parts, strings.ToUpper(method))
+		case MatchURL:
+			parts = append(parts, u.String())
+		case MatchPath:
+			stripped := *u
+			stripped.RawQuery = ""
+			stripped.Fragment = ""
+			parts = append(parts, stripped.String())
+		case MatchBody:
+			parts = append(parts, string(body))
+		}
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+func newHTTPResponse(req *http.Request, entry Entry) (*http.Response, error) {
+	body, err := entry.Response.Content.Body()
+
+	if err != nil {
+		return nil, fmt.Errorf("recorded response for %s: %w", entry.Request.URL, err)
+	}
+
+	header := make(http.Header)
+
+	for _, h := range entry.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+
+	// The body is stored decoded, so the recorded length and encoding no
+	// longer describe it.
+	header.Del("Content-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	status := entry.Response.StatusText
+
+	if status == "" {
+		status = http.StatusText(entry.Response.Status)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.Status, status),
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,151 @@
+// Package htmlfixture maps page URLs to local HTML files, so that scripts
+// reading pages with the in-memory HTML driver can run without the network.
+package htmlfixture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// MappingFiles are the names a mapping file is looked up by in a fixtures
+// directory, in order.
+var MappingFiles = []string{"fixtures.yaml", "fixtures.yml", "fixtures.json"}
+
+// ErrNotFound is returned for a URL without a fixture.
+var ErrNotFound = errors.New("no HTML fixture")
+
+// Set is a mapping from page URLs to HTML files.
+type Set struct {
+	// pages maps URLs to absolute file paths.
+	pages map[string]string
+}
+
+// Load reads a mapping file, or the mapping file of a directory. The file is
+// a YAML or JSON object from URLs to HTML files, relative to the file:
+//
+//	https://example.com/: index.html
+//	https://example.com/products?page=2: products/page-2.html
+func Load(path string) (*Set, error) {
+	mapping, err := mappingFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(mapping)
+
+	if err != nil {
+		return nil, fmt.Errorf("read HTML fixtures: %w", err)
+	}
+
+	if filepath.Ext(mapping) != ".json" {
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("HTML fixtures %s: %w", mapping, err)
+		}
+	}
+
+	var pages map[string]string
+
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return nil, fmt.Errorf("HTML fixtures %s: expected an object from URLs to files: %w", mapping, err)
+	}
+
+	set := &Set{pages: make(map[string]string, len(pages))}
+	dir := filepath.Dir(mapping)
+
+	for page, file := range pages {
+		u, err := url.Parse(page)
+
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("HTML fixtures %s: %q is not an absolute URL", mapping, page)
+		}
+
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("HTML fixtures %s: %s: %w", mapping, page, err)
+		}
+
+		set.pages[normalize(u)] = file
+	}
+
+	return set, nil
+}
+
+// Lookup returns the content of the fixture for a page URL. A URL matches an
+// entry exactly, or without its query when no entry has the query. The
+// fragment is ignored.
+func (s *Set) Lookup(page string) ([]byte, error) {
+	u, err := url.Parse(page)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s", ErrNotFound, page)
+	}
+
+	file, ok := s.pages[normalize(u)]
+
+	if !ok && u.RawQuery != "" {
+		stripped := *u
+		stripped.RawQuery = ""
+		file, ok = s.pages[normalize(&stripped)]
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNotFound, page)
+	}
+
+	content, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("HTML fixture for %s: %w", page, err)
+	}
+
+	return content, nil
+}
+
+func mappingFile(path string) (string, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return "", fmt.Errorf("read HTML fixtures: %w", err)
+	}
+
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	for _, name := range MappingFiles {
+		file := filepath.Join(path, name)
+
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("no mapping file in %s; expected one of %s", path, strings.Join(MappingFiles, ", "))
+}
+
+// normalize makes equivalent URLs compare equal: the scheme and host are
+// lowercased, an empty path becomes "/", and the fragment is dropped.
+func normalize(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	n.Fragment = ""
+	n.RawFragment = ""
+
+	if n.Path == "" {
+		n.Path = "/"
+	}
+
+	return n.String()
+}
@@ -0,0 +1,83 @@
+package htmlfixture
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "index.html"), "<h1>Home</h1>")
+	writeFile(t, filepath.Join(dir, "products", "page-2.html"), "<h1>Page 2</h1>")
+	writeFile(t, filepath.Join(dir, "products", "page-1.html"), "<h1>Page 1</h1>")
+	writeFile(t, filepath.Join(dir, "fixtures.yaml"), `
+https://Example.com: index.html
+https://example.com/products: products/page-1.html
+https://example.com/products?page=2: products/page-2.html
+`)
+
+	set, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/", want: "<h1>Home</h1>"},
+		{url: "https://example.com#top", want: "<h1>Home</h1>"},
+		{url: "https://example.com/products?page=2", want: "<h1>Page 2</h1>"},
+		{url: "https://example.com/products?page=3", want: "<h1>Page 1</h1>"},
+	}
+
+	for _, tt := range tests {
+		content, err := set.Lookup(tt.url)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.url, err)
+		}
+
+		if string(content) != tt.want {
+			t.Fatalf("%s: expected %q, got %q", tt.url, tt.want, content)
+		}
+	}
+
+	if _, err := set.Lookup("https://example.com/about"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "no mapping file") {
+		t.Fatalf("expected missing mapping error, got %v", err)
+	}
+
+	mapping := writeFile(t, filepath.Join(dir, "pages.json"), `{"/relative": "index.html"}`)
+	if _, err := Load(mapping); err == nil || !strings.Contains(err.Error(), "not an absolute URL") {
+		t.Fatalf("expected relative URL error, got %v", err)
+	}
+
+	writeFile(t, mapping, `{"https://example.com/": "missing.html"}`)
+	if _, err := Load(mapping); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
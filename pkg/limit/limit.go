@@ -0,0 +1,275 @@
+package limit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+)
+
+// Exit codes of executions stopped by a limit. They let a scheduler tell a
+// runaway script from one that failed on its own, which exits with 1.
+const (
+	ExitTimeout    = 3
+	ExitMemory     = 4
+	ExitOutputSize = 5
+)
+
+var (
+	ErrTimeout    = errors.New("execution timed out")
+	ErrMemory     = errors.New("memory use exceeded the limit")
+	ErrOutputSize = errors.New("result exceeded the output size limit")
+)
+
+// Grace is how long Run waits for an execution to stop after a limit
+// cancelled it, before returning without it.
+var Grace = time.Second
+
+// memoryInterval is how often the heap size is sampled.
+const memoryInterval = 100 * time.Millisecond
+
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// Options are the limits of one execution. A zero value disables a limit.
+type Options struct {
+	Timeout time.Duration
+	// MaxOutputSize caps the size of a result in bytes.
+	MaxOutputSize int64
+	// MaxMemory is a soft ceiling on the heap of the process in bytes.
+	MaxMemory int64
+}
+
+// Error is a limit that stopped an execution.
+type Error struct {
+	err    error
+	detail string
+	code   int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.err, e.detail)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// ExitCode is the status the process exits with.
+func (e *Error) ExitCode() int {
+	return e.code
+}
+
+// Context returns ctx bounded by the timeout and the memory ceiling. cancel
+// must be called once the execution is over.
+func (o Options) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stopMemory := o.WithMemoryLimit(ctx)
+	ctx, stopTimeout := o.WithTimeout(ctx)
+
+	return ctx, func() {
+		stopTimeout()
+		stopMemory()
+	}
+}
+
+// WithTimeout returns ctx cancelled with a timeout error once the timeout
+// has passed.
+func (o Options) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeoutCause(ctx, o.Timeout, &Error{
+		err:    ErrTimeout,
+		detail: fmt.Sprintf("stopped after %s", o.Timeout),
+		code:   ExitTimeout,
+	})
+}
+
+// WithMemoryLimit returns ctx cancelled with a memory error once the heap
+// outgrows MaxMemory. The ceiling is also set as the soft memory limit of the
+// Go runtime, so the collector works harder before the execution is stopped.
+// Only memory of this process is counted; a remote runtime is not.
+func (o Options) WithMemoryLimit(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.MaxMemory <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	previous := debug.SetMemoryLimit(o.MaxMemory)
+	stopped := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(memoryInterval)
+		defer ticker.Stop()
+
+		sample := []metrics.Sample{{Name: heapMetric}}
+
+		for {
+			select {
+			case <-stopped:
+				return
+			case <-ticker.C:
+			}
+
+			metrics.Read(sample)
+
+			if sample[0].Value.Kind() != metrics.KindUint64 {
+				continue
+			}
+
+			if used := sample[0].Value.Uint64(); used > uint64(o.MaxMemory) {
+				cancel(&Error{
+					err:    ErrMemory,
+					detail: fmt.Sprintf("heap reached %d bytes, above the limit of %d", used, o.MaxMemory),
+					code:   ExitMemory,
+				})
+
+				return
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(stopped)
+		cancel(context.Canceled)
+		debug.SetMemoryLimit(previous)
+	}
+}
+
+// SetMemoryLimit sets MaxMemory as the soft memory limit of the Go runtime
+// until restore is called, without stopping any execution. It suits a process
+// running many executions at once, where the heap of one must not stop the
+// others.
+func (o Options) SetMemoryLimit() (restore func()) {
+	if o.MaxMemory <= 0 {
+		return func() {}
+	}
+
+	previous := debug.SetMemoryLimit(o.MaxMemory)
+
+	return func() {
+		debug.SetMemoryLimit(previous)
+	}
+}
+
+// Reader returns r failing with an output size error once more than
+// MaxOutputSize bytes were read from it.
+func (o Options) Reader(r io.Reader) io.Reader {
+	if o.MaxOutputSize <= 0 {
+		return r
+	}
+
+	return &sizeReader{r: r, remaining: o.MaxOutputSize, max: o.MaxOutputSize}
+}
+
+// Run calls run with ctx and returns its result. When a limit stops ctx and
+// run has not returned within Grace, Run returns the limit error without
+// it, so a script that ignores cancellation cannot hang the caller; a
+// result it returns later is closed.
+//
+// Go cannot stop a goroutine from outside, so an abandoned execution keeps
+// running, along with whatever it holds, until run returns. Runtimes are
+// expected to observe ctx and return soon after it is cancelled, which ends
+// the goroutine; Grace only guards against one that does not. In a
+// long-lived process, such as a batch, the repl, or serve, every execution
+// that never returns stays alive outside the caller's concurrency limit
+// until the process exits.
+func Run(ctx context.Context, run func(context.Context) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	type result struct {
+		out io.ReadCloser
+		err error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		out, err := run(ctx)
+		done <- result{out, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.out, Err(ctx, res.err)
+	case <-ctx.Done():
+	}
+
+	if _, ok := limitError(ctx); !ok {
+		res := <-done
+		return res.out, Err(ctx, res.err)
+	}
+
+	select {
+	case res := <-done:
+		return res.out, Err(ctx, res.err)
+	case <-time.After(Grace):
+		go func() {
+			if res := <-done; res.out != nil {
+				_ = res.out.Close()
+			}
+		}()
+
+		return nil, Err(ctx, ctx.Err())
+	}
+}
+
+// Err replaces err with the limit that stopped ctx, if any, since the error
+// an execution returns once cancelled only says that it was.
+func Err(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if limitErr, ok := limitError(ctx); ok {
+		return limitErr
+	}
+
+	return err
+}
+
+func limitError(ctx context.Context) (*Error, bool) {
+	var limitErr *Error
+
+	if ctx.Err() == nil || !errors.As(context.Cause(ctx), &limitErr) {
+		return nil, false
+	}
+
+	return limitErr, true
+}
+
+type sizeReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (s *sizeReader) Read(p []byte) (int, error) {
+	if s.remaining < 0 {
+		return 0, s.err()
+	}
+
+	// Read one byte past the limit to tell a result of exactly MaxOutputSize
+	// bytes from a larger one.
+	if int64(len(p)) > s.remaining+1 {
+		p = p[:s.remaining+1]
+	}
+
+	n, err := s.r.Read(p)
+	s.remaining -= int64(n)
+
+	if s.remaining < 0 {
+		return n + int(s.remaining), s.err()
+	}
+
+	return n, err
+}
+
+func (s *sizeReader) err() error {
+	return &Error{
+		err:    ErrOutputSize,
+		detail: fmt.Sprintf("more than %d bytes", s.max),
+		code:   ExitOutputSize,
+	}
+}
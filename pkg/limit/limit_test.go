@@ -0,0 +1,184 @@
+package limit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReader(t *testing.T) {
+	limits := Options{MaxOutputSize: 5}
+
+	data, err := io.ReadAll(limits.Reader(strings.NewReader("12345")))
+	if err != nil || string(data) != "12345" {
+		t.Fatalf("expected a result of exactly the limit to pass, got %q, %v", data, err)
+	}
+
+	data, err = io.ReadAll(limits.Reader(strings.NewReader("123456")))
+	if !errors.Is(err, ErrOutputSize) {
+		t.Fatalf("expected output size error, got %v", err)
+	}
+
+	if string(data) != "12345" {
+		t.Fatalf("expected only the allowed bytes, got %q", data)
+	}
+
+	assertExitCode(t, err, ExitOutputSize)
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := Options{Timeout: 10 * time.Millisecond}.WithTimeout(context.Background())
+	defer cancel()
+
+	<-ctx.Done()
+
+	err := Err(ctx, ctx.Err())
+	if !errors.Is(err, ErrTimeout) || err.Error() != "execution timed out: stopped after 10ms" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertExitCode(t, err, ExitTimeout)
+
+	plain := errors.New("query failed")
+	if err := Err(context.Background(), plain); err != plain {
+		t.Fatalf("expected errors of unlimited executions to be kept, got %v", err)
+	}
+}
+
+func TestWithMemoryLimit(t *testing.T) {
+	ctx, cancel := Options{MaxMemory: 1}.WithMemoryLimit(context.Background())
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the memory limit to stop the execution")
+	}
+
+	err := Err(ctx, ctx.Err())
+	if !errors.Is(err, ErrMemory) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertExitCode(t, err, ExitMemory)
+}
+
+func TestSetMemoryLimitRestoresPreviousLimit(t *testing.T) {
+	previous := debug.SetMemoryLimit(-1)
+
+	restore := Options{MaxMemory: 1 << 40}.SetMemoryLimit()
+
+	if got := debug.SetMemoryLimit(-1); got != 1<<40 {
+		t.Fatalf("expected the memory limit to be set, got %d", got)
+	}
+
+	restore()
+
+	if got := debug.SetMemoryLimit(-1); got != previous {
+		t.Fatalf("expected the previous memory limit %d, got %d", previous, got)
+	}
+}
+
+func TestRunReturnsWhenExecutionIgnoresLimit(t *testing.T) {
+	previous := Grace
+	Grace = 10 * time.Millisecond
+	defer func() { Grace = previous }()
+
+	ctx, cancel := Options{Timeout: 10 * time.Millisecond}.WithTimeout(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	out, err := Run(ctx, func(context.Context) (io.ReadCloser, error) {
+		<-release
+		return io.NopCloser(strings.NewReader("late")), nil
+	})
+
+	if out != nil || !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout without a result, got %v, %v", out, err)
+	}
+}
+
+func TestRunLeavesNoGoroutinesOnceExecutionStops(t *testing.T) {
+	previous := Grace
+	Grace = 10 * time.Millisecond
+	defer func() { Grace = previous }()
+
+	before := runtime.NumGoroutine()
+
+	for _, delay := range []time.Duration{0, 5 * Grace} {
+		ctx, cancel := Options{Timeout: 10 * time.Millisecond}.WithTimeout(context.Background())
+		closed := make(chan struct{})
+
+		_, err := Run(ctx, func(ctx context.Context) (io.ReadCloser, error) {
+			<-ctx.Done()
+			time.Sleep(delay)
+
+			return closeFunc(func() { close(closed) }), ctx.Err()
+		})
+		cancel()
+
+		if !errors.Is(err, ErrTimeout) {
+			t.Fatalf("expected timeout, got %v", err)
+		}
+
+		if delay > 0 {
+			select {
+			case <-closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected a late result to be closed")
+			}
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no goroutines left behind, have %d, started with %d", runtime.NumGoroutine(), before)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunKeepsResultOfUnlimitedExecution(t *testing.T) {
+	out, err := Run(context.Background(), func(context.Context) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("ok")), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := io.ReadAll(out)
+	if string(data) != "ok" {
+		t.Fatalf("unexpected result: %q", data)
+	}
+}
+
+// closeFunc is a result that calls close when it is closed.
+type closeFunc func()
+
+func (c closeFunc) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (c closeFunc) Close() error {
+	c()
+	return nil
+}
+
+func assertExitCode(t *testing.T, err error, want int) {
+	t.Helper()
+
+	var coded interface{ ExitCode() int }
+	if !errors.As(err, &coded) || coded.ExitCode() != want {
+		t.Fatalf("expected exit code %d for %v", want, err)
+	}
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile calls write with a temporary file next to path and moves it into
+// place once write succeeds, so readers never see a partial result and a
+// failed run leaves an existing file untouched.
+func WriteFile(path string, write func(io.Writer) error) error {
+	return ReplaceFile(path, "output", os.Rename, write)
+}
+
+// ReplaceFile is WriteFile for other kinds of files, such as compiled
+// artifacts: errors name the file by kind, and rename moves the temporary
+// file into place.
+func ReplaceFile(path, kind string, rename func(oldpath, newpath string) error, write func(io.Writer) error) error {
+	outputDir := filepath.Dir(path)
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("create output directory %s: %w", outputDir, err)
+	}
+
+	tempFile, err := os.CreateTemp(outputDir, TempPattern(path))
+
+	if err != nil {
+		return fmt.Errorf("create temporary %s for %s: %w", kind, path, err)
+	}
+
+	tempPath := tempFile.Name()
+	cleanupTemp := true
+	defer func() {
+		if !cleanupTemp {
+			return
+		}
+
+		_ = tempFile.Close()
+		_ = os.Remove(tempPath)
+	}()
+
+	if err := write(tempFile); err != nil {
+		return err
+	}
+
+	if err := tempFile.Chmod(0o644); err != nil {
+		return fmt.Errorf("set permissions on temporary %s for %s: %w", kind, path, err)
+	}
+
+	if err := tempFile.Sync(); err != nil {
+		return fmt.Errorf("sync temporary %s for %s: %w", kind, path, err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("close temporary %s for %s: %w", kind, path, err)
+	}
+
+	if err := rename(tempPath, path); err != nil {
+		return fmt.Errorf("replace %s with temporary %s: %w", path, kind, err)
+	}
+
+	cleanupTemp = false
+
+	return nil
+}
+
+// TempPattern is the pattern of the temporary files that replace path.
+func TempPattern(path string) string {
+	return "." + filepath.Base(path) + ".tmp-*"
+}
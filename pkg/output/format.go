@@ -0,0 +1,371 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Format selects how a query result is written.
+type Format string
+
+const (
+	// FormatJSON writes the result exactly as the runtime returned it.
+	FormatJSON Format = "json"
+	// FormatPretty writes indented JSON.
+	FormatPretty Format = "pretty"
+	FormatYAML   Format = "yaml"
+	// FormatNDJSON writes each element of a top-level array as one compact
+	// JSON value per line. Any other result is written as a single line.
+	FormatNDJSON Format = "ndjson"
+	// FormatCSV writes an array of objects as rows under a header of their
+	// keys. Arrays of arrays become headerless rows, and scalars a single
+	// "value" column.
+	FormatCSV Format = "csv"
+	// FormatTable writes the rows of FormatCSV as aligned columns.
+	FormatTable Format = "table"
+)
+
+// Formats lists the supported formats in the order they are documented.
+var Formats = []Format{FormatJSON, FormatPretty, FormatYAML, FormatNDJSON, FormatCSV, FormatTable}
+
+// ErrNotTabular indicates a result that cannot be written as rows.
+var ErrNotTabular = errors.New("result cannot be written as rows")
+
+func ParseFormat(value string) (Format, error) {
+	if value == "" {
+		return FormatJSON, nil
+	}
+
+	for _, format := range Formats {
+		if Format(value) == format {
+			return format, nil
+		}
+	}
+
+	names := make([]string, 0, len(Formats))
+	for _, format := range Formats {
+		names = append(names, string(format))
+	}
+
+	return "", fmt.Errorf("unknown output format %q; expected one of %s", value, strings.Join(names, ", "))
+}
+
+// Write reads a JSON result from r and writes it to w in format. FormatJSON
+// is copied as it is read; the other formats need the whole result first.
+func Write(w io.Writer, r io.Reader, format Format) error {
+	if format == FormatJSON || format == "" {
+		_, err := io.Copy(w, r)
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	converted, err := Convert(data, format)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(converted)
+
+	return err
+}
+
+// Convert rewrites a JSON result in format. Object keys keep the order the
+// runtime wrote them in.
+func Convert(data []byte, format Format) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("convert result to %s: result is not valid JSON", format)
+	}
+
+	switch format {
+	case FormatJSON, "":
+		return data, nil
+	case FormatPretty:
+		var out bytes.Buffer
+
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			return nil, err
+		}
+
+		out.WriteByte('\n')
+
+		return out.Bytes(), nil
+	case FormatYAML:
+		return yaml.JSONToYAML(data)
+	case FormatNDJSON:
+		return ndjson(data)
+	case FormatCSV, FormatTable:
+		header, rows, err := tabulate(data)
+		if err != nil {
+			return nil, fmt.Errorf("convert result to %s: %w", format, err)
+		}
+
+		if format == FormatCSV {
+			return writeCSV(header, rows)
+		}
+
+		return writeTable(header, rows)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func ndjson(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	items := []json.RawMessage{data}
+
+	if data[0] == '[' {
+		items = nil
+
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range items {
+		if err := json.Compact(&out, item); err != nil {
+			return nil, err
+		}
+
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes(), nil
+}
+
+// field is an object member in the order it appeared in the result.
+type field struct {
+	name  string
+	value json.RawMessage
+}
+
+// tabulate turns a result into rows. The header is empty for arrays of
+// arrays.
+func tabulate(data []byte) ([]string, [][]string, error) {
+	items := []json.RawMessage{data}
+
+	if data[0] == '[' {
+		items = nil
+
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, nil, nil
+	}
+
+	kind := rowKind(items[0])
+
+	for _, item := range items[1:] {
+		if rowKind(item) != kind {
+			return nil, nil, fmt.Errorf("%w: mixed objects, arrays, and scalars", ErrNotTabular)
+		}
+	}
+
+	switch kind {
+	case '{':
+		return objectRows(items)
+	case '[':
+		return arrayRows(items)
+	default:
+		rows := make([][]string, 0, len(items))
+
+		for _, item := range items {
+			rows = append(rows, []string{cell(item)})
+		}
+
+		return []string{"value"}, rows, nil
+	}
+}
+
+func objectRows(items []json.RawMessage) ([]string, [][]string, error) {
+	var header []string
+	columns := make(map[string]int)
+	objects := make([][]field, 0, len(items))
+
+	for _, item := range items {
+		fields, err := decodeObject(item)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, f := range fields {
+			if _, ok := columns[f.name]; !ok {
+				columns[f.name] = len(header)
+				header = append(header, f.name)
+			}
+		}
+
+		objects = append(objects, fields)
+	}
+
+	rows := make([][]string, 0, len(objects))
+
+	for _, fields := range objects {
+		row := make([]string, len(header))
+
+		for _, f := range fields {
+			row[columns[f.name]] = cell(f.value)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
+
+func arrayRows(items []json.RawMessage) ([]string, [][]string, error) {
+	rows := make([][]string, 0, len(items))
+
+	for _, item := range items {
+		var values []json.RawMessage
+
+		if err := json.Unmarshal(item, &values); err != nil {
+			return nil, nil, err
+		}
+
+		row := make([]string, 0, len(values))
+		for _, value := range values {
+			row = append(row, cell(value))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return nil, rows, nil
+}
+
+// decodeObject reads the members of a JSON object without losing their order.
+func decodeObject(data json.RawMessage) ([]field, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var fields []field
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field{name: token.(string), value: value})
+	}
+
+	return fields, nil
+}
+
+// cell formats a value for a single column: strings without quotes, null as
+// an empty cell, and nested values as compact JSON.
+func cell(value json.RawMessage) string {
+	switch kindOf(value) {
+	case '"':
+		var text string
+		_ = json.Unmarshal(value, &text)
+
+		return text
+	case 'n':
+		return ""
+	default:
+		var out bytes.Buffer
+		if err := json.Compact(&out, value); err != nil {
+			return string(value)
+		}
+
+		return out.String()
+	}
+}
+
+// kindOf returns the first byte of a JSON value, which is enough to tell
+// objects, arrays, strings, and null apart.
+func kindOf(value json.RawMessage) byte {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return 0
+	}
+
+	switch value[0] {
+	case '{', '[', '"', 'n':
+		return value[0]
+	default:
+		return 's'
+	}
+}
+
+// rowKind groups values into objects, arrays, and scalars.
+func rowKind(value json.RawMessage) byte {
+	switch kind := kindOf(value); kind {
+	case '{', '[':
+		return kind
+	default:
+		return 's'
+	}
+}
+
+func writeCSV(header []string, rows [][]string) ([]byte, error) {
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+
+	if len(header) > 0 {
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func writeTable(header []string, rows [][]string) ([]byte, error) {
+	var aligned bytes.Buffer
+	table := tabwriter.NewWriter(&aligned, 0, 4, 2, ' ', 0)
+
+	if len(header) > 0 {
+		fmt.Fprintln(table, strings.Join(header, "\t"))
+	}
+
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+
+	if err := table.Flush(); err != nil {
+		return nil, err
+	}
+
+	// Padding after the last non-empty cell only adds trailing spaces.
+	var out bytes.Buffer
+
+	for _, line := range strings.SplitAfter(aligned.String(), "\n") {
+		if line != "" {
+			out.WriteString(strings.TrimRight(line, " \n") + "\n")
+		}
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+package output
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, value := range []string{"json", "pretty", "yaml", "ndjson", "csv", "table"} {
+		format, err := ParseFormat(value)
+		if err != nil || string(format) != value {
+			t.Fatalf("unexpected format for %q: %q, %v", value, format, err)
+		}
+	}
+
+	if format, err := ParseFormat(""); err != nil || format != FormatJSON {
+		t.Fatalf("expected json by default, got %q, %v", format, err)
+	}
+
+	if _, err := ParseFormat("xml"); err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	people := `[{"name":"Ann","age":31,"tags":["a","b"]},{"name":"Bob, Jr.","city":null,"age":4}]`
+
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		want   string
+		errHas string
+	}{
+		{name: "json passthrough", format: FormatJSON, input: `{"b":1,"a":2}`, want: `{"b":1,"a":2}`},
+		{name: "pretty", format: FormatPretty, input: `{"b":1,"a":[1,2]}`, want: "{\n  \"b\": 1,\n  \"a\": [\n    1,\n    2\n  ]\n}\n"},
+		{name: "yaml keeps key order", format: FormatYAML, input: `{"b":1,"a":"x"}`, want: "b: 1\na: x\n"},
+		{name: "ndjson array", format: FormatNDJSON, input: "[{\"a\": 1}, 2, \"three\"]", want: "{\"a\":1}\n2\n\"three\"\n"},
+		{name: "ndjson scalar", format: FormatNDJSON, input: `{"a": [1, 2]}`, want: "{\"a\":[1,2]}\n"},
+		{
+			name:   "csv objects",
+			format: FormatCSV,
+			input:  people,
+			want:   "name,age,tags,city\nAnn,31,\"[\"\"a\"\",\"\"b\"\"]\",\n\"Bob, Jr.\",4,,\n",
+		},
+		{name: "csv arrays", format: FormatCSV, input: `[[1,"a"],[2,null]]`, want: "1,a\n2,\n"},
+		{name: "csv scalars", format: FormatCSV, input: `[1,"two",null]`, want: "value\n1\ntwo\n\n"},
+		{name: "csv single object", format: FormatCSV, input: `{"a":1,"b":true}`, want: "a,b\n1,true\n"},
+		{name: "csv mixed", format: FormatCSV, input: `[{"a":1},2]`, errHas: "mixed objects"},
+		{
+			name:   "table",
+			format: FormatTable,
+			input:  people,
+			want:   "name      age  tags       city\nAnn       31   [\"a\",\"b\"]\nBob, Jr.  4\n",
+		},
+		{name: "invalid json", format: FormatYAML, input: `{`, errHas: "not valid JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Convert([]byte(test.input), test.format)
+			if test.errHas != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errHas) {
+					t.Fatalf("expected error containing %q, got %v", test.errHas, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != test.want {
+				t.Fatalf("unexpected output:\n%q\nwant:\n%q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteFileReplacesOnlyOnSuccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "result.json")
+
+	err := WriteFile(path, func(w io.Writer) error {
+		return Write(w, strings.NewReader(`[1,2]`), FormatNDJSON)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeErr := errors.New("boom")
+	err = WriteFile(path, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1\n2\n" {
+		t.Fatalf("expected previous result to be kept, got %q", data)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be removed: %v", entries)
+	}
+}
@@ -2,7 +2,9 @@ package repl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -10,10 +12,13 @@ import (
 
 	"github.com/MontFerret/ferret/v2/pkg/source"
 
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	clirun "github.com/MontFerret/cli/v2/pkg/run"
 	"github.com/MontFerret/cli/v2/pkg/runtime"
 )
 
-func Start(ctx context.Context, opts runtime.Options, params map[string]interface{}) error {
+// Start runs the shell until the user exits. limits apply to each query.
+func Start(ctx context.Context, opts runtime.Options, params map[string]interface{}, limits limit.Options) error {
 	rt, err := runtime.New(opts)
 
 	if err != nil {
@@ -90,15 +95,39 @@ func Start(ctx context.Context, opts runtime.Options, params map[string]interfac
 			break
 		}
 
-		out, err := rt.Run(ctx, source.NewAnonymous(query), params)
+		src := source.NewAnonymous(query)
+
+		// A session's params serve many queries, so only missing ones are
+		// reported.
+		if err := clirun.CheckParams([]*clirun.Input{{Name: "query", Source: src}}, params).Err(false); err != nil {
+			fmt.Println("Failed to execute the query")
+			fmt.Println(err)
+			continue
+		}
+
+		queryCtx, cancel := limits.Context(ctx)
+		out, err := limit.Run(queryCtx, func(ctx context.Context) (io.ReadCloser, error) {
+			return rt.Run(ctx, src, params)
+		})
 
 		if err != nil {
+			cancel()
 			fmt.Println("Failed to execute the query")
 			fmt.Println(err)
 			continue
 		}
 
-		err = writeResult(os.Stdout, out)
+		err = limit.Err(queryCtx, writeResult(os.Stdout, limitedResult{limits.Reader(out), out}))
+		cancel()
+
+		// A query stopped by a limit leaves the shell usable.
+		var limitErr *limit.Error
+
+		if errors.As(err, &limitErr) {
+			fmt.Println()
+			fmt.Println(err)
+			continue
+		}
 
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -108,3 +137,10 @@ func Start(ctx context.Context, opts runtime.Options, params map[string]interfac
 
 	return nil
 }
+
+// limitedResult reads a result through the output size limit and closes the
+// original result.
+type limitedResult struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,88 @@
+package run
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+// Result is the outcome of one script of a batch.
+type Result struct {
+	Name     string
+	Output   []byte
+	Err      error
+	Duration time.Duration
+}
+
+// ExecuteAll runs inputs through a single runtime with at most jobs of them
+// at a time. Every input runs even when others fail. report is called once
+// per input, in input order, as soon as that input and all before it have
+// finished, so output can be written while later scripts are still running.
+// Changes report makes to a result, such as marking it failed, are kept in
+// the returned results. The timeout and output size of limits apply to each
+// input; a memory ceiling belongs on ctx, since it covers the whole batch.
+func ExecuteAll(ctx context.Context, rt cliruntime.Runtime, params map[string]any, inputs []*Input, jobs int, limits limit.Options, report func(*Result)) []Result {
+	results := make([]Result, len(inputs))
+	done := make([]chan struct{}, len(inputs))
+
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	var group errgroup.Group
+	group.SetLimit(max(jobs, 1))
+
+	go func() {
+		for i, input := range inputs {
+			group.Go(func() error {
+				defer close(done[i])
+
+				results[i] = executeOne(ctx, rt, params, input, limits)
+
+				return nil
+			})
+		}
+	}()
+
+	for i := range inputs {
+		<-done[i]
+
+		if report != nil {
+			report(&results[i])
+		}
+	}
+
+	return results
+}
+
+func executeOne(ctx context.Context, rt cliruntime.Runtime, params map[string]any, input *Input, limits limit.Options) Result {
+	started := time.Now()
+	result := Result{Name: input.Name}
+
+	ctx, cancel := limits.WithTimeout(ctx)
+	defer cancel()
+
+	out, err := limit.Run(ctx, func(ctx context.Context) (io.ReadCloser, error) {
+		if len(input.Artifact) > 0 {
+			return rt.RunArtifact(ctx, input.Artifact, params)
+		}
+
+		return rt.Run(ctx, input.Source, params)
+	})
+
+	if err == nil {
+		result.Output, err = io.ReadAll(limits.Reader(out))
+		err = limit.Err(ctx, err)
+		_ = out.Close()
+	}
+
+	result.Err = err
+	result.Duration = time.Since(started)
+
+	return result
+}
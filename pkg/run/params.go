@@ -0,0 +1,108 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/MontFerret/ferret/v2/pkg/bytecode"
+	"github.com/MontFerret/ferret/v2/pkg/bytecode/artifact"
+	"github.com/MontFerret/ferret/v2/pkg/compiler"
+)
+
+// ParamCheck compares the params scripts reference with the supplied ones.
+type ParamCheck struct {
+	// Missing lists, per script name, the referenced params that were not
+	// supplied.
+	Missing map[string][]string
+	// Unused lists the supplied params no script references.
+	Unused []string
+}
+
+// ReferencedParams returns the entry params of an input, the names a script
+// uses as @name. It reports false when the input cannot be compiled or
+// decoded; running it reports that error with full diagnostics instead.
+func ReferencedParams(input *Input) ([]string, bool) {
+	var program *bytecode.Program
+	var err error
+
+	switch {
+	case len(input.Artifact) > 0:
+		program, err = artifact.Unmarshal(input.Artifact)
+	case input.Source != nil:
+		program, err = compiler.New().Compile(input.Source)
+	default:
+		return nil, false
+	}
+
+	if err != nil || program == nil {
+		return nil, false
+	}
+
+	return program.Params, true
+}
+
+// CheckParams compares the params of every input with params. Inputs that
+// cannot be compiled are skipped, and do not make supplied params unused.
+func CheckParams(inputs []*Input, params map[string]any) ParamCheck {
+	check := ParamCheck{Missing: make(map[string][]string)}
+	referenced := make(map[string]bool)
+	complete := true
+
+	for _, input := range inputs {
+		names, ok := ReferencedParams(input)
+
+		if !ok {
+			complete = false
+			continue
+		}
+
+		for _, name := range names {
+			referenced[name] = true
+
+			if _, ok := params[name]; !ok && !slices.Contains(check.Missing[input.Name], name) {
+				check.Missing[input.Name] = append(check.Missing[input.Name], name)
+			}
+		}
+	}
+
+	if complete {
+		for name := range params {
+			if !referenced[name] {
+				check.Unused = append(check.Unused, name)
+			}
+		}
+
+		sort.Strings(check.Unused)
+	}
+
+	return check
+}
+
+// Err reports missing params and, when strict is set, unused ones.
+func (c ParamCheck) Err(strict bool) error {
+	var problems []string
+
+	names := make([]string, 0, len(c.Missing))
+	for name := range c.Missing {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		problems = append(problems, fmt.Sprintf("%s: missing params %s", name, strings.Join(c.Missing[name], ", ")))
+	}
+
+	if strict && len(c.Unused) > 0 {
+		problems = append(problems, "unknown params "+strings.Join(c.Unused, ", "))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(problems, "; "))
+}
@@ -4,15 +4,20 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/MontFerret/ferret/v2/pkg/bytecode/artifact"
 	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	clisource "github.com/MontFerret/cli/v2/pkg/source"
 )
 
 func ResolveInput(eval string, args []string) (*Input, error) {
 	if eval != "" {
 		return &Input{
+			Name:   "<eval>",
 			Source: source.New("<eval>", eval),
 		}, nil
 	}
@@ -28,6 +33,75 @@ func ResolveInput(eval string, args []string) (*Input, error) {
 	return resolveStdin()
 }
 
+// ResolveInputs reads every script named by paths, in order. Directories are
+// searched recursively for .fql sources and .fqlc artifacts in lexical order,
+// skipping hidden directories.
+func ResolveInputs(paths []string) ([]*Input, error) {
+	var inputs []*Input
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			input, err := resolveFile(path)
+
+			if err != nil {
+				return nil, err
+			}
+
+			inputs = append(inputs, input)
+
+			continue
+		}
+
+		found := len(inputs)
+
+		err = clisource.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() || !IsScriptFile(file) {
+				return nil
+			}
+
+			input, err := resolveFile(file)
+
+			if err != nil {
+				return err
+			}
+
+			inputs = append(inputs, input)
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(inputs) == found {
+			return nil, fmt.Errorf("no .fql or .fqlc scripts found in %s", path)
+		}
+	}
+
+	return inputs, nil
+}
+
+// IsScriptFile reports whether path names a FQL source or compiled artifact.
+func IsScriptFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".fql", ".fqlc":
+		return true
+	default:
+		return false
+	}
+}
+
 func resolveFile(path string) (*Input, error) {
 	data, err := os.ReadFile(path)
 
@@ -61,11 +135,13 @@ func resolveStdin() (*Input, error) {
 func resolveData(name string, data []byte) *Input {
 	if artifact.HasMagic(data) {
 		return &Input{
+			Name:     name,
 			Artifact: data,
 		}
 	}
 
 	return &Input{
+		Name:   name,
 		Source: source.New(name, string(data)),
 	}
 }
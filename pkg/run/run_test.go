@@ -2,13 +2,17 @@ package run
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/MontFerret/cli/v2/pkg/build"
+	"github.com/MontFerret/cli/v2/pkg/limit"
 	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
 	"github.com/MontFerret/ferret/v2/pkg/compiler"
 	"github.com/MontFerret/ferret/v2/pkg/source"
@@ -269,6 +273,188 @@ func TestResolveInput_NoStdinReturnsNil(t *testing.T) {
 	})
 }
 
+func TestResolveInputs_ExpandsDirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	writeQuery(t, filepath.Join(dir, "suite", "b.fql"), "RETURN 2")
+	writeQuery(t, filepath.Join(dir, "suite", "a.fql"), "RETURN 1")
+	writeQuery(t, filepath.Join(dir, "suite", "nested", "c.fql"), "RETURN 3")
+	writeQuery(t, filepath.Join(dir, "suite", "notes.txt"), "not a script")
+	writeQuery(t, filepath.Join(dir, "suite", ".git", "d.fql"), "RETURN 4")
+	writeQuery(t, filepath.Join(dir, "single.fql"), "RETURN 0")
+
+	inputs, err := ResolveInputs([]string{filepath.Join(dir, "single.fql"), filepath.Join(dir, "suite")})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, input := range inputs {
+		names = append(names, strings.TrimPrefix(filepath.ToSlash(input.Name), filepath.ToSlash(dir)+"/"))
+	}
+
+	if strings.Join(names, ",") != "single.fql,suite/a.fql,suite/b.fql,suite/nested/c.fql" {
+		t.Fatalf("unexpected inputs: %v", names)
+	}
+
+	if _, err := ResolveInputs([]string{t.TempDir()}); err == nil || !strings.Contains(err.Error(), "no .fql or .fqlc scripts") {
+		t.Fatalf("unexpected error for empty directory: %v", err)
+	}
+}
+
+func TestExecuteAll_ReportsInOrderWithinJobLimit(t *testing.T) {
+	rt := &batchRuntime{delays: map[string]time.Duration{"a": 30 * time.Millisecond}}
+	inputs := []*Input{
+		{Name: "a", Source: source.New("a", "a")},
+		{Name: "b", Source: source.New("b", "fail")},
+		{Name: "c", Source: source.New("c", "c")},
+	}
+
+	var reported []string
+	results := ExecuteAll(context.Background(), rt, nil, inputs, 2, limit.Options{}, func(result *Result) {
+		reported = append(reported, result.Name)
+	})
+
+	if strings.Join(reported, ",") != "a,b,c" {
+		t.Fatalf("expected results in input order, got %v", reported)
+	}
+
+	if results[1].Err == nil || results[0].Err != nil || string(results[2].Output) != `"c"` {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+
+	if rt.peak != 2 {
+		t.Fatalf("expected two scripts to run at once, got %d", rt.peak)
+	}
+}
+
+func TestCheckParams_ComparesReferencedAndSuppliedParams(t *testing.T) {
+	dir := t.TempDir()
+	artifactInput := filepath.Join(dir, "limit.fql")
+	artifactPath := filepath.Join(dir, "limit.fqlc")
+
+	writeQuery(t, artifactInput, "RETURN @limit")
+	buildArtifact(t, artifactInput, artifactPath)
+
+	artifactResolved, err := ResolveInput("", []string{artifactPath})
+
+	if err != nil {
+		t.Fatalf("unexpected resolve error: %v", err)
+	}
+
+	inputs := []*Input{
+		{Name: "page.fql", Source: source.New("page.fql", "RETURN [@url, @url, @depth]")},
+		artifactResolved,
+	}
+
+	check := CheckParams(inputs, map[string]any{"url": "https://example.com", "limit": float64(5), "urll": "typo"})
+
+	if strings.Join(check.Missing["page.fql"], ",") != "depth" || len(check.Missing) != 1 {
+		t.Fatalf("unexpected missing params: %v", check.Missing)
+	}
+
+	if strings.Join(check.Unused, ",") != "urll" {
+		t.Fatalf("unexpected unused params: %v", check.Unused)
+	}
+}
+
+func TestCheckParams_SkipsInputsThatDoNotCompile(t *testing.T) {
+	inputs := []*Input{{Name: "broken.fql", Source: source.New("broken.fql", "RETURN @url +")}}
+
+	check := CheckParams(inputs, map[string]any{"url": "https://example.com"})
+
+	if len(check.Missing) != 0 || len(check.Unused) != 0 {
+		t.Fatalf("expected no findings for a script that does not compile: %#v", check)
+	}
+}
+
+func TestParamCheckErr(t *testing.T) {
+	check := ParamCheck{
+		Missing: map[string][]string{"b.fql": {"depth"}, "a.fql": {"url", "limit"}},
+		Unused:  []string{"urll"},
+	}
+
+	if err := check.Err(false); err == nil || err.Error() != "a.fql: missing params url, limit; b.fql: missing params depth" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := check.Err(true); err == nil || !strings.HasSuffix(err.Error(), "; unknown params urll") {
+		t.Fatalf("unexpected strict error: %v", err)
+	}
+
+	if err := (ParamCheck{Unused: []string{"urll"}}).Err(false); err != nil {
+		t.Fatalf("unused params should only fail in strict mode: %v", err)
+	}
+}
+
+func TestExecuteAll_AppliesLimitsToEachScript(t *testing.T) {
+	previous := limit.Grace
+	limit.Grace = 10 * time.Millisecond
+	defer func() { limit.Grace = previous }()
+
+	rt := &batchRuntime{delays: map[string]time.Duration{"slow": time.Second}}
+	inputs := []*Input{
+		{Name: "slow", Source: source.New("slow", "a")},
+		{Name: "large", Source: source.New("large", "a much larger result")},
+		{Name: "small", Source: source.New("small", "b")},
+	}
+
+	results := ExecuteAll(context.Background(), rt, nil, inputs, 3, limit.Options{Timeout: 100 * time.Millisecond, MaxOutputSize: 8}, nil)
+
+	if !errors.Is(results[0].Err, limit.ErrTimeout) {
+		t.Fatalf("expected the slow script to time out, got %v", results[0].Err)
+	}
+
+	if !errors.Is(results[1].Err, limit.ErrOutputSize) {
+		t.Fatalf("expected the large result to be rejected, got %v", results[1].Err)
+	}
+
+	if results[2].Err != nil || string(results[2].Output) != `"b"` {
+		t.Fatalf("unexpected result within limits: %#v", results[2])
+	}
+}
+
+// batchRuntime returns each query's text as its result and fails queries
+// named "fail". It records how many queries ran at once.
+type batchRuntime struct {
+	delays  map[string]time.Duration
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (r *batchRuntime) Version(context.Context) (string, error) {
+	return "test", nil
+}
+
+func (r *batchRuntime) Run(_ context.Context, query *source.Source, _ map[string]any) (io.ReadCloser, error) {
+	r.mu.Lock()
+	r.running++
+	r.peak = max(r.peak, r.running)
+	r.mu.Unlock()
+
+	time.Sleep(r.delays[query.Name()] + 10*time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+
+	if query.Content() == "fail" {
+		return nil, errors.New("query failed")
+	}
+
+	return io.NopCloser(strings.NewReader(`"` + query.Content() + `"`)), nil
+}
+
+func (r *batchRuntime) RunArtifact(context.Context, []byte, map[string]any) (io.ReadCloser, error) {
+	return nil, errors.New("artifacts are not supported")
+}
+
+func (r *batchRuntime) Close() error {
+	return nil
+}
+
 func writeQuery(t *testing.T, path, content string) {
 	t.Helper()
 
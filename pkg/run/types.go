@@ -3,6 +3,8 @@ package run
 import "github.com/MontFerret/ferret/v2/pkg/source"
 
 type Input struct {
+	// Name is the file the input was read from, "stdin", or "<eval>".
+	Name     string
 	Artifact []byte
 	Source   *source.Source
 }
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/MontFerret/cli/v2/pkg/har"
 	"github.com/MontFerret/cli/v2/pkg/logger"
 	"github.com/MontFerret/ferret/v2"
 	"github.com/MontFerret/ferret/v2/pkg/logging"
@@ -26,6 +27,8 @@ type Builtin struct {
 	engine  *ferret.Engine
 	logger  *logger.Logger
 	network ferretnet.Network
+	// recorder keeps the HTTP exchanges to save on close when recording.
+	recorder *har.Recorder
 }
 
 func NewBuiltin(opts Options) (Runtime, error) {
@@ -46,12 +49,6 @@ func newBuiltin(opts Options) (*Builtin, error) {
 		}
 	}
 
-	mods, err := newModules(opts)
-
-	if err != nil {
-		return nil, fmt.Errorf("initialize modules: %w", err)
-	}
-
 	log, err := logger.New(opts.Logger)
 
 	if err != nil {
@@ -59,7 +56,6 @@ func newBuiltin(opts Options) (*Builtin, error) {
 	}
 
 	engineOpts := []ferret.Option{
-		ferret.WithModules(mods...),
 		ferret.WithFSRoot(fsRoot),
 	}
 
@@ -76,14 +72,27 @@ func newBuiltin(opts Options) (*Builtin, error) {
 	}
 
 	var network ferretnet.Network
+	var recorder *har.Recorder
+	// pages is the fixture client that DOCUMENT fetches pages with.
+	var pages har.Doer
 
-	if len(opts.HTTPPolicy) > 0 {
+	if len(opts.HTTPPolicy) > 0 || opts.HTTPFixtures != nil {
 		client, err := ferrethttp.New(opts.HTTPPolicy...)
 		if err != nil {
 			_ = log.Close()
 			return nil, fmt.Errorf("initialize HTTP policy: %w", err)
 		}
 
+		if opts.HTTPFixtures != nil {
+			client, recorder, err = newFixtureClient(client, opts.HTTPFixtures)
+			if err != nil {
+				_ = log.Close()
+				return nil, err
+			}
+
+			pages = client.(har.Doer)
+		}
+
 		network, err = ferretnet.New(ferretnet.WithHTTPClient(client))
 		if err != nil {
 			if closer, ok := client.(ferrethttp.IdleConnectionCloser); ok {
@@ -97,6 +106,19 @@ func newBuiltin(opts Options) (*Builtin, error) {
 		engineOpts = append(engineOpts, ferret.WithNetwork(network))
 	}
 
+	mods, err := newModules(opts, pages)
+
+	if err != nil {
+		if network != nil {
+			ferretnet.CloseIdleNetworkConnections(network)
+		}
+
+		_ = log.Close()
+		return nil, fmt.Errorf("initialize modules: %w", err)
+	}
+
+	engineOpts = append(engineOpts, ferret.WithModules(mods...))
+
 	engine, err := ferret.New(engineOpts...)
 
 	if err != nil {
@@ -109,10 +131,11 @@ func newBuiltin(opts Options) (*Builtin, error) {
 	}
 
 	return &Builtin{
-		opts:    opts,
-		engine:  engine,
-		logger:  log,
-		network: network,
+		opts:     opts,
+		engine:   engine,
+		logger:   log,
+		network:  network,
+		recorder: recorder,
 	}, nil
 }
 
@@ -175,6 +198,12 @@ func (rt *Builtin) Close() error {
 		ferretnet.CloseIdleNetworkConnections(rt.network)
 	}
 
+	if rt.recorder != nil {
+		if saveErr := har.Save(rt.opts.HTTPFixtures.Record, version, rt.recorder.Entries()); saveErr != nil {
+			err = errors.Join(err, fmt.Errorf("save HTTP recording: %w", saveErr))
+		}
+	}
+
 	if rt.logger != nil {
 		err = errors.Join(err, rt.logger.Close())
 	}
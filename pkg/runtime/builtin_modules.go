@@ -16,6 +16,7 @@ import (
 	"github.com/MontFerret/contrib/modules/toml"
 	"github.com/MontFerret/contrib/modules/web/article"
 	"github.com/MontFerret/contrib/modules/web/html"
+	"github.com/MontFerret/contrib/modules/web/html/drivers"
 	"github.com/MontFerret/contrib/modules/web/html/drivers/cdp"
 	"github.com/MontFerret/contrib/modules/web/html/drivers/memory"
 	"github.com/MontFerret/contrib/modules/web/robots"
@@ -23,14 +24,20 @@ import (
 	"github.com/MontFerret/contrib/modules/xml"
 	"github.com/MontFerret/contrib/modules/yaml"
 	"github.com/MontFerret/ferret/v2/pkg/module"
+
+	"github.com/MontFerret/cli/v2/pkg/har"
 )
 
 type namespaceInitializer func(opts Options) ([]module.Module, error)
 
-func newModules(opts Options) ([]module.Module, error) {
+// newModules initializes the modules of every namespace. pages, when set, is
+// the HTTP fixture client that the in-memory HTML driver fetches pages with.
+func newModules(opts Options, pages har.Doer) ([]module.Module, error) {
 	return initModules(
 		opts,
-		webMods,
+		func(opts Options) ([]module.Module, error) {
+			return webMods(opts, pages)
+		},
 		dataMods,
 		dbMods,
 		securityMods,
@@ -57,9 +64,24 @@ func initModules(opts Options, initializers ...namespaceInitializer) ([]module.M
 	return merged, nil
 }
 
-func webMods(opts Options) ([]module.Module, error) {
+func webMods(opts Options, pages har.Doer) ([]module.Module, error) {
+	memoryDriver := memory.New(opts.ToInMemory()...)
+	var defaultDriver drivers.Driver = memoryDriver
+
+	if opts.HTMLFixtures != "" {
+		driver, err := newFixtureDriver(memoryDriver, opts.HTMLFixtures)
+
+		if err != nil {
+			return nil, fmt.Errorf("initialize html module: %w", err)
+		}
+
+		defaultDriver = driver
+	} else if pages != nil {
+		defaultDriver = newPageDriver(memoryDriver, pages, opts)
+	}
+
 	htmlmod, err := html.New(
-		html.WithDefaultDriver(memory.New(opts.ToInMemory()...)),
+		html.WithDefaultDriver(defaultDriver),
 		html.WithDrivers(
 			cdp.New(opts.ToCDP()...),
 		),
@@ -3,6 +3,8 @@ package runtime
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/MontFerret/ferret/v2"
@@ -18,6 +20,20 @@ type DebugSession struct {
 	plan      *ferret.Plan
 	closeErr  error
 	closeOnce sync.Once
+	// frames keeps the variables of the outer frames, which the core session
+	// only reports for the innermost one.
+	frames frameRecords
+}
+
+// frameRecords holds, outermost first, the locals each frame on the stack
+// had at the last pause in it. A caller cannot run while it waits on a call,
+// so its variables stay as they were until the program returns to it. A nil
+// entry is a frame that was entered without pausing in it.
+type frameRecords []*frameRecord
+
+type frameRecord struct {
+	frame  ferret.DebugFrame
+	locals []ferret.DebugVariable
 }
 
 // NewDebugSession compiles source for debugging and creates a retained-state
@@ -55,6 +71,130 @@ func NewDebugSession(ctx context.Context, opts Options, params map[string]any, s
 	}, nil
 }
 
+func (s *DebugSession) Start(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.record(s.DebugSession.Start(ctx))
+}
+
+func (s *DebugSession) Continue(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.record(s.DebugSession.Continue(ctx))
+}
+
+func (s *DebugSession) Step(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.record(s.DebugSession.Step(ctx))
+}
+
+func (s *DebugSession) Next(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.record(s.DebugSession.Next(ctx))
+}
+
+func (s *DebugSession) Out(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.record(s.DebugSession.Out(ctx))
+}
+
+// FrameLocals returns the variables of the frame at the given stack index,
+// with 0 the innermost. An outer frame reports its variables as of the last
+// pause in it.
+func (s *DebugSession) FrameLocals(frame int) ([]ferret.DebugVariable, error) {
+	if frame == 0 {
+		return s.Locals()
+	}
+
+	record, err := s.frames.at(frame)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return record.locals, nil
+}
+
+// EvaluateInFrame evaluates expression in the frame at the given stack index.
+// The VM only evaluates in the innermost frame, so an outer frame can print
+// its recorded variables but not compute other expressions.
+func (s *DebugSession) EvaluateInFrame(ctx context.Context, frame int, expression string) (ferret.DebugValue, error) {
+	if frame == 0 {
+		return s.Evaluate(ctx, expression)
+	}
+
+	record, err := s.frames.at(frame)
+
+	if err != nil {
+		return ferret.DebugValue{}, err
+	}
+
+	name := strings.TrimSpace(expression)
+
+	for _, variable := range record.locals {
+		if variable.Name == name {
+			return variable.Value, nil
+		}
+	}
+
+	return ferret.DebugValue{}, fmt.Errorf("%q is not a variable of frame %d; only the variables of an outer frame can be printed", name, frame)
+}
+
+// record updates the frame records after a resume. Once the program stops
+// pausing, no frame is left to inspect.
+func (s *DebugSession) record(event *ferret.DebugEvent, err error) (*ferret.DebugEvent, error) {
+	if err != nil || event == nil || event.Reason == ferret.DebugReasonCompleted || event.Reason == ferret.DebugReasonTerminated {
+		s.frames = nil
+		return event, err
+	}
+
+	// Without the stack the records cannot be matched to frames, so they are
+	// dropped rather than shown for the wrong ones.
+	frames, framesErr := s.Frames()
+	locals, localsErr := s.Locals()
+
+	if framesErr != nil || localsErr != nil {
+		s.frames = nil
+		return event, nil
+	}
+
+	s.frames = s.frames.observe(frames, locals)
+
+	return event, nil
+}
+
+// observe returns the records for a pause with the given stack, innermost
+// frame first, and the locals of its innermost frame. Records of frames that
+// returned, or were replaced by another call at the same depth, are dropped.
+func (r frameRecords) observe(frames []ferret.DebugFrame, locals []ferret.DebugVariable) frameRecords {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	next := make(frameRecords, len(frames))
+
+	for depth := range len(frames) - 1 {
+		frame := frames[len(frames)-1-depth]
+
+		if depth < len(r) && r[depth] != nil && r[depth].frame.Name == frame.Name {
+			next[depth] = r[depth]
+		}
+	}
+
+	next[len(next)-1] = &frameRecord{frame: frames[0], locals: locals}
+
+	return next
+}
+
+// at returns the record of the frame at the given stack index, innermost
+// first.
+func (r frameRecords) at(frame int) (*frameRecord, error) {
+	depth := len(r) - 1 - frame
+
+	if frame < 0 || depth < 0 {
+		return nil, fmt.Errorf("frame %d is not on the stack", frame)
+	}
+
+	if r[depth] == nil {
+		return nil, fmt.Errorf("the program has not paused in frame %d since entering it, so its variables are unknown; step into the call or set a breakpoint before it", frame)
+	}
+
+	return r[depth], nil
+}
+
 // Close releases the debugger session, plan, engine, and logger.
 func (s *DebugSession) Close() error {
 	if s == nil {
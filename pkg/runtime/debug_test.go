@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/MontFerret/ferret/v2"
@@ -161,3 +162,42 @@ func TestNewDebugSessionRejectsRemoteRuntime(t *testing.T) {
 		t.Fatalf("unexpected builtin runtime error: %q", got)
 	}
 }
+
+func TestFrameRecordsKeepOuterFramesUntilTheyReturn(t *testing.T) {
+	mainFrame := ferret.DebugFrame{Name: "<main>"}
+	fnFrame := ferret.DebugFrame{Name: "fn"}
+	mainLocals := []ferret.DebugVariable{{Name: "x", Value: ferret.DebugValue{Display: "1"}}}
+	fnLocals := []ferret.DebugVariable{{Name: "y", Value: ferret.DebugValue{Display: "2"}}}
+
+	var records frameRecords
+
+	records = records.observe([]ferret.DebugFrame{mainFrame}, mainLocals)
+	records = records.observe([]ferret.DebugFrame{fnFrame, mainFrame}, fnLocals)
+
+	record, err := records.at(1)
+	if err != nil || record.locals[0].Name != "x" {
+		t.Fatalf("expected the caller's locals, got %#v, %v", record, err)
+	}
+
+	if _, err := records.at(2); err == nil {
+		t.Fatal("expected a frame beyond the stack to be rejected")
+	}
+
+	// A call entered without pausing in its caller leaves the caller unknown.
+	records = records.observe([]ferret.DebugFrame{fnFrame, {Name: "other"}, mainFrame}, fnLocals)
+
+	if _, err := records.at(1); err == nil || !strings.Contains(err.Error(), "has not paused") {
+		t.Fatalf("expected an unknown frame error, got %v", err)
+	}
+
+	if record, err := records.at(2); err != nil || record.locals[0].Name != "x" {
+		t.Fatalf("expected main to stay recorded, got %#v, %v", record, err)
+	}
+
+	// Returning to main drops the frames that were left.
+	records = records.observe([]ferret.DebugFrame{mainFrame}, mainLocals)
+
+	if len(records) != 1 {
+		t.Fatalf("expected only main to be recorded, got %d frames", len(records))
+	}
+}
@@ -3,8 +3,9 @@ package runtime
 import "errors"
 
 var (
-	// ErrArtifactRequiresBuiltinRuntime indicates compiled artifacts can only run on the builtin runtime.
-	ErrArtifactRequiresBuiltinRuntime = errors.New("compiled artifacts require the builtin runtime")
+	// ErrRemoteArtifactUnsupported indicates the remote worker does not
+	// advertise the artifact feature in /info.
+	ErrRemoteArtifactUnsupported = errors.New("remote runtime does not support compiled artifacts")
 
 	// ErrHTTPPolicyRequiresBuiltinRuntime indicates HTTP policy options cannot configure a remote runtime.
 	ErrHTTPPolicyRequiresBuiltinRuntime = errors.New("HTTP policy options are only supported by the builtin runtime")
@@ -12,7 +13,19 @@ var (
 	// ErrFSPolicyRequiresBuiltinRuntime indicates filesystem policy options cannot configure a remote runtime.
 	ErrFSPolicyRequiresBuiltinRuntime = errors.New("filesystem policy options are only supported by the builtin runtime")
 
-	// ErrDebugRequiresBuiltinRuntime indicates source debugging is only available
-	// through the builtin runtime.
+	// ErrHTTPFixturesRequireBuiltinRuntime indicates HTTP record or replay was
+	// requested for a remote runtime.
+	ErrHTTPFixturesRequireBuiltinRuntime = errors.New("HTTP record and replay are only supported by the builtin runtime")
+
+	// ErrHTMLFixturesRequireBuiltinRuntime indicates HTML fixtures were
+	// requested for a remote runtime.
+	ErrHTMLFixturesRequireBuiltinRuntime = errors.New("HTML fixtures are only supported by the builtin runtime")
+
+	// ErrDebugRequiresBuiltinRuntime indicates an in-process debug session was
+	// requested for a remote runtime; use NewRemoteDebugSession instead.
 	ErrDebugRequiresBuiltinRuntime = errors.New("debug currently supports only the builtin runtime")
+
+	// ErrRemoteDebugUnsupported indicates the remote worker does not expose the
+	// /debug/sessions protocol.
+	ErrRemoteDebugUnsupported = errors.New("remote runtime does not support debugging")
 )
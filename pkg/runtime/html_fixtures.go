@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/MontFerret/contrib/modules/web/html/drivers"
+	"github.com/MontFerret/contrib/modules/web/html/drivers/memory"
+
+	"github.com/MontFerret/cli/v2/pkg/htmlfixture"
+)
+
+// fixtureDriver is the in-memory HTML driver with pages opened from local
+// fixtures instead of fetched over HTTP.
+type fixtureDriver struct {
+	*memory.Driver
+	fixtures *htmlfixture.Set
+}
+
+func newFixtureDriver(driver *memory.Driver, path string) (*fixtureDriver, error) {
+	fixtures, err := htmlfixture.Load(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &fixtureDriver{Driver: driver, fixtures: fixtures}, nil
+}
+
+// Open parses the fixture of the page URL. A URL without a fixture fails
+// rather than reaching the network.
+func (d *fixtureDriver) Open(ctx context.Context, params drivers.Params) (drivers.HTMLPage, error) {
+	content, err := d.fixtures.Lookup(params.URL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Driver.Parse(ctx, drivers.ParseParams{Content: content})
+}
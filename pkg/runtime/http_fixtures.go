@@ -0,0 +1,147 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MontFerret/contrib/modules/web/html/drivers"
+	"github.com/MontFerret/contrib/modules/web/html/drivers/memory"
+	ferrethttp "github.com/MontFerret/ferret/v2/pkg/net/http"
+
+	"github.com/MontFerret/cli/v2/pkg/har"
+)
+
+func (f *HTTPFixtures) validate() error {
+	if f.Record != "" && f.Replay != "" {
+		return errors.New("cannot record and replay at the same time")
+	}
+
+	if f.Record == "" && f.Replay == "" {
+		return errors.New("either a record or a replay path is required")
+	}
+
+	if f.Record != "" && (len(f.Match) > 0 || f.Strict) {
+		return errors.New("request matching only applies to replay")
+	}
+
+	_, err := har.ParseMatch(f.Match)
+
+	return err
+}
+
+// fixtureClient puts a recorder or replayer in front of the runtime's HTTP
+// client.
+type fixtureClient struct {
+	har.Doer
+	inner ferrethttp.Client
+}
+
+func (c *fixtureClient) CloseIdleConnections() {
+	if closer, ok := c.inner.(ferrethttp.IdleConnectionCloser); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// newFixtureClient wraps client as configured by fixtures. The recorder is
+// returned when recording so that its exchanges can be saved on close.
+func newFixtureClient(client ferrethttp.Client, fixtures *HTTPFixtures) (ferrethttp.Client, *har.Recorder, error) {
+	doer, ok := client.(har.Doer)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("HTTP fixtures: the HTTP client cannot be wrapped")
+	}
+
+	if fixtures.Record != "" {
+		recorder := har.NewRecorder(doer, fixtures.KeepSecrets)
+
+		return &fixtureClient{Doer: recorder, inner: client}, recorder, nil
+	}
+
+	entries, err := har.Load(fixtures.Replay)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	match, err := har.ParseMatch(fixtures.Match)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fixtures.Strict {
+		doer = nil
+	}
+
+	replayer, err := har.NewReplayer(entries, match, doer)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &fixtureClient{Doer: replayer, inner: client}, nil, nil
+}
+
+// pageDriver is the in-memory HTML driver with pages fetched through the
+// HTTP fixtures, so that DOCUMENT is recorded and replayed like any other
+// request.
+type pageDriver struct {
+	*memory.Driver
+	client har.Doer
+	header http.Header
+}
+
+func newPageDriver(driver *memory.Driver, client har.Doer, opts Options) *pageDriver {
+	header := make(http.Header)
+
+	if opts.Headers != nil {
+		header = opts.Headers.Data.Clone()
+	}
+
+	if opts.UserAgent != "" {
+		header.Set("User-Agent", opts.UserAgent)
+	}
+
+	if opts.Cookies != nil {
+		for _, cookie := range opts.Cookies.Data {
+			header.Add("Cookie", (&http.Cookie{Name: cookie.Name, Value: cookie.Value}).String())
+		}
+	}
+
+	return &pageDriver{Driver: driver, client: client, header: header}
+}
+
+// Open fetches the page URL through the fixture client and parses the body.
+// A strict replay fails for a page that was not recorded.
+func (d *pageDriver) Open(ctx context.Context, params drivers.Params) (drivers.HTMLPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = d.header.Clone()
+
+	resp, err := d.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("open %s: %s", params.URL, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", params.URL, err)
+	}
+
+	return d.Driver.Parse(ctx, drivers.ParseParams{Content: content})
+}
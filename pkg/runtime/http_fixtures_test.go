@@ -0,0 +1,54 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MontFerret/contrib/modules/web/html/drivers"
+	"github.com/MontFerret/contrib/modules/web/html/drivers/memory"
+
+	"github.com/MontFerret/cli/v2/pkg/har"
+)
+
+func TestPageDriverRecordsPagesOpenedByTheInMemoryDriver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<h1>" + r.UserAgent() + "</h1>"))
+	}))
+	defer server.Close()
+
+	recorder := har.NewRecorder(server.Client(), false)
+	driver := newPageDriver(memory.New(), recorder, Options{UserAgent: "fixture-agent"})
+
+	if _, err := driver.Open(context.Background(), drivers.Params{URL: server.URL + "/page"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Request.URL != server.URL+"/page" || entries[0].Response.Content.Text != "<h1>fixture-agent</h1>" {
+		t.Fatalf("unexpected recording: %#v", entries)
+	}
+}
+
+func TestPageDriverFailsStrictReplayOfUnrecordedPages(t *testing.T) {
+	replayer, err := har.NewReplayer([]har.Entry{{
+		Request:  har.Request{Method: http.MethodGet, URL: "https://example.com/recorded"},
+		Response: har.Response{Status: http.StatusOK, Content: har.Content{Text: "<h1>recorded</h1>"}},
+	}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver := newPageDriver(memory.New(), replayer, Options{})
+	ctx := context.Background()
+
+	if _, err := driver.Open(ctx, drivers.Params{URL: "https://example.com/recorded"}); err != nil {
+		t.Fatalf("expected the recorded page to open, got %v", err)
+	}
+
+	if _, err := driver.Open(ctx, drivers.Params{URL: "https://example.com/missing"}); !errors.Is(err, har.ErrUnmatched) {
+		t.Fatalf("expected an unmatched page to fail, got %v", err)
+	}
+}
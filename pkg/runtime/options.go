@@ -25,6 +25,15 @@ type Options struct {
 	FSPolicy *FileSystemPolicy
 	// HTTPPolicy configures outbound HTTP for the builtin runtime only.
 	HTTPPolicy []ferrethttp.PolicyOption
+	// HTTPFixtures records or replays outbound HTTP for the builtin runtime only.
+	HTTPFixtures *HTTPFixtures
+	// HTMLFixtures is a mapping file, or a directory with one, from which the
+	// in-memory HTML driver opens pages instead of fetching them. Builtin
+	// runtime only.
+	HTMLFixtures string
+	// Remote configures authentication, TLS, timeouts, and retries for
+	// remote runtimes only.
+	Remote RemoteOptions
 }
 
 // FileSystemPolicy configures the sandboxed filesystem used by the builtin runtime.
@@ -33,6 +42,24 @@ type FileSystemPolicy struct {
 	ReadOnly bool
 }
 
+// HTTPFixtures configures recording HTTP exchanges to a HAR file, or
+// answering requests from recorded ones. Record and Replay are exclusive.
+type HTTPFixtures struct {
+	// Record is the HAR file, or the directory of one, written when the
+	// runtime closes.
+	Record string
+	// KeepSecrets records credentials and cookies as they were sent instead
+	// of redacting them.
+	KeepSecrets bool
+	// Replay is a HAR file, or a directory of them, to answer requests from.
+	Replay string
+	// Match names the request parts compared when replaying; see har.Match.
+	Match []string
+	// Strict fails replayed requests without a recording instead of sending
+	// them to the network.
+	Strict bool
+}
+
 func NewDefaultOptions() Options {
 	return Options{
 		Type:                DefaultRuntime,
@@ -45,6 +72,7 @@ func NewDefaultOptions() Options {
 		WithBrowser:         false,
 		WithHeadlessBrowser: false,
 		Logger:              logger.NewDefaultOptions(),
+		Remote:              NewDefaultRemoteOptions(),
 	}
 }
 
@@ -69,6 +97,26 @@ func ValidateOptions(opts Options) error {
 		return ErrFSPolicyRequiresBuiltinRuntime
 	}
 
+	if opts.HTTPFixtures != nil {
+		if !IsBuiltinType(opts.Type) {
+			return ErrHTTPFixturesRequireBuiltinRuntime
+		}
+
+		if err := opts.HTTPFixtures.validate(); err != nil {
+			return fmt.Errorf("HTTP fixtures: %w", err)
+		}
+	}
+
+	if opts.HTMLFixtures != "" && !IsBuiltinType(opts.Type) {
+		return ErrHTMLFixturesRequireBuiltinRuntime
+	}
+
+	if !IsBuiltinType(opts.Type) {
+		if err := opts.Remote.validate(); err != nil {
+			return fmt.Errorf("remote runtime: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -8,10 +8,24 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"sync"
 
 	"github.com/MontFerret/ferret/v2/pkg/source"
 )
 
+// Remote protocol negotiated through /info. Workers that predate it report
+// neither a protocol nor features and only run source text.
+const (
+	// RemoteProtocolVersion is the protocol version spoken by this client.
+	// Version 2 sends artifacts and their params together in a JSON body.
+	RemoteProtocolVersion = 2
+	// RemoteFeatureArtifact marks workers that accept POST /artifact.
+	RemoteFeatureArtifact = "artifact"
+	// RemoteFeatureDebug marks workers that host /debug/sessions.
+	RemoteFeatureDebug = "debug"
+)
+
 type (
 	remoteVersion struct {
 		Worker string `json:"worker"`
@@ -19,8 +33,10 @@ type (
 	}
 
 	remoteInfo struct {
-		IP      string        `json:"ip"`
-		Version remoteVersion `json:"version"`
+		IP       string        `json:"ip"`
+		Version  remoteVersion `json:"version"`
+		Protocol int           `json:"protocol,omitempty"`
+		Features []string      `json:"features,omitempty"`
 	}
 
 	remoteQuery struct {
@@ -28,39 +44,55 @@ type (
 		Params map[string]interface{} `json:"params"`
 	}
 
+	// remoteArtifact is the body of POST /artifact. The artifact is encoded
+	// as base64 by encoding/json.
+	remoteArtifact struct {
+		Artifact []byte         `json:"artifact"`
+		Params   map[string]any `json:"params"`
+	}
+
+	remoteFailure struct {
+		Error string `json:"error"`
+	}
+
+	// RemoteError is a non-2xx answer from a remote worker. Message is the
+	// worker's {"error": "..."} body when it sent one.
+	RemoteError struct {
+		Method     string
+		Endpoint   string
+		StatusCode int
+		Message    string
+	}
+
 	Remote struct {
 		url    url.URL
 		opts   Options
 		client *http.Client
+		mu     sync.Mutex
+		info   *remoteInfo
 	}
 )
 
-func NewRemote(url url.URL, opts Options) Runtime {
+func NewRemote(url url.URL, opts Options) (Runtime, error) {
+	client, err := newRemoteClient(opts.Remote)
+
+	if err != nil {
+		return nil, err
+	}
+
 	rt := new(Remote)
 	rt.url = url
 	rt.opts = opts
-	rt.client = http.DefaultClient
+	rt.client = client
 
-	return rt
+	return rt, nil
 }
 
 func (rt *Remote) Version(ctx context.Context) (string, error) {
-	data, err := rt.makeRequest(ctx, "GET", "/info", nil)
+	info, err := rt.fetchInfo(ctx)
 
 	if err != nil {
-		return "", fmt.Errorf("make request: %w", err)
-	}
-
-	info := remoteInfo{}
-
-	b, err := io.ReadAll(data)
-
-	if err != nil {
-		return "", fmt.Errorf("read response data: %w", err)
-	}
-
-	if err := json.Unmarshal(b, &info); err != nil {
-		return "", fmt.Errorf("deserialize response data: %w", err)
+		return "", err
 	}
 
 	return info.Version.Ferret, nil
@@ -76,17 +108,83 @@ func (rt *Remote) Run(ctx context.Context, query *source.Source, params map[stri
 		return nil, fmt.Errorf("serialize query: %w", err)
 	}
 
-	return rt.makeRequest(ctx, "POST", "/", body)
+	return rt.makeTimedRequest(ctx, "POST", "/", body)
 }
 
-func (rt *Remote) RunArtifact(_ context.Context, _ []byte, _ map[string]any) (io.ReadCloser, error) {
-	return nil, ErrArtifactRequiresBuiltinRuntime
+// RunArtifact sends the compiled artifact to the worker's /artifact endpoint
+// once /info confirms the worker speaks this protocol and supports it.
+func (rt *Remote) RunArtifact(ctx context.Context, data []byte, params map[string]any) (io.ReadCloser, error) {
+	info, err := rt.fetchInfo(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := info.require(RemoteFeatureArtifact, ErrRemoteArtifactUnsupported); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(&remoteArtifact{Artifact: data, Params: params})
+
+	if err != nil {
+		return nil, fmt.Errorf("serialize artifact request: %w", err)
+	}
+
+	return rt.makeTimedRequest(ctx, "POST", "/artifact", body)
 }
 
 func (rt *Remote) Close() error {
 	return nil
 }
 
+// fetchInfo reads the worker's /info once and keeps it for later calls.
+func (rt *Remote) fetchInfo(ctx context.Context) (*remoteInfo, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if rt.info != nil {
+		return rt.info, nil
+	}
+
+	data, err := rt.makeTimedRequest(ctx, "GET", "/info", nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("make request: %w", err)
+	}
+
+	defer data.Close()
+
+	b, err := io.ReadAll(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("read response data: %w", err)
+	}
+
+	info := &remoteInfo{}
+
+	if err := json.Unmarshal(b, info); err != nil {
+		return nil, fmt.Errorf("deserialize response data: %w", err)
+	}
+
+	rt.info = info
+
+	return info, nil
+}
+
+// require returns unsupported unless the worker speaks this client's protocol
+// and advertises feature.
+func (info *remoteInfo) require(feature string, unsupported error) error {
+	if !slices.Contains(info.Features, feature) {
+		return unsupported
+	}
+
+	if info.Protocol != RemoteProtocolVersion {
+		return fmt.Errorf("%w: the worker speaks remote protocol %d, this CLI speaks %d", unsupported, info.Protocol, RemoteProtocolVersion)
+	}
+
+	return nil
+}
+
 func (rt *Remote) createRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Request, error) {
 	var reader io.Reader
 
@@ -115,6 +213,12 @@ func (rt *Remote) createRequest(ctx context.Context, method, endpoint string, bo
 
 	req.Header.Set("Content-Type", "application/json")
 
+	if rt.opts.Remote.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+rt.opts.Remote.Token)
+	} else if rt.opts.Remote.Username != "" {
+		req.SetBasicAuth(rt.opts.Remote.Username, rt.opts.Remote.Password)
+	}
+
 	return req, nil
 }
 
@@ -125,11 +229,104 @@ func (rt *Remote) makeRequest(ctx context.Context, method, endpoint string, body
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	resp, err := rt.client.Do(req)
+	resp, err := rt.send(req)
 
 	if err != nil {
-		return nil, fmt.Errorf("make HTTP request to remote runtime: %w", err)
+		return nil, err
 	}
 
 	return resp.Body, nil
 }
+
+// makeTimedRequest makes the request bounded by the remote timeout, which
+// keeps running until the returned body is closed.
+func (rt *Remote) makeTimedRequest(ctx context.Context, method, endpoint string, body []byte) (io.ReadCloser, error) {
+	if rt.opts.Remote.Timeout == 0 {
+		return rt.makeRequest(ctx, method, endpoint, body)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rt.opts.Remote.Timeout)
+	data, err := rt.makeRequest(ctx, method, endpoint, body)
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &cancelOnClose{ReadCloser: data, cancel: cancel}, nil
+}
+
+// send makes the request, retrying idempotent ones as configured, and turns
+// a non-2xx answer into a *RemoteError.
+func (rt *Remote) send(req *http.Request) (*http.Response, error) {
+	retries := 0
+
+	if isIdempotent(req.Method) {
+		retries = rt.opts.Remote.Retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := wait(req.Context(), attempt); err != nil {
+				return nil, err
+			}
+
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+
+				if err != nil {
+					return nil, fmt.Errorf("create request: %w", err)
+				}
+
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+		}
+
+		resp, err := rt.client.Do(req)
+
+		if err != nil {
+			if attempt < retries && req.Context().Err() == nil {
+				continue
+			}
+
+			return nil, fmt.Errorf("make HTTP request to remote runtime: %w", err)
+		}
+
+		if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+			return resp, nil
+		}
+
+		remoteErr := newRemoteError(req, resp)
+
+		if attempt < retries && isRetryable(resp.StatusCode) {
+			continue
+		}
+
+		return nil, remoteErr
+	}
+}
+
+// newRemoteError reads the worker's error body and closes the response.
+func newRemoteError(req *http.Request, resp *http.Response) *RemoteError {
+	defer resp.Body.Close()
+
+	failure := remoteFailure{}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	_ = json.Unmarshal(data, &failure)
+
+	return &RemoteError{
+		Method:     req.Method,
+		Endpoint:   req.URL.Path,
+		StatusCode: resp.StatusCode,
+		Message:    failure.Error,
+	}
+}
+
+func (e *RemoteError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("remote runtime returned %d %s for %s %s", e.StatusCode, http.StatusText(e.StatusCode), e.Method, e.Endpoint)
+}
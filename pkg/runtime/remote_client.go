@@ -0,0 +1,170 @@
+package runtime
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultRemoteRetries is how many times idempotent remote calls are retried
+// unless configured otherwise.
+const DefaultRemoteRetries = 2
+
+// remoteRetryDelay is the wait before the first retry of a remote call; it
+// doubles with every further attempt.
+const remoteRetryDelay = 250 * time.Millisecond
+
+// RemoteOptions configure how the CLI talks to a remote worker. They are
+// ignored by the builtin runtime.
+type RemoteOptions struct {
+	// Token is sent as a bearer token with every request.
+	Token string
+	// Username and Password are sent as basic auth. They cannot be combined
+	// with Token.
+	Username string
+	Password string
+	// CACert is a PEM file of certificates trusted in addition to the system
+	// pool when verifying the worker.
+	CACert string
+	// ClientCert and ClientKey are the PEM certificate and key presented to
+	// workers that require mutual TLS.
+	ClientCert string
+	ClientKey  string
+	// Timeout bounds each run request, including reading its result, and
+	// each debug call that does not wait for the program. Resuming a debug
+	// session is never bounded. Zero means no timeout.
+	Timeout time.Duration
+	// Retries is how many times an idempotent request is retried after a
+	// connection error or a 429, 502, 503, or 504 answer.
+	Retries int
+}
+
+func NewDefaultRemoteOptions() RemoteOptions {
+	return RemoteOptions{Retries: DefaultRemoteRetries}
+}
+
+func (o RemoteOptions) validate() error {
+	if o.Token != "" && (o.Username != "" || o.Password != "") {
+		return errors.New("a bearer token cannot be combined with basic auth")
+	}
+
+	if o.Password != "" && o.Username == "" {
+		return errors.New("a password requires a username")
+	}
+
+	if (o.ClientCert == "") != (o.ClientKey == "") {
+		return errors.New("a client certificate and key must be given together")
+	}
+
+	if o.Timeout < 0 {
+		return errors.New("timeout cannot be negative")
+	}
+
+	if o.Retries < 0 {
+		return errors.New("retries cannot be negative")
+	}
+
+	return nil
+}
+
+// newRemoteClient returns the HTTP client for a remote worker, using the
+// default client when no TLS settings are configured. The client has no
+// timeout of its own: each call bounds its request through its context.
+func newRemoteClient(opts RemoteOptions) (*http.Client, error) {
+	if opts.CACert == "" && opts.ClientCert == "" {
+		return http.DefaultClient, nil
+	}
+
+	client := &http.Client{}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificate: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA certificate %s contains no PEM certificates", opts.CACert)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if opts.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	client.Transport = transport
+
+	return client, nil
+}
+
+// cancelOnClose releases the context of a timed request once its response
+// body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+
+	return c.ReadCloser.Close()
+}
+
+// isIdempotent reports whether a request can be sent again without running
+// anything twice on the worker.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryable reports whether an answer is worth retrying.
+func isRetryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait sleeps before the given retry, doubling the delay each time.
+func wait(ctx context.Context, retry int) error {
+	timer := time.NewTimer(remoteRetryDelay << (retry - 1))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,496 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+	ferruntime "github.com/MontFerret/ferret/v2/pkg/runtime"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+const remoteDebugEndpoint = "/debug/sessions"
+
+// remoteDebugCallTimeout bounds the debug calls that return without waiting
+// for the program, such as breakpoints, frames, and locals, when no remote
+// timeout is configured. Resume calls wait for the next pause instead.
+const remoteDebugCallTimeout = 30 * time.Second
+
+type (
+	remoteDebugQuery struct {
+		Name   string         `json:"name"`
+		Text   string         `json:"text"`
+		Params map[string]any `json:"params"`
+	}
+
+	remoteDebugCreated struct {
+		ID string `json:"id"`
+	}
+
+	remoteDebugSpan struct {
+		Start int `json:"start"`
+		End   int `json:"end"`
+	}
+
+	remoteDebugLocation struct {
+		File   string          `json:"file"`
+		Line   int             `json:"line"`
+		Column int             `json:"column"`
+		Span   remoteDebugSpan `json:"span"`
+	}
+
+	remoteDebugOutput struct {
+		ContentType string `json:"contentType"`
+		Content     []byte `json:"content"`
+	}
+
+	remoteDebugEvent struct {
+		Reason           string              `json:"reason"`
+		Location         remoteDebugLocation `json:"location"`
+		HitBreakpointIDs []int               `json:"hitBreakpointIds,omitempty"`
+		Error            string              `json:"error,omitempty"`
+		Output           *remoteDebugOutput  `json:"output,omitempty"`
+	}
+
+	remoteDebugBreakpointRequest struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column,omitempty"`
+		Mode   string `json:"mode"`
+	}
+
+	remoteDebugBreakpoint struct {
+		ID              int    `json:"id"`
+		File            string `json:"file"`
+		RequestedLine   int    `json:"requestedLine"`
+		RequestedColumn int    `json:"requestedColumn,omitempty"`
+		Line            int    `json:"line,omitempty"`
+		Column          int    `json:"column,omitempty"`
+		Mode            string `json:"mode"`
+		Bound           bool   `json:"bound"`
+	}
+
+	remoteDebugFrame struct {
+		Name     string              `json:"name"`
+		Location remoteDebugLocation `json:"location"`
+	}
+
+	remoteDebugVariable struct {
+		Name  string `json:"name"`
+		Param bool   `json:"param,omitempty"`
+		Value string `json:"value"`
+	}
+
+	remoteDebugEvaluation struct {
+		Expression string `json:"expression,omitempty"`
+		Frame      int    `json:"frame,omitempty"`
+		Value      string `json:"value,omitempty"`
+	}
+
+	// RemoteDebugSession drives a debugger session hosted by a debug-enabled
+	// Ferret worker. The worker keeps the paused VM; every method is a JSON
+	// call against its /debug/sessions endpoints.
+	RemoteDebugSession struct {
+		remote      *Remote
+		path        string
+		mu          sync.Mutex
+		breakpoints []ferret.DebugBreakpoint
+		closeErr    error
+		closeOnce   sync.Once
+	}
+)
+
+// NewRemoteDebugSession uploads source to a remote worker and opens a debugger
+// session there. The worker must advertise the debug feature in /info, as
+// "ferret serve --debug" does.
+func NewRemoteDebugSession(ctx context.Context, opts Options, params map[string]any, src *source.Source) (*RemoteDebugSession, error) {
+	opts = NormalizeOptions(opts)
+
+	if err := ValidateOptions(opts); err != nil {
+		return nil, err
+	}
+
+	if IsBuiltinType(opts.Type) {
+		return nil, fmt.Errorf("remote debugging requires a remote runtime URL")
+	}
+
+	u, err := url.Parse(normalizeRuntimeType(opts.Type))
+
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	client, err := newRemoteClient(opts.Remote)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session := &RemoteDebugSession{
+		remote: &Remote{url: *u, opts: opts, client: client},
+	}
+
+	callCtx, cancel := session.callContext(ctx)
+	defer cancel()
+
+	info, err := session.remote.fetchInfo(callCtx)
+
+	if err != nil {
+		var status *RemoteError
+
+		if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+			return nil, ErrRemoteDebugUnsupported
+		}
+
+		return nil, err
+	}
+
+	if err := info.require(RemoteFeatureDebug, ErrRemoteDebugUnsupported); err != nil {
+		return nil, err
+	}
+
+	var created remoteDebugCreated
+
+	err = session.call(callCtx, http.MethodPost, remoteDebugEndpoint, &remoteDebugQuery{
+		Name:   src.Name(),
+		Text:   src.Content(),
+		Params: params,
+	}, &created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if created.ID == "" {
+		return nil, fmt.Errorf("remote runtime returned an empty debug session id")
+	}
+
+	session.path = remoteDebugEndpoint + "/" + url.PathEscape(created.ID)
+
+	return session, nil
+}
+
+func (s *RemoteDebugSession) Start(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.resume(ctx, "start")
+}
+
+func (s *RemoteDebugSession) Continue(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.resume(ctx, "continue")
+}
+
+func (s *RemoteDebugSession) Step(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.resume(ctx, "step")
+}
+
+func (s *RemoteDebugSession) Next(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.resume(ctx, "next")
+}
+
+func (s *RemoteDebugSession) Out(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.resume(ctx, "out")
+}
+
+// Pause is sent on its own request so it can interrupt a resume call that is
+// still waiting for the worker to stop.
+func (s *RemoteDebugSession) Pause() error {
+	ctx, cancel := s.callContext(context.Background())
+	defer cancel()
+
+	return s.call(ctx, http.MethodPost, s.path+"/pause", nil, nil)
+}
+
+func (s *RemoteDebugSession) SetBreakpointAt(location ferret.DebugSourceLocation, options ferret.DebugBreakpointOptions) (ferret.DebugBreakpoint, error) {
+	var created remoteDebugBreakpoint
+
+	ctx, cancel := s.callContext(context.Background())
+	defer cancel()
+
+	err := s.call(ctx, http.MethodPost, s.path+"/breakpoints", &remoteDebugBreakpointRequest{
+		File:   location.File,
+		Line:   location.Line,
+		Column: location.Column,
+		Mode:   remoteBindingMode(options.BindingMode),
+	}, &created)
+
+	if err != nil {
+		return ferret.DebugBreakpoint{}, err
+	}
+
+	breakpoint := ferret.DebugBreakpoint{
+		ID:              ferret.DebugBreakpointID(created.ID),
+		File:            created.File,
+		RequestedLine:   created.RequestedLine,
+		RequestedColumn: created.RequestedColumn,
+		Line:            created.Line,
+		Column:          created.Column,
+		BindingMode:     debugBindingMode(created.Mode),
+		Bound:           created.Bound,
+	}
+
+	s.mu.Lock()
+	s.breakpoints = append(s.breakpoints, breakpoint)
+	s.mu.Unlock()
+
+	return breakpoint, nil
+}
+
+func (s *RemoteDebugSession) DeleteBreakpoint(id ferret.DebugBreakpointID) error {
+	endpoint := s.path + "/breakpoints/" + strconv.Itoa(int(id))
+
+	ctx, cancel := s.callContext(context.Background())
+	defer cancel()
+
+	if err := s.call(ctx, http.MethodDelete, endpoint, nil, nil); err != nil {
+		var status *RemoteError
+
+		if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+			return ferruntime.Errorf(ferruntime.ErrNotFound, "breakpoint %d", id)
+		}
+
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, breakpoint := range s.breakpoints {
+		if breakpoint.ID == id {
+			s.breakpoints = append(s.breakpoints[:i], s.breakpoints[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// Breakpoints returns the breakpoints created through this session. The
+// worker owns the session, so no other client can change the set.
+func (s *RemoteDebugSession) Breakpoints() []ferret.DebugBreakpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]ferret.DebugBreakpoint(nil), s.breakpoints...)
+}
+
+func (s *RemoteDebugSession) Frames() ([]ferret.DebugFrame, error) {
+	var frames []remoteDebugFrame
+
+	ctx, cancel := s.callContext(context.Background())
+	defer cancel()
+
+	if err := s.call(ctx, http.MethodGet, s.path+"/frames", nil, &frames); err != nil {
+		return nil, err
+	}
+
+	result := make([]ferret.DebugFrame, 0, len(frames))
+
+	for _, frame := range frames {
+		result = append(result, ferret.DebugFrame{Name: frame.Name, Location: frame.Location.debugLocation()})
+	}
+
+	return result, nil
+}
+
+func (s *RemoteDebugSession) Locals() ([]ferret.DebugVariable, error) {
+	return s.FrameLocals(0)
+}
+
+// FrameLocals returns the variables of the frame at the given stack index,
+// where 0 is the innermost frame.
+func (s *RemoteDebugSession) FrameLocals(frame int) ([]ferret.DebugVariable, error) {
+	var variables []remoteDebugVariable
+
+	endpoint := s.path + "/locals"
+	if frame > 0 {
+		endpoint += "?frame=" + strconv.Itoa(frame)
+	}
+
+	ctx, cancel := s.callContext(context.Background())
+	defer cancel()
+
+	if err := s.call(ctx, http.MethodGet, endpoint, nil, &variables); err != nil {
+		return nil, err
+	}
+
+	result := make([]ferret.DebugVariable, 0, len(variables))
+
+	for _, variable := range variables {
+		result = append(result, ferret.DebugVariable{
+			Name:  variable.Name,
+			Param: variable.Param,
+			Value: ferret.DebugValue{Display: variable.Value},
+		})
+	}
+
+	return result, nil
+}
+
+func (s *RemoteDebugSession) Evaluate(ctx context.Context, expression string) (ferret.DebugValue, error) {
+	return s.EvaluateInFrame(ctx, 0, expression)
+}
+
+// EvaluateInFrame evaluates expression in the scope of the frame at the given
+// stack index, where 0 is the innermost frame.
+func (s *RemoteDebugSession) EvaluateInFrame(ctx context.Context, frame int, expression string) (ferret.DebugValue, error) {
+	var evaluation remoteDebugEvaluation
+
+	ctx, cancel := s.callContext(ctx)
+	defer cancel()
+
+	err := s.call(ctx, http.MethodPost, s.path+"/evaluate", &remoteDebugEvaluation{Expression: expression, Frame: frame}, &evaluation)
+
+	if err != nil {
+		return ferret.DebugValue{}, err
+	}
+
+	return ferret.DebugValue{Display: evaluation.Value}, nil
+}
+
+// Close ends the remote session. A session the worker already discarded is
+// treated as closed.
+func (s *RemoteDebugSession) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.closeOnce.Do(func() {
+		ctx, cancel := s.callContext(context.Background())
+		defer cancel()
+
+		err := s.call(ctx, http.MethodDelete, s.path, nil, nil)
+
+		var status *RemoteError
+
+		if err != nil && !(errors.As(err, &status) && status.StatusCode == http.StatusNotFound) {
+			s.closeErr = err
+		}
+	})
+
+	return s.closeErr
+}
+
+// callContext bounds a call that does not wait for the program by the remote
+// timeout, or by remoteDebugCallTimeout when none is configured.
+func (s *RemoteDebugSession) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := s.remote.opts.Remote.Timeout
+
+	if timeout <= 0 {
+		timeout = remoteDebugCallTimeout
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (s *RemoteDebugSession) resume(ctx context.Context, action string) (*ferret.DebugEvent, error) {
+	var event remoteDebugEvent
+
+	if err := s.call(ctx, http.MethodPost, s.path+"/"+action, nil, &event); err != nil {
+		return nil, err
+	}
+
+	result := &ferret.DebugEvent{
+		Reason:   ferret.DebugReason(event.Reason),
+		Location: event.Location.debugLocation(),
+	}
+
+	for _, id := range event.HitBreakpointIDs {
+		result.HitBreakpointIDs = append(result.HitBreakpointIDs, ferret.DebugBreakpointID(id))
+	}
+
+	if event.Error != "" {
+		result.Error = errors.New(event.Error)
+	}
+
+	if event.Output != nil {
+		result.Output = &encoding.Output{
+			ContentType: event.Output.ContentType,
+			Content:     event.Output.Content,
+		}
+	}
+
+	return result, nil
+}
+
+func (s *RemoteDebugSession) call(ctx context.Context, method, endpoint string, body, result any) error {
+	var payload []byte
+
+	if body != nil {
+		data, err := json.Marshal(body)
+
+		if err != nil {
+			return fmt.Errorf("serialize debug request: %w", err)
+		}
+
+		payload = data
+	}
+
+	req, err := s.remote.createRequest(ctx, method, endpoint, payload)
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.remote.send(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return fmt.Errorf("read response data: %w", err)
+	}
+
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("deserialize response data: %w", err)
+	}
+
+	return nil
+}
+
+func (l remoteDebugLocation) debugLocation() ferret.DebugLocation {
+	return ferret.DebugLocation{
+		File:   l.File,
+		Line:   l.Line,
+		Column: l.Column,
+		Span:   source.Span{Start: l.Span.Start, End: l.Span.End},
+	}
+}
+
+func remoteBindingMode(mode ferret.DebugBreakpointBindingMode) string {
+	switch mode {
+	case ferret.DebugBreakpointBindExact:
+		return "exact"
+	case ferret.DebugBreakpointBindNextExecutableInFunction:
+		return "in-function"
+	default:
+		return "next-file"
+	}
+}
+
+func debugBindingMode(mode string) ferret.DebugBreakpointBindingMode {
+	switch mode {
+	case "exact":
+		return ferret.DebugBreakpointBindExact
+	case "in-function":
+		return ferret.DebugBreakpointBindNextExecutableInFunction
+	default:
+		return ferret.DebugBreakpointBindNextExecutableInFile
+	}
+}
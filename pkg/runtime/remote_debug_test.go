@@ -0,0 +1,312 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/ferret/v2"
+	ferruntime "github.com/MontFerret/ferret/v2/pkg/runtime"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+func TestRemoteDebugSessionDrivesWorkerProtocol(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		query    remoteDebugQuery
+	)
+
+	mux := http.NewServeMux()
+	record := func(r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+	}
+	reply := func(w http.ResponseWriter, value any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(value)
+	}
+
+	mux.HandleFunc("GET /info", debugInfo)
+	mux.HandleFunc("POST /debug/sessions", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		_ = json.NewDecoder(r.Body).Decode(&query)
+		reply(w, remoteDebugCreated{ID: "s1"})
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/breakpoints", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		var req remoteDebugBreakpointRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		reply(w, remoteDebugBreakpoint{ID: 1, File: req.File, RequestedLine: req.Line, Line: req.Line + 1, Mode: req.Mode, Bound: true})
+	})
+	mux.HandleFunc("DELETE /debug/sessions/s1/breakpoints/{id}", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		if r.PathValue("id") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/start", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		reply(w, remoteDebugEvent{Reason: "entry", Location: remoteDebugLocation{File: "demo.fql", Line: 1, Column: 1}})
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/continue", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		reply(w, remoteDebugEvent{
+			Reason:           "breakpoint",
+			Location:         remoteDebugLocation{File: "demo.fql", Line: 2, Column: 1, Span: remoteDebugSpan{Start: 10, End: 16}},
+			HitBreakpointIDs: []int{1},
+		})
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/next", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		reply(w, remoteDebugEvent{Reason: "completed", Output: &remoteDebugOutput{ContentType: "application/json", Content: []byte("2")}})
+	})
+	mux.HandleFunc("GET /debug/sessions/s1/frames", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		reply(w, []remoteDebugFrame{{Name: "<main>", Location: remoteDebugLocation{File: "demo.fql", Line: 2}}})
+	})
+	mux.HandleFunc("GET /debug/sessions/s1/locals", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		reply(w, []remoteDebugVariable{{Name: "x", Value: "1"}, {Name: "@limit", Param: true, Value: "10"}})
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/evaluate", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		var req remoteDebugEvaluation
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Expression != "x + 1" {
+			w.WriteHeader(http.StatusBadRequest)
+			reply(w, remoteFailure{Error: "expression is not supported"})
+			return
+		}
+		reply(w, remoteDebugEvaluation{Value: "2"})
+	})
+	mux.HandleFunc("DELETE /debug/sessions/s1", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	session, err := NewRemoteDebugSession(ctx, Options{Type: server.URL}, map[string]any{"limit": 10}, source.New("demo.fql", "LET x = 1\nRETURN x + 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query.Name != "demo.fql" || query.Text != "LET x = 1\nRETURN x + 1" || query.Params["limit"] != float64(10) {
+		t.Fatalf("unexpected uploaded query: %#v", query)
+	}
+
+	breakpoint, err := session.SetBreakpointAt(
+		ferret.DebugSourceLocation{File: "demo.fql", Line: 1},
+		ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindExact},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if breakpoint.ID != 1 || breakpoint.Line != 2 || !breakpoint.Bound || breakpoint.BindingMode != ferret.DebugBreakpointBindExact {
+		t.Fatalf("unexpected breakpoint: %#v", breakpoint)
+	}
+	if got := session.Breakpoints(); len(got) != 1 || got[0] != breakpoint {
+		t.Fatalf("unexpected breakpoints: %#v", got)
+	}
+
+	event, err := session.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Reason != ferret.DebugReasonEntry || event.Location.Line != 1 {
+		t.Fatalf("unexpected start event: %#v", event)
+	}
+
+	event, err = session.Continue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Reason != ferret.DebugReasonBreakpoint || event.Location.Span != (source.Span{Start: 10, End: 16}) ||
+		len(event.HitBreakpointIDs) != 1 || event.HitBreakpointIDs[0] != breakpoint.ID {
+		t.Fatalf("unexpected breakpoint event: %#v", event)
+	}
+
+	frames, err := session.Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || frames[0].Name != "<main>" || frames[0].Location.Line != 2 {
+		t.Fatalf("unexpected frames: %#v", frames)
+	}
+
+	locals, err := session.Locals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locals) != 2 || locals[1].Name != "@limit" || !locals[1].Param || locals[1].Value.Display != "10" {
+		t.Fatalf("unexpected locals: %#v", locals)
+	}
+
+	value, err := session.Evaluate(ctx, "x + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Display != "2" {
+		t.Fatalf("unexpected evaluation: %#v", value)
+	}
+	if _, err := session.Evaluate(ctx, "LENGTH([1])"); err == nil || err.Error() != "expression is not supported" {
+		t.Fatalf("expected worker error message, got %v", err)
+	}
+
+	if err := session.DeleteBreakpoint(7); !errors.Is(err, ferruntime.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if err := session.DeleteBreakpoint(breakpoint.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := session.Breakpoints(); len(got) != 0 {
+		t.Fatalf("expected breakpoint to be removed, got %#v", got)
+	}
+
+	event, err = session.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Reason != ferret.DebugReasonCompleted || event.Output == nil || string(event.Output.Content) != "2" {
+		t.Fatalf("unexpected completion event: %#v", event)
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if last := requests[len(requests)-1]; last != "DELETE /debug/sessions/s1" {
+		t.Fatalf("expected session to be deleted once, got %v", requests)
+	}
+}
+
+func TestNewRemoteDebugSessionUnsupportedWorker(t *testing.T) {
+	withoutFeature := http.NewServeMux()
+	withoutFeature.HandleFunc("GET /info", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(remoteInfo{Protocol: RemoteProtocolVersion, Features: []string{RemoteFeatureArtifact}})
+	})
+
+	for name, handler := range map[string]http.Handler{
+		"no info":    http.NotFoundHandler(),
+		"no feature": withoutFeature,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			_, err := NewRemoteDebugSession(context.Background(), Options{Type: server.URL}, nil, source.NewAnonymous("RETURN 1"))
+
+			if !errors.Is(err, ErrRemoteDebugUnsupported) {
+				t.Fatalf("expected unsupported error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoteDebugSessionBoundsControlCalls(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", debugInfo)
+	mux.HandleFunc("POST /debug/sessions", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(remoteDebugCreated{ID: "s1"})
+	})
+	mux.HandleFunc("GET /debug/sessions/s1/frames", func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/continue", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"reason":"completed"}`))
+	})
+	mux.HandleFunc("DELETE /debug/sessions/s1", func(http.ResponseWriter, *http.Request) {})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	opts := Options{Type: server.URL, Remote: RemoteOptions{Timeout: 30 * time.Millisecond}}
+	session, err := NewRemoteDebugSession(context.Background(), opts, nil, source.NewAnonymous("RETURN 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if _, err := session.Frames(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected frames to time out, got %v", err)
+	}
+
+	// Resuming waits for the program however long it runs.
+	if _, err := session.Continue(context.Background()); err != nil {
+		t.Fatalf("expected continue to outlast the timeout, got %v", err)
+	}
+}
+
+func debugInfo(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(remoteInfo{
+		Protocol: RemoteProtocolVersion,
+		Features: []string{RemoteFeatureArtifact, RemoteFeatureDebug},
+	})
+}
+
+func TestRemoteDebugSessionInspectsSelectedFrame(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", debugInfo)
+	mux.HandleFunc("POST /debug/sessions", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(remoteDebugCreated{ID: "s1"})
+	})
+	mux.HandleFunc("GET /debug/sessions/s1/locals", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]remoteDebugVariable{{Name: "frame", Value: r.URL.Query().Get("frame")}})
+	})
+	mux.HandleFunc("POST /debug/sessions/s1/evaluate", func(w http.ResponseWriter, r *http.Request) {
+		var req remoteDebugEvaluation
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(remoteDebugEvaluation{Value: req.Expression + "@" + strconv.Itoa(req.Frame)})
+	})
+	mux.HandleFunc("DELETE /debug/sessions/s1", func(http.ResponseWriter, *http.Request) {})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	session, err := NewRemoteDebugSession(ctx, Options{Type: server.URL}, nil, source.New("demo.fql", "RETURN 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	locals, err := session.FrameLocals(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locals) != 1 || locals[0].Value.Display != "2" {
+		t.Fatalf("unexpected frame locals: %#v", locals)
+	}
+
+	locals, err = session.Locals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locals) != 1 || locals[0].Value.Display != "" {
+		t.Fatalf("expected innermost frame without a query, got %#v", locals)
+	}
+
+	value, err := session.EvaluateInFrame(ctx, 1, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Display != "x@1" {
+		t.Fatalf("unexpected frame evaluation: %#v", value)
+	}
+}
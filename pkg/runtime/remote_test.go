@@ -0,0 +1,201 @@
+package runtime
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+func newTestRemote(t *testing.T, rawURL string, opts RemoteOptions) Runtime {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := NewRemote(*u, Options{Type: rawURL, Remote: opts})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return rt
+}
+
+func TestRemote_ReportsWorkerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error":"script failed"}`))
+	}))
+	defer server.Close()
+
+	rt := newTestRemote(t, server.URL, RemoteOptions{})
+
+	_, err := rt.Run(context.Background(), source.NewAnonymous("RETURN 1"), nil)
+
+	var remoteErr *RemoteError
+	if !errors.As(err, &remoteErr) || remoteErr.StatusCode != http.StatusUnprocessableEntity || err.Error() != "script failed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = rt.Version(context.Background())
+	if !errors.As(err, &remoteErr) || !strings.Contains(err.Error(), "500 Internal Server Error for GET /info") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRemote_RetriesOnlyIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"version":{"ferret":"2"}}`))
+	}))
+	defer server.Close()
+
+	rt := newTestRemote(t, server.URL, RemoteOptions{Retries: 2})
+
+	if version, err := rt.Version(context.Background()); err != nil || version != "2" {
+		t.Fatalf("unexpected version %q, %v", version, err)
+	}
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	calls.Store(0)
+
+	if _, err := rt.Run(context.Background(), source.NewAnonymous("RETURN 1"), nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single attempt for a script run, got %d", calls.Load())
+	}
+}
+
+func TestRemote_BoundsRunsByTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); strings.Contains(string(body), "WAITFOR") {
+			<-r.Context().Done()
+			return
+		}
+
+		_, _ = w.Write([]byte(`42`))
+	}))
+	defer server.Close()
+
+	rt := newTestRemote(t, server.URL, RemoteOptions{Timeout: 30 * time.Millisecond})
+
+	if _, err := rt.Run(context.Background(), source.NewAnonymous("WAITFOR EVENT \"never\""), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the run to time out, got %v", err)
+	}
+
+	out, err := rt.Run(context.Background(), source.NewAnonymous("RETURN 42"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if body, _ := io.ReadAll(out); string(body) != "42" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	if err := out.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+}
+
+func TestRemote_SendsCredentials(t *testing.T) {
+	var auth atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opts RemoteOptions
+		want string
+	}{
+		{name: "bearer", opts: RemoteOptions{Token: "secret"}, want: "Bearer secret"},
+		{name: "basic", opts: RemoteOptions{Username: "ferret", Password: "pw"}, want: "Basic ZmVycmV0OnB3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTestRemote(t, server.URL, tt.opts).Version(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := auth.Load(); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRemote_TrustsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"version":{"ferret":"2"}}`))
+	}))
+	defer server.Close()
+
+	if _, err := newTestRemote(t, server.URL, RemoteOptions{}).Version(context.Background()); err == nil {
+		t.Fatal("expected an untrusted certificate error")
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	if err := os.WriteFile(caPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := newTestRemote(t, server.URL, RemoteOptions{CACert: caPath}).Run(context.Background(), source.NewAnonymous("RETURN 1"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Close()
+
+	if body, _ := io.ReadAll(out); !strings.Contains(string(body), "ferret") {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestRemoteOptions_Validate(t *testing.T) {
+	tests := []RemoteOptions{
+		{Token: "secret", Username: "ferret"},
+		{Password: "pw"},
+		{ClientCert: "client.pem"},
+		{Retries: -1},
+	}
+
+	for _, opts := range tests {
+		if err := ValidateOptions(Options{Type: "https://worker.example", Remote: opts}); err == nil {
+			t.Fatalf("expected %+v to be rejected", opts)
+		}
+	}
+}
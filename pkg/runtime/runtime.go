@@ -38,7 +38,7 @@ func New(opts Options) (Runtime, error) {
 		return nil, fmt.Errorf("parse url: %w", err)
 	}
 
-	return NewRemote(*u, opts), nil
+	return NewRemote(*u, opts)
 }
 
 func Run(ctx context.Context, opts Options, query *source.Source, params map[string]any) (out io.ReadCloser, err error) {
@@ -2,18 +2,84 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
-func TestRunArtifact_RemoteRuntimeRejected(t *testing.T) {
-	_, err := RunArtifact(context.Background(), Options{Type: "https://worker.example"}, []byte("FBC2"), nil)
+func TestRunArtifact_RemoteRuntime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			_, _ = w.Write([]byte(`{"version":{"ferret":"2"},"protocol":2,"features":["artifact"]}`))
+		case "/artifact":
+			var body remoteArtifact
 
-	if err == nil {
-		t.Fatal("expected error")
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Params["n"] != float64(1) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"bad artifact request"}`))
+
+				return
+			}
+
+			_, _ = w.Write(body.Artifact)
+		}
+	}))
+	defer server.Close()
+
+	out, err := RunArtifact(context.Background(), Options{Type: server.URL}, []byte("FBC2"), map[string]any{"n": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
+	defer out.Close()
+
+	if data, _ := io.ReadAll(out); string(data) != "FBC2" {
+		t.Fatalf("unexpected result: %q", data)
+	}
+}
+
+func TestRunArtifact_RemoteRuntimeReportsWorkerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			_, _ = w.Write([]byte(`{"protocol":2,"features":["artifact"]}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"incompatible artifact"}`))
+		}
+	}))
+	defer server.Close()
 
-	if !errors.Is(err, ErrArtifactRequiresBuiltinRuntime) {
+	_, err := RunArtifact(context.Background(), Options{Type: server.URL}, []byte("FBC2"), nil)
+
+	if err == nil || err.Error() != "incompatible artifact" {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestRunArtifact_RemoteRuntimeWithoutSupportRejected(t *testing.T) {
+	for name, info := range map[string]string{
+		"legacy worker":  `{"version":{"ferret":"2"}}`,
+		"older protocol": `{"version":{"ferret":"2"},"protocol":1,"features":["artifact"]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/info" {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+
+				_, _ = w.Write([]byte(info))
+			}))
+			defer server.Close()
+
+			_, err := RunArtifact(context.Background(), Options{Type: server.URL}, []byte("FBC2"), nil)
+
+			if !errors.Is(err, ErrRemoteArtifactUnsupported) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,599 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MontFerret/ferret/v2"
+	ferruntime "github.com/MontFerret/ferret/v2/pkg/runtime"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+// debugIdleTimeout is how long a paused debug session is kept without any
+// request before it is closed.
+const debugIdleTimeout = 10 * time.Minute
+
+type (
+	// DebugSession is a debugger session hosted by the server.
+	DebugSession interface {
+		Start(context.Context) (*ferret.DebugEvent, error)
+		Continue(context.Context) (*ferret.DebugEvent, error)
+		Step(context.Context) (*ferret.DebugEvent, error)
+		Next(context.Context) (*ferret.DebugEvent, error)
+		Out(context.Context) (*ferret.DebugEvent, error)
+		Pause() error
+		SetBreakpointAt(ferret.DebugSourceLocation, ferret.DebugBreakpointOptions) (ferret.DebugBreakpoint, error)
+		DeleteBreakpoint(ferret.DebugBreakpointID) error
+		Frames() ([]ferret.DebugFrame, error)
+		Locals() ([]ferret.DebugVariable, error)
+		Evaluate(context.Context, string) (ferret.DebugValue, error)
+		Close() error
+	}
+
+	// DebugSessionFactory opens a debugger session for src.
+	DebugSessionFactory func(ctx context.Context, src *source.Source, params map[string]any) (DebugSession, error)
+
+	// frameInspector is implemented by sessions that can inspect frames other
+	// than the innermost one.
+	frameInspector interface {
+		FrameLocals(frame int) ([]ferret.DebugVariable, error)
+		EvaluateInFrame(ctx context.Context, frame int, expression string) (ferret.DebugValue, error)
+	}
+
+	hostedSession struct {
+		DebugSession
+
+		// mu is held while the session runs, so inspection calls fail instead
+		// of racing the VM. Pause bypasses it to interrupt a running resume.
+		mu   sync.Mutex
+		idle *time.Timer
+	}
+
+	debugQuery struct {
+		Name   string         `json:"name"`
+		Text   string         `json:"text"`
+		Params map[string]any `json:"params"`
+	}
+
+	debugCreated struct {
+		ID string `json:"id"`
+	}
+
+	debugSpan struct {
+		Start int `json:"start"`
+		End   int `json:"end"`
+	}
+
+	debugLocation struct {
+		File   string    `json:"file"`
+		Line   int       `json:"line"`
+		Column int       `json:"column"`
+		Span   debugSpan `json:"span"`
+	}
+
+	debugOutput struct {
+		ContentType string `json:"contentType"`
+		Content     []byte `json:"content"`
+	}
+
+	debugEvent struct {
+		Reason           string        `json:"reason"`
+		Location         debugLocation `json:"location"`
+		HitBreakpointIDs []int         `json:"hitBreakpointIds,omitempty"`
+		Error            string        `json:"error,omitempty"`
+		Output           *debugOutput  `json:"output,omitempty"`
+	}
+
+	debugBreakpointRequest struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column,omitempty"`
+		Mode   string `json:"mode"`
+	}
+
+	debugBreakpoint struct {
+		ID              int    `json:"id"`
+		File            string `json:"file"`
+		RequestedLine   int    `json:"requestedLine"`
+		RequestedColumn int    `json:"requestedColumn,omitempty"`
+		Line            int    `json:"line,omitempty"`
+		Column          int    `json:"column,omitempty"`
+		Mode            string `json:"mode"`
+		Bound           bool   `json:"bound"`
+	}
+
+	debugFrame struct {
+		Name     string        `json:"name"`
+		Location debugLocation `json:"location"`
+	}
+
+	debugVariable struct {
+		Name  string `json:"name"`
+		Param bool   `json:"param,omitempty"`
+		Value string `json:"value"`
+	}
+
+	debugEvaluation struct {
+		Expression string `json:"expression,omitempty"`
+		Frame      int    `json:"frame,omitempty"`
+		Value      string `json:"value,omitempty"`
+	}
+)
+
+var errSessionRunning = errors.New("debug session is running; pause it first")
+
+func (s *Server) handleDebugCreate(w http.ResponseWriter, r *http.Request) {
+	var q debugQuery
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+
+	if err == nil {
+		err = json.Unmarshal(body, &q)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid query: %w", err))
+		return
+	}
+
+	// The slot is reserved before the session opens, so that concurrent
+	// creates cannot open more sessions than there are slots.
+	s.debugMu.Lock()
+	reserved := s.reserveSlot()
+	s.debugMu.Unlock()
+
+	if !reserved {
+		writeError(w, http.StatusServiceUnavailable, errors.New("too many debug sessions"))
+		return
+	}
+
+	name := q.Name
+
+	if name == "" {
+		name = "anonymous"
+	}
+
+	// The session outlives the request that creates it.
+	session, err := s.opts.Debug(context.Background(), source.New(name, q.Text), q.Params)
+
+	if err != nil {
+		s.releaseSlot()
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	id, err := newSessionID()
+
+	if err != nil {
+		_ = session.Close()
+		s.releaseSlot()
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	hosted := &hostedSession{DebugSession: session}
+	hosted.idle = time.AfterFunc(debugIdleTimeout, func() {
+		s.expireSession(id)
+	})
+
+	s.debugMu.Lock()
+	s.sessions[id] = hosted
+	s.debugMu.Unlock()
+
+	writeJSON(w, http.StatusOK, debugCreated{ID: id})
+}
+
+func (s *Server) handleDebugResume(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.session(w, r)
+
+	if !ok {
+		return
+	}
+
+	var resume func(context.Context) (*ferret.DebugEvent, error)
+
+	switch r.PathValue("action") {
+	case "start":
+		resume = session.Start
+	case "continue":
+		resume = session.Continue
+	case "step":
+		resume = session.Step
+	case "next":
+		resume = session.Next
+	case "out":
+		resume = session.Out
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown debug action %q", r.PathValue("action")))
+		return
+	}
+
+	session.mu.Lock()
+	event, err := resume(r.Context())
+	session.mu.Unlock()
+
+	session.touch()
+
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newDebugEvent(event))
+}
+
+func (s *Server) handleDebugPause(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.session(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := session.Pause(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDebugSetBreakpoint(w http.ResponseWriter, r *http.Request) {
+	var req debugBreakpointRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid breakpoint: %w", err))
+		return
+	}
+
+	s.inspect(w, r, func(session *hostedSession) (any, error) {
+		breakpoint, err := session.SetBreakpointAt(ferret.DebugSourceLocation{
+			File:   req.File,
+			Line:   req.Line,
+			Column: req.Column,
+		}, ferret.DebugBreakpointOptions{BindingMode: bindingMode(req.Mode)})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return debugBreakpoint{
+			ID:              int(breakpoint.ID),
+			File:            breakpoint.File,
+			RequestedLine:   breakpoint.RequestedLine,
+			RequestedColumn: breakpoint.RequestedColumn,
+			Line:            breakpoint.Line,
+			Column:          breakpoint.Column,
+			Mode:            bindingModeName(breakpoint.BindingMode),
+			Bound:           breakpoint.Bound,
+		}, nil
+	})
+}
+
+func (s *Server) handleDebugDeleteBreakpoint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("breakpoint"))
+
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown breakpoint %q", r.PathValue("breakpoint")))
+		return
+	}
+
+	s.inspect(w, r, func(session *hostedSession) (any, error) {
+		return nil, session.DeleteBreakpoint(ferret.DebugBreakpointID(id))
+	})
+}
+
+func (s *Server) handleDebugFrames(w http.ResponseWriter, r *http.Request) {
+	s.inspect(w, r, func(session *hostedSession) (any, error) {
+		frames, err := session.Frames()
+
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]debugFrame, 0, len(frames))
+
+		for _, frame := range frames {
+			result = append(result, debugFrame{Name: frame.Name, Location: newDebugLocation(frame.Location)})
+		}
+
+		return result, nil
+	})
+}
+
+func (s *Server) handleDebugLocals(w http.ResponseWriter, r *http.Request) {
+	frame := 0
+
+	if value := r.URL.Query().Get("frame"); value != "" {
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid frame %q", value))
+			return
+		}
+
+		frame = parsed
+	}
+
+	s.inspect(w, r, func(session *hostedSession) (any, error) {
+		variables, err := session.frameLocals(frame)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]debugVariable, 0, len(variables))
+
+		for _, variable := range variables {
+			result = append(result, debugVariable{
+				Name:  variable.Name,
+				Param: variable.Param,
+				Value: variable.Value.Display,
+			})
+		}
+
+		return result, nil
+	})
+}
+
+func (s *Server) handleDebugEvaluate(w http.ResponseWriter, r *http.Request) {
+	var req debugEvaluation
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid evaluation: %w", err))
+		return
+	}
+
+	s.inspect(w, r, func(session *hostedSession) (any, error) {
+		value, err := session.evaluateInFrame(r.Context(), req.Frame, req.Expression)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return debugEvaluation{Value: value.Display}, nil
+	})
+}
+
+func (s *Server) handleDebugClose(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.session(w, r); !ok {
+		return
+	}
+
+	if err := s.closeSession(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session looks up the session named in the request path and writes a 404
+// when there is none.
+func (s *Server) session(w http.ResponseWriter, r *http.Request) (*hostedSession, bool) {
+	s.debugMu.Lock()
+	session, ok := s.sessions[r.PathValue("id")]
+	s.debugMu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown debug session %q", r.PathValue("id")))
+		return nil, false
+	}
+
+	session.touch()
+
+	return session, true
+}
+
+// inspect runs a call that needs the session paused and writes its result,
+// or a 204 when it has none.
+func (s *Server) inspect(w http.ResponseWriter, r *http.Request, call func(*hostedSession) (any, error)) {
+	session, ok := s.session(w, r)
+
+	if !ok {
+		return
+	}
+
+	if !session.mu.TryLock() {
+		writeError(w, http.StatusConflict, errSessionRunning)
+		return
+	}
+
+	result, err := call(session)
+	session.mu.Unlock()
+
+	switch {
+	case errors.Is(err, ferruntime.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case result == nil:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// closeSession removes a session, releases it, and frees its slot.
+func (s *Server) closeSession(id string) error {
+	s.debugMu.Lock()
+	session, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.debugMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	session.idle.Stop()
+	defer s.releaseSlot()
+
+	return session.Close()
+}
+
+// reserveSlot takes a free slot for a debug session without waiting for one.
+// It always succeeds when the server has no concurrency limit.
+func (s *Server) reserveSlot() bool {
+	if s.slots == nil {
+		return true
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) releaseSlot() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// expireSession closes an idle session unless it is running, in which case
+// it gets another idle period.
+func (s *Server) expireSession(id string) {
+	s.debugMu.Lock()
+	session, ok := s.sessions[id]
+	s.debugMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if !session.mu.TryLock() {
+		session.touch()
+		return
+	}
+
+	session.mu.Unlock()
+	_ = s.closeSession(id)
+}
+
+// Close ends every debug session still open.
+func (s *Server) Close() error {
+	s.debugMu.Lock()
+	ids := make([]string, 0, len(s.sessions))
+
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+
+	s.debugMu.Unlock()
+
+	var err error
+
+	for _, id := range ids {
+		err = errors.Join(err, s.closeSession(id))
+	}
+
+	return err
+}
+
+func (h *hostedSession) touch() {
+	h.idle.Reset(debugIdleTimeout)
+}
+
+func (h *hostedSession) frameLocals(frame int) ([]ferret.DebugVariable, error) {
+	if inspector, ok := h.DebugSession.(frameInspector); ok {
+		return inspector.FrameLocals(frame)
+	}
+
+	if frame != 0 {
+		return nil, errors.New("this session can only inspect the innermost frame")
+	}
+
+	return h.Locals()
+}
+
+func (h *hostedSession) evaluateInFrame(ctx context.Context, frame int, expression string) (ferret.DebugValue, error) {
+	if inspector, ok := h.DebugSession.(frameInspector); ok {
+		return inspector.EvaluateInFrame(ctx, frame, expression)
+	}
+
+	if frame != 0 {
+		return ferret.DebugValue{}, errors.New("this session can only inspect the innermost frame")
+	}
+
+	return h.Evaluate(ctx, expression)
+}
+
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("create session id: %w", err)
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+func newDebugEvent(event *ferret.DebugEvent) debugEvent {
+	if event == nil {
+		return debugEvent{Reason: string(ferret.DebugReasonTerminated)}
+	}
+
+	result := debugEvent{
+		Reason:   string(event.Reason),
+		Location: newDebugLocation(event.Location),
+	}
+
+	for _, id := range event.HitBreakpointIDs {
+		result.HitBreakpointIDs = append(result.HitBreakpointIDs, int(id))
+	}
+
+	if event.Error != nil {
+		result.Error = event.Error.Error()
+	}
+
+	if event.Output != nil {
+		result.Output = &debugOutput{
+			ContentType: event.Output.ContentType,
+			Content:     event.Output.Content,
+		}
+	}
+
+	return result
+}
+
+func newDebugLocation(location ferret.DebugLocation) debugLocation {
+	return debugLocation{
+		File:   location.File,
+		Line:   location.Line,
+		Column: location.Column,
+		Span:   debugSpan{Start: location.Span.Start, End: location.Span.End},
+	}
+}
+
+func bindingMode(mode string) ferret.DebugBreakpointBindingMode {
+	switch mode {
+	case "exact":
+		return ferret.DebugBreakpointBindExact
+	case "in-function":
+		return ferret.DebugBreakpointBindNextExecutableInFunction
+	default:
+		return ferret.DebugBreakpointBindNextExecutableInFile
+	}
+}
+
+func bindingModeName(mode ferret.DebugBreakpointBindingMode) string {
+	switch mode {
+	case ferret.DebugBreakpointBindExact:
+		return "exact"
+	case ferret.DebugBreakpointBindNextExecutableInFunction:
+		return "in-function"
+	default:
+		return "next-file"
+	}
+}
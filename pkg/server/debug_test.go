@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+	ferruntime "github.com/MontFerret/ferret/v2/pkg/runtime"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+func TestServer_HostsRemoteDebugSessions(t *testing.T) {
+	var opened *fakeDebugSession
+
+	server := httptest.NewServer(New(Options{
+		Runtime: &fakeRuntime{},
+		Debug: func(_ context.Context, src *source.Source, params map[string]any) (DebugSession, error) {
+			opened = &fakeDebugSession{src: src, params: params}
+			return opened, nil
+		},
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	session, err := cliruntime.NewRemoteDebugSession(ctx, cliruntime.Options{Type: server.URL}, map[string]any{"limit": 3}, source.New("demo.fql", "LET x = 1\nRETURN x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opened == nil || opened.src.Name() != "demo.fql" || opened.src.Content() != "LET x = 1\nRETURN x" || opened.params["limit"] != float64(3) {
+		t.Fatalf("unexpected session: %#v", opened)
+	}
+
+	breakpoint, err := session.SetBreakpointAt(ferret.DebugSourceLocation{File: "demo.fql", Line: 2}, ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindExact})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if breakpoint.ID != 1 || breakpoint.Line != 2 || !breakpoint.Bound || breakpoint.BindingMode != ferret.DebugBreakpointBindExact {
+		t.Fatalf("unexpected breakpoint: %#v", breakpoint)
+	}
+
+	if event, err := session.Start(ctx); err != nil || event.Reason != ferret.DebugReasonEntry {
+		t.Fatalf("unexpected start: %#v, %v", event, err)
+	}
+
+	event, err := session.Continue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Reason != ferret.DebugReasonBreakpoint || event.Location.Line != 2 || len(event.HitBreakpointIDs) != 1 || event.HitBreakpointIDs[0] != breakpoint.ID {
+		t.Fatalf("unexpected breakpoint event: %#v", event)
+	}
+
+	frames, err := session.Frames()
+	if err != nil || len(frames) != 1 || frames[0].Name != "<main>" {
+		t.Fatalf("unexpected frames: %#v, %v", frames, err)
+	}
+
+	locals, err := session.Locals()
+	if err != nil || len(locals) != 1 || locals[0].Name != "x" || locals[0].Value.Display != "1" {
+		t.Fatalf("unexpected locals: %#v, %v", locals, err)
+	}
+
+	if _, err := session.FrameLocals(1); err == nil || !strings.Contains(err.Error(), "innermost frame") {
+		t.Fatalf("expected frame error, got %v", err)
+	}
+
+	value, err := session.Evaluate(ctx, "x + 1")
+	if err != nil || value.Display != "2" {
+		t.Fatalf("unexpected evaluation: %#v, %v", value, err)
+	}
+
+	if err := session.DeleteBreakpoint(9); !errors.Is(err, ferruntime.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := session.DeleteBreakpoint(breakpoint.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := session.Pause(); err != nil || !opened.paused {
+		t.Fatalf("expected pause to reach the session: %v", err)
+	}
+
+	event, err = session.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Reason != ferret.DebugReasonCompleted || event.Output == nil || string(event.Output.Content) != "1" {
+		t.Fatalf("unexpected completion: %#v", event)
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !opened.closed {
+		t.Fatal("expected the hosted session to be closed")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/debug/sessions/unknown/frames", nil)
+	if status, _ := do(t, req); status != http.StatusNotFound {
+		t.Fatalf("expected unknown session to be not found, got %d", status)
+	}
+}
+
+func TestServer_InspectsOuterFramesOfHostedSessions(t *testing.T) {
+	server := httptest.NewServer(New(Options{
+		Runtime: &fakeRuntime{},
+		Debug: func(_ context.Context, src *source.Source, params map[string]any) (DebugSession, error) {
+			return &frameInspectingSession{fakeDebugSession: fakeDebugSession{src: src, params: params}}, nil
+		},
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	session, err := cliruntime.NewRemoteDebugSession(ctx, cliruntime.Options{Type: server.URL}, nil, source.New("demo.fql", "RETURN 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	locals, err := session.FrameLocals(1)
+	if err != nil || len(locals) != 1 || locals[0].Value.Display != "1" {
+		t.Fatalf("unexpected frame locals: %#v, %v", locals, err)
+	}
+
+	value, err := session.EvaluateInFrame(ctx, 1, "x")
+	if err != nil || value.Display != "x@1" {
+		t.Fatalf("unexpected frame evaluation: %#v, %v", value, err)
+	}
+}
+
+func TestServer_DebugRequiresOptIn(t *testing.T) {
+	server := httptest.NewServer(New(Options{Runtime: &fakeRuntime{}}))
+	defer server.Close()
+
+	_, err := cliruntime.NewRemoteDebugSession(context.Background(), cliruntime.Options{Type: server.URL}, nil, source.NewAnonymous("RETURN 1"))
+	if !errors.Is(err, cliruntime.ErrRemoteDebugUnsupported) {
+		t.Fatalf("expected unsupported error, got %v", err)
+	}
+}
+
+func TestServer_CloseEndsDebugSessions(t *testing.T) {
+	session := &fakeDebugSession{}
+	srv := New(Options{
+		Runtime: &fakeRuntime{},
+		Debug: func(context.Context, *source.Source, map[string]any) (DebugSession, error) {
+			return session, nil
+		},
+	})
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/debug/sessions", strings.NewReader(`{"text":"RETURN 1"}`))
+	if status, body := do(t, req); status != http.StatusOK {
+		t.Fatalf("unexpected response %d: %s", status, body)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !session.closed {
+		t.Fatal("expected server close to end open sessions")
+	}
+}
+
+func TestServer_DebugSessionsTakeConcurrencySlots(t *testing.T) {
+	server := httptest.NewServer(New(Options{
+		Runtime:     &fakeRuntime{},
+		Concurrency: 2,
+		Debug: func(context.Context, *source.Source, map[string]any) (DebugSession, error) {
+			time.Sleep(20 * time.Millisecond)
+			return &fakeDebugSession{}, nil
+		},
+	}))
+	defer server.Close()
+
+	created := make(chan string, 6)
+
+	for range 6 {
+		go func() {
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/debug/sessions", strings.NewReader(`{"text":"RETURN 1"}`))
+			status, body := do(t, req)
+
+			var session debugCreated
+			if status == http.StatusOK && json.Unmarshal([]byte(body), &session) == nil {
+				created <- session.ID
+			} else {
+				created <- ""
+			}
+		}()
+	}
+
+	var ids []string
+
+	for range 6 {
+		if id := <-created; id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 sessions to open, got %d", len(ids))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"text":"RETURN 1"}`))
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected the script to wait for a slot, got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, server.URL+"/debug/sessions/"+ids[0], nil)
+	if status, body := do(t, req); status != http.StatusNoContent {
+		t.Fatalf("unexpected close %d: %s", status, body)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"text":"RETURN 1"}`))
+	if status, body := do(t, req); status != http.StatusOK {
+		t.Fatalf("expected the closed session to free its slot, got %d: %s", status, body)
+	}
+}
+
+// fakeDebugSession pauses at entry, then on line 2, then completes.
+type fakeDebugSession struct {
+	src         *source.Source
+	params      map[string]any
+	breakpoints []ferret.DebugBreakpoint
+	paused      bool
+	closed      bool
+}
+
+func (f *fakeDebugSession) Start(context.Context) (*ferret.DebugEvent, error) {
+	return &ferret.DebugEvent{Reason: ferret.DebugReasonEntry, Location: ferret.DebugLocation{File: "demo.fql", Line: 1}}, nil
+}
+
+func (f *fakeDebugSession) Continue(context.Context) (*ferret.DebugEvent, error) {
+	return &ferret.DebugEvent{
+		Reason:           ferret.DebugReasonBreakpoint,
+		Location:         ferret.DebugLocation{File: "demo.fql", Line: 2},
+		HitBreakpointIDs: []ferret.DebugBreakpointID{1},
+	}, nil
+}
+
+func (f *fakeDebugSession) Step(ctx context.Context) (*ferret.DebugEvent, error) {
+	return f.Next(ctx)
+}
+
+func (f *fakeDebugSession) Next(context.Context) (*ferret.DebugEvent, error) {
+	return &ferret.DebugEvent{
+		Reason: ferret.DebugReasonCompleted,
+		Output: &encoding.Output{ContentType: "application/json", Content: []byte("1")},
+	}, nil
+}
+
+func (f *fakeDebugSession) Out(ctx context.Context) (*ferret.DebugEvent, error) {
+	return f.Next(ctx)
+}
+
+func (f *fakeDebugSession) Pause() error {
+	f.paused = true
+	return nil
+}
+
+func (f *fakeDebugSession) SetBreakpointAt(location ferret.DebugSourceLocation, options ferret.DebugBreakpointOptions) (ferret.DebugBreakpoint, error) {
+	breakpoint := ferret.DebugBreakpoint{
+		ID:            ferret.DebugBreakpointID(len(f.breakpoints) + 1),
+		File:          location.File,
+		RequestedLine: location.Line,
+		Line:          location.Line,
+		BindingMode:   options.BindingMode,
+		Bound:         true,
+	}
+	f.breakpoints = append(f.breakpoints, breakpoint)
+
+	return breakpoint, nil
+}
+
+func (f *fakeDebugSession) DeleteBreakpoint(id ferret.DebugBreakpointID) error {
+	for i, breakpoint := range f.breakpoints {
+		if breakpoint.ID == id {
+			f.breakpoints = append(f.breakpoints[:i], f.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+
+	return ferruntime.Errorf(ferruntime.ErrNotFound, "breakpoint %d", id)
+}
+
+func (f *fakeDebugSession) Frames() ([]ferret.DebugFrame, error) {
+	return []ferret.DebugFrame{{Name: "<main>", Location: ferret.DebugLocation{File: "demo.fql", Line: 2}}}, nil
+}
+
+func (f *fakeDebugSession) Locals() ([]ferret.DebugVariable, error) {
+	return []ferret.DebugVariable{{Name: "x", Value: ferret.DebugValue{Display: "1"}}}, nil
+}
+
+func (f *fakeDebugSession) Evaluate(_ context.Context, expression string) (ferret.DebugValue, error) {
+	if expression != "x + 1" {
+		return ferret.DebugValue{}, errors.New("unsupported expression")
+	}
+
+	return ferret.DebugValue{Display: "2"}, nil
+}
+
+func (f *fakeDebugSession) Close() error {
+	f.closed = true
+	return nil
+}
+
+// frameInspectingSession answers for any frame with its index.
+type frameInspectingSession struct {
+	fakeDebugSession
+}
+
+func (f *frameInspectingSession) FrameLocals(frame int) ([]ferret.DebugVariable, error) {
+	return []ferret.DebugVariable{{Name: "frame", Value: ferret.DebugValue{Display: strconv.Itoa(frame)}}}, nil
+}
+
+func (f *frameInspectingSession) EvaluateInFrame(_ context.Context, frame int, expression string) (ferret.DebugValue, error) {
+	return ferret.DebugValue{Display: expression + "@" + strconv.Itoa(frame)}, nil
+}
@@ -0,0 +1,294 @@
+// Package server exposes a runtime over HTTP with the protocol that the
+// remote runtime client speaks, so that one CLI can execute scripts for
+// another.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+// maxRequestSize bounds the body of a request, script or artifact.
+const maxRequestSize int64 = 32 << 20
+
+// Options configure a Server.
+type Options struct {
+	// Runtime executes the scripts. It must be safe for concurrent use.
+	Runtime cliruntime.Runtime
+	// Version is the version of the worker reported by /info.
+	Version string
+	// Concurrency is the number of scripts executed at once. Further
+	// requests wait for a free slot. Zero or less means no limit.
+	Concurrency int
+	// Limits bound the time and result size of each request. MaxMemory is
+	// not applied: requests share the heap, so the memory limit belongs to
+	// the whole process.
+	Limits limit.Options
+	// Token, when set, must be sent as a bearer token with every request.
+	Token string
+	// Debug, when set, opens the sessions of the /debug/sessions endpoints.
+	// Each open session takes one of the Concurrency slots until it is
+	// closed or expires.
+	Debug DebugSessionFactory
+}
+
+// Server handles the remote runtime protocol:
+//
+//	GET  /info      worker and Ferret versions, protocol version, and
+//	                supported features
+//	POST /          run {text, params}
+//	POST /artifact  run {artifact, params}, with the compiled artifact
+//	                encoded as base64
+//
+// With Options.Debug it also hosts debugger sessions:
+//
+//	POST   /debug/sessions                           open {name, text, params}
+//	POST   /debug/sessions/{id}/{action}             start, continue, step,
+//	                                                 next, or out; returns the
+//	                                                 next pause
+//	POST   /debug/sessions/{id}/pause                interrupt a running resume
+//	POST   /debug/sessions/{id}/breakpoints          set a breakpoint
+//	DELETE /debug/sessions/{id}/breakpoints/{bp}     delete a breakpoint
+//	GET    /debug/sessions/{id}/frames               stack of the paused VM
+//	GET    /debug/sessions/{id}/locals?frame=n       variables of a frame
+//	POST   /debug/sessions/{id}/evaluate             evaluate in a frame
+//	DELETE /debug/sessions/{id}                      close the session
+//
+// Sessions idle for debugIdleTimeout are closed.
+//
+// Results are returned as JSON. Errors use a non-2xx status with
+// {"error": "..."}.
+type Server struct {
+	opts     Options
+	slots    chan struct{}
+	mux      *http.ServeMux
+	debugMu  sync.Mutex
+	sessions map[string]*hostedSession
+}
+
+type (
+	query struct {
+		Text   string         `json:"text"`
+		Params map[string]any `json:"params"`
+	}
+
+	artifactQuery struct {
+		Artifact []byte         `json:"artifact"`
+		Params   map[string]any `json:"params"`
+	}
+
+	info struct {
+		IP       string      `json:"ip"`
+		Version  versionInfo `json:"version"`
+		Protocol int         `json:"protocol"`
+		Features []string    `json:"features"`
+	}
+
+	versionInfo struct {
+		Worker string `json:"worker"`
+		Ferret string `json:"ferret"`
+	}
+
+	errorBody struct {
+		Error string `json:"error"`
+	}
+)
+
+func New(opts Options) *Server {
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+
+	if opts.Concurrency > 0 {
+		s.slots = make(chan struct{}, opts.Concurrency)
+	}
+
+	s.mux.HandleFunc("GET /info", s.handleInfo)
+	s.mux.HandleFunc("POST /{$}", s.handleRun)
+	s.mux.HandleFunc("POST /artifact", s.handleArtifact)
+
+	if opts.Debug != nil {
+		s.sessions = make(map[string]*hostedSession)
+		s.mux.HandleFunc("POST /debug/sessions", s.handleDebugCreate)
+		s.mux.HandleFunc("POST /debug/sessions/{id}/{action}", s.handleDebugResume)
+		s.mux.HandleFunc("POST /debug/sessions/{id}/pause", s.handleDebugPause)
+		s.mux.HandleFunc("POST /debug/sessions/{id}/breakpoints", s.handleDebugSetBreakpoint)
+		s.mux.HandleFunc("DELETE /debug/sessions/{id}/breakpoints/{breakpoint}", s.handleDebugDeleteBreakpoint)
+		s.mux.HandleFunc("GET /debug/sessions/{id}/frames", s.handleDebugFrames)
+		s.mux.HandleFunc("GET /debug/sessions/{id}/locals", s.handleDebugLocals)
+		s.mux.HandleFunc("POST /debug/sessions/{id}/evaluate", s.handleDebugEvaluate)
+		s.mux.HandleFunc("DELETE /debug/sessions/{id}", s.handleDebugClose)
+	}
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ferret"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.opts.Token == "" {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	ferretVersion, err := s.opts.Runtime.Version(r.Context())
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	features := []string{cliruntime.RemoteFeatureArtifact}
+
+	if s.opts.Debug != nil {
+		features = append(features, cliruntime.RemoteFeatureDebug)
+	}
+
+	writeJSON(w, http.StatusOK, info{
+		IP:       localIP(r),
+		Version:  versionInfo{Worker: s.opts.Version, Ferret: ferretVersion},
+		Protocol: cliruntime.RemoteProtocolVersion,
+		Features: features,
+	})
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	var q query
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+
+	if err == nil {
+		err = json.Unmarshal(body, &q)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid query: %w", err))
+		return
+	}
+
+	s.execute(w, r, func(ctx context.Context) (io.ReadCloser, error) {
+		return s.opts.Runtime.Run(ctx, source.NewAnonymous(q.Text), q.Params)
+	})
+}
+
+func (s *Server) handleArtifact(w http.ResponseWriter, r *http.Request) {
+	var q artifactQuery
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+
+	if err == nil {
+		err = json.Unmarshal(body, &q)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid artifact request: %w", err))
+		return
+	}
+
+	if len(q.Artifact) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("artifact is empty"))
+		return
+	}
+
+	s.execute(w, r, func(ctx context.Context) (io.ReadCloser, error) {
+		return s.opts.Runtime.RunArtifact(ctx, q.Artifact, q.Params)
+	})
+}
+
+// execute runs the request once a slot is free and writes its result.
+func (s *Server) execute(w http.ResponseWriter, r *http.Request, run func(context.Context) (io.ReadCloser, error)) {
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-r.Context().Done():
+			writeError(w, http.StatusServiceUnavailable, r.Context().Err())
+			return
+		}
+	}
+
+	ctx, cancel := s.opts.Limits.WithTimeout(r.Context())
+	defer cancel()
+
+	out, err := limit.Run(ctx, run)
+
+	var result []byte
+
+	if err == nil {
+		result, err = io.ReadAll(s.opts.Limits.Reader(out))
+		err = limit.Err(ctx, errors.Join(err, out.Close()))
+	}
+
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(result)
+}
+
+// statusOf maps an execution error to a response status.
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, limit.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, limit.ErrOutputSize):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+// localIP returns the address the request was received on.
+func localIP(r *http.Request) string {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+
+	if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
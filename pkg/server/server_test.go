@@ -0,0 +1,269 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/ferret/v2/pkg/source"
+
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+func TestServer_RunsQueriesFromRemoteRuntime(t *testing.T) {
+	rt := &fakeRuntime{}
+	server := httptest.NewServer(New(Options{Runtime: rt, Version: "1.2.3"}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	remote, err := cliruntime.NewRemote(*u, cliruntime.NewDefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := remote.Version(context.Background())
+	if err != nil || version != "fake" {
+		t.Fatalf("unexpected version %q, %v", version, err)
+	}
+
+	out, err := remote.Run(context.Background(), source.NewAnonymous("RETURN @name"), map[string]any{"name": "ferret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Close()
+
+	result, _ := io.ReadAll(out)
+	if string(result) != `{"params":{"name":"ferret"},"query":"RETURN @name"}` {
+		t.Fatalf("unexpected result: %s", result)
+	}
+}
+
+func TestServer_RunsArtifacts(t *testing.T) {
+	server := httptest.NewServer(New(Options{Runtime: &fakeRuntime{}}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/artifact", strings.NewReader(`{"artifact":"Y29tcGlsZWQ=","params":{"limit":3}}`))
+
+	status, body := do(t, req)
+	if status != http.StatusOK || body != `{"artifact":"compiled","params":{"limit":3}}` {
+		t.Fatalf("unexpected response %d: %s", status, body)
+	}
+
+	u, _ := url.Parse(server.URL)
+	remote, err := cliruntime.NewRemote(*u, cliruntime.NewDefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := remote.RunArtifact(context.Background(), []byte("compiled"), map[string]any{"limit": 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer out.Close()
+
+	if result, _ := io.ReadAll(out); string(result) != body {
+		t.Fatalf("unexpected remote result: %s", result)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/artifact", strings.NewReader(`{"artifact":"Y29tcGlsZWQ=","params":[1]}`))
+
+	if status, body := do(t, req); status != http.StatusBadRequest || !strings.Contains(body, "invalid artifact request") {
+		t.Fatalf("expected bad params error, got %d: %s", status, body)
+	}
+
+	// Params too large for any header travel in the body.
+	large := strings.Repeat("x", 64<<10)
+
+	out, err = remote.RunArtifact(context.Background(), []byte("compiled"), map[string]any{"large": large})
+	if err != nil {
+		t.Fatalf("unexpected error with large params: %v", err)
+	}
+	defer out.Close()
+
+	if result, _ := io.ReadAll(out); !strings.Contains(string(result), large) {
+		t.Fatal("expected the large params to reach the runtime")
+	}
+}
+
+func TestServer_LeavesMemoryLimitToTheProcess(t *testing.T) {
+	server := httptest.NewServer(New(Options{
+		Runtime: &fakeRuntime{delay: 300 * time.Millisecond},
+		Limits:  limit.Options{MaxMemory: 1},
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"text":"RETURN 1"}`))
+
+	if status, body := do(t, req); status != http.StatusOK {
+		t.Fatalf("expected the request to outlive the heap sampling, got %d: %s", status, body)
+	}
+}
+
+func TestServer_ReportsErrorsAsJSON(t *testing.T) {
+	server := httptest.NewServer(New(Options{
+		Runtime: &fakeRuntime{},
+		Limits:  limit.Options{Timeout: 50 * time.Millisecond},
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		err    string
+	}{
+		{name: "invalid query", body: `{`, status: http.StatusBadRequest, err: "invalid query"},
+		{name: "script error", body: `{"text":"fail"}`, status: http.StatusUnprocessableEntity, err: "script failed"},
+		{name: "timeout", body: `{"text":"slow"}`, status: http.StatusGatewayTimeout, err: "time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(tt.body))
+			status, body := do(t, req)
+
+			var res errorBody
+			if err := json.Unmarshal([]byte(body), &res); err != nil {
+				t.Fatalf("expected JSON error body, got %q", body)
+			}
+
+			if status != tt.status || !strings.Contains(res.Error, tt.err) {
+				t.Fatalf("expected %d with %q, got %d: %s", tt.status, tt.err, status, body)
+			}
+		})
+	}
+}
+
+func TestServer_RequiresBearerToken(t *testing.T) {
+	server := httptest.NewServer(New(Options{Runtime: &fakeRuntime{}, Token: "secret"}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/info", nil)
+	if status, _ := do(t, req); status != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized, got %d", status)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/info", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if status, body := do(t, req); status != http.StatusOK {
+		t.Fatalf("expected success, got %d: %s", status, body)
+	}
+
+	u, _ := url.Parse(server.URL)
+	opts := cliruntime.NewDefaultOptions()
+	opts.Remote.Token = "wrong"
+
+	remote, err := cliruntime.NewRemote(*u, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var remoteErr *cliruntime.RemoteError
+
+	if _, err := remote.Version(context.Background()); !errors.As(err, &remoteErr) || remoteErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized remote error, got %v", err)
+	}
+}
+
+func TestServer_LimitsConcurrency(t *testing.T) {
+	rt := &fakeRuntime{delay: 20 * time.Millisecond}
+	server := httptest.NewServer(New(Options{Runtime: rt, Concurrency: 2}))
+	defer server.Close()
+
+	done := make(chan struct{})
+
+	for range 6 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"text":"RETURN 1"}`))
+			do(t, req)
+		}()
+	}
+
+	for range 6 {
+		<-done
+	}
+
+	if peak := rt.peak.Load(); peak > 2 {
+		t.Fatalf("expected at most 2 scripts at once, got %d", peak)
+	}
+}
+
+func do(t *testing.T, req *http.Request) (int, string) {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return 0, ""
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	return resp.StatusCode, strings.TrimSpace(string(body))
+}
+
+// fakeRuntime echoes what it was asked to run. The query "fail" fails and
+// "slow" runs until cancelled.
+type fakeRuntime struct {
+	delay   time.Duration
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (rt *fakeRuntime) Version(context.Context) (string, error) {
+	return "fake", nil
+}
+
+func (rt *fakeRuntime) Run(ctx context.Context, query *source.Source, params map[string]any) (io.ReadCloser, error) {
+	return rt.run(ctx, map[string]any{"query": query.Content(), "params": params}, query.Content())
+}
+
+func (rt *fakeRuntime) RunArtifact(ctx context.Context, data []byte, params map[string]any) (io.ReadCloser, error) {
+	return rt.run(ctx, map[string]any{"artifact": string(data), "params": params}, "")
+}
+
+func (rt *fakeRuntime) run(ctx context.Context, echo map[string]any, text string) (io.ReadCloser, error) {
+	running := rt.running.Add(1)
+	defer rt.running.Add(-1)
+
+	for {
+		peak := rt.peak.Load()
+		if running <= peak || rt.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	switch text {
+	case "fail":
+		return nil, errors.New("script failed")
+	case "slow":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	time.Sleep(rt.delay)
+
+	data, err := json.Marshal(echo)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (rt *fakeRuntime) Close() error {
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/MontFerret/ferret/v2/pkg/source"
 )
@@ -12,6 +14,9 @@ import (
 type Input struct {
 	Eval string
 	Args []string
+	// Recursive searches directories in Args for .fql files instead of
+	// failing on them.
+	Recursive bool
 }
 
 // Resolve returns file sources from eval, stdin, or file paths.
@@ -40,14 +45,72 @@ func Resolve(input Input) ([]*source.Source, error) {
 	sources := make([]*source.Source, 0, len(input.Args))
 
 	for _, path := range input.Args {
-		content, err := os.ReadFile(path)
+		if input.Recursive {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				found, err := resolveDir(path)
 
+				if err != nil {
+					return nil, err
+				}
+
+				sources = append(sources, found...)
+
+				continue
+			}
+		}
+
+		src, err := resolveFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, src)
+	}
+
+	return sources, nil
+}
+
+func resolveDir(dir string) ([]*source.Source, error) {
+	var sources []*source.Source
+
+	err := WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
-			return nil, fmt.Errorf("reading %s: %w", path, err)
+			return err
 		}
 
-		sources = append(sources, source.New(path, string(content)))
+		if entry.IsDir() || filepath.Ext(path) != ".fql" {
+			return nil
+		}
+
+		src, err := resolveFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		sources = append(sources, src)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no .fql scripts found in %s", dir)
 	}
 
 	return sources, nil
 }
+
+func resolveFile(path string) (*source.Source, error) {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return source.New(path, string(content)), nil
+}
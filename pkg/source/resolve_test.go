@@ -107,3 +107,38 @@ func TestResolve_NoInput(t *testing.T) {
 	// In test context, stdin behavior varies - just verify no crash
 	_ = sources
 }
+
+func TestResolve_RecursiveDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"b.fql":        "RETURN 2",
+		"nested/a.fql": "RETURN 1",
+		"notes.txt":    "not a script",
+		".git/x.fql":   "RETURN 3",
+	} {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources, err := source.Resolve(source.Input{Args: []string{dir}, Recursive: true})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sources) != 2 || sources[0].Name() != filepath.Join(dir, "b.fql") || sources[1].Name() != filepath.Join(dir, "nested", "a.fql") {
+		t.Fatalf("unexpected sources: %v", sources)
+	}
+
+	if _, err := source.Resolve(source.Input{Args: []string{dir}}); err == nil {
+		t.Fatal("expected directories to be rejected unless recursive")
+	}
+}
@@ -0,0 +1,27 @@
+package source
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// WalkDir walks the file tree rooted at root like filepath.WalkDir, without
+// descending into hidden directories below root, such as .git. Scripts, tests,
+// and watched files are all found with it, so that they skip the same
+// directories.
+func WalkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() && path != root && Hidden(entry.Name()) {
+			return filepath.SkipDir
+		}
+
+		return fn(path, entry, err)
+	})
+}
+
+// Hidden reports whether name is a dotfile, such as the swap files of
+// editors or a .git directory.
+func Hidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,132 @@
+package testrun
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MontFerret/cli/v2/pkg/source"
+)
+
+const (
+	// TestSuffix marks a script that is a test on its own, with or without a
+	// snapshot.
+	TestSuffix = "_test.fql"
+	// ExpectedSuffix names the snapshot of script.fql: script.expected.json.
+	ExpectedSuffix = ".expected.json"
+)
+
+// paramsSuffixes name the per-test params of script.fql, such as
+// script.params.json, in the order they are looked up.
+var paramsSuffixes = []string{".params.json", ".params.yaml", ".params.yml", ".params.toml"}
+
+// Case is a script to run and the files that go with it.
+type Case struct {
+	Script string
+	// Expected is the snapshot file of the script. It may not exist yet for
+	// a *_test.fql script.
+	Expected string
+	// Params is the file of params for this test, or empty when there is none.
+	Params string
+	// Name is the script path relative to the directory it was found in, or
+	// its file name when it was given directly. Files kept per test, such as
+	// HTTP recordings, are keyed by it.
+	Name string
+}
+
+// Discover finds the tests in paths. Directories are searched recursively
+// for *_test.fql scripts and for scripts with a .expected.json snapshot.
+// Files name a test directly. Cases are sorted by script path.
+func Discover(paths []string) ([]Case, error) {
+	var cases []Case
+	seen := make(map[string]bool)
+
+	add := func(script, name string) {
+		if !seen[script] {
+			seen[script] = true
+			cases = append(cases, newCase(script, name))
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			if filepath.Ext(path) != ".fql" {
+				return nil, fmt.Errorf("%s is not a .fql script", path)
+			}
+
+			add(path, filepath.Base(path))
+
+			continue
+		}
+
+		err = source.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && isTest(file) {
+				name, err := filepath.Rel(path, file)
+
+				if err != nil {
+					return err
+				}
+
+				add(file, name)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].Script < cases[j].Script
+	})
+
+	return cases, nil
+}
+
+// isTest reports whether a file found in a directory is a test.
+func isTest(path string) bool {
+	if strings.HasSuffix(path, TestSuffix) {
+		return true
+	}
+
+	if filepath.Ext(path) != ".fql" {
+		return false
+	}
+
+	_, err := os.Stat(expectedPath(path))
+
+	return err == nil
+}
+
+func newCase(script, name string) Case {
+	c := Case{Script: script, Expected: expectedPath(script), Name: name}
+	base := strings.TrimSuffix(script, ".fql")
+
+	for _, suffix := range paramsSuffixes {
+		if _, err := os.Stat(base + suffix); err == nil {
+			c.Params = base + suffix
+			break
+		}
+	}
+
+	return c
+}
+
+func expectedPath(script string) string {
+	return strings.TrimSuffix(script, ".fql") + ExpectedSuffix
+}
@@ -0,0 +1,124 @@
+package testrun
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes results as a JUnit XML report, which CI systems show
+// per test. Tests are grouped in one suite per directory.
+func WriteJUnit(w io.Writer, results []Result) error {
+	report := junitSuites{}
+	suites := make(map[string]int)
+	var elapsed []time.Duration
+	var total time.Duration
+
+	for _, res := range results {
+		dir := filepath.ToSlash(filepath.Dir(res.Case.Script))
+		i, ok := suites[dir]
+
+		if !ok {
+			i = len(report.Suites)
+			suites[dir] = i
+			report.Suites = append(report.Suites, junitSuite{Name: dir})
+			elapsed = append(elapsed, 0)
+		}
+
+		suite := &report.Suites[i]
+		tc := junitCase{
+			Name:      filepath.Base(res.Case.Script),
+			ClassName: dir,
+			Time:      seconds(res.Duration),
+		}
+
+		switch res.Status {
+		case StatusFailed:
+			tc.Failure = problem(res)
+			suite.Failures++
+		case StatusError:
+			tc.Error = problem(res)
+			suite.Errors++
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		elapsed[i] += res.Duration
+		total += res.Duration
+	}
+
+	for i := range report.Suites {
+		suite := &report.Suites[i]
+		suite.Time = seconds(elapsed[i])
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func problem(res Result) *junitProblem {
+	p := &junitProblem{}
+
+	if res.Err != nil {
+		p.Message = res.Err.Error()
+	}
+
+	p.Text = strings.TrimSpace(strings.Join([]string{p.Message, res.Diff}, "\n"))
+
+	return p
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
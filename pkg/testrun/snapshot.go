@@ -0,0 +1,114 @@
+package testrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/MontFerret/cli/v2/pkg/output"
+)
+
+// Normalize rewrites a JSON result as indented JSON with sorted object keys,
+// the form snapshots are stored in, so that rewriting a snapshot only
+// changes the lines whose values changed.
+func Normalize(result []byte) ([]byte, error) {
+	value, err := decode(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// Equal reports whether two JSON documents hold the same value. Object key
+// order and formatting are ignored, and numbers are compared by value.
+func Equal(expected, actual []byte) (bool, error) {
+	want, err := decode(expected)
+
+	if err != nil {
+		return false, fmt.Errorf("snapshot: %w", err)
+	}
+
+	got, err := decode(actual)
+
+	if err != nil {
+		return false, fmt.Errorf("result: %w", err)
+	}
+
+	return reflect.DeepEqual(want, got), nil
+}
+
+// Diff returns a unified diff from the expected to the actual result, both
+// normalized so that only differing values show up.
+func Diff(expected, actual []byte) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(diffText(expected)),
+		B:        difflib.SplitLines(diffText(actual)),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  3,
+	})
+
+	return diff
+}
+
+// WriteSnapshot stores result, normalized, as the snapshot of c.
+func WriteSnapshot(c Case, result []byte) error {
+	data, err := Normalize(result)
+
+	if err != nil {
+		return fmt.Errorf("result: %w", err)
+	}
+
+	return output.WriteFile(c.Expected, func(w io.Writer) error {
+		_, err := w.Write(data)
+
+		return err
+	})
+}
+
+// readSnapshot returns the snapshot of c, or nil when it has none.
+func readSnapshot(c Case) ([]byte, error) {
+	data, err := os.ReadFile(c.Expected)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	return data, nil
+}
+
+func decode(data []byte) (any, error) {
+	var value any
+
+	if err := json.Unmarshal(bytes.TrimSpace(data), &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	return value, nil
+}
+
+func diffText(data []byte) string {
+	if normalized, err := Normalize(data); err == nil {
+		return string(normalized)
+	}
+
+	return string(data)
+}
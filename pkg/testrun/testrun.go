@@ -0,0 +1,128 @@
+package testrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MontFerret/cli/v2/pkg/limit"
+	clirun "github.com/MontFerret/cli/v2/pkg/run"
+	cliruntime "github.com/MontFerret/cli/v2/pkg/runtime"
+)
+
+// Status is the outcome of a test.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusUpdated Status = "updated"
+	// StatusError means the script did not produce a result to compare.
+	StatusError Status = "error"
+)
+
+// Result is the outcome of one test.
+type Result struct {
+	Case     Case
+	Status   Status
+	Duration time.Duration
+	// Diff is a unified diff from the snapshot to the result of a failed
+	// comparison.
+	Diff string
+	// Err explains a failure or an error.
+	Err error
+}
+
+// Run executes the script of c with params and checks its result. Limits
+// apply as for a single run.
+func Run(ctx context.Context, opts cliruntime.Options, params map[string]any, c Case, limits limit.Options, update bool) Result {
+	started := time.Now()
+	result, err := execute(ctx, opts, params, c, limits)
+
+	if err != nil {
+		return Result{Case: c, Status: StatusError, Duration: time.Since(started), Err: err}
+	}
+
+	res := Check(c, result, update)
+	res.Duration = time.Since(started)
+
+	return res
+}
+
+// Check compares result with the snapshot of c. With update, the snapshot is
+// rewritten instead when it is missing or differs. A *_test.fql script
+// without a snapshot passes unless its result is false, so a script can
+// assert on its own.
+func Check(c Case, result []byte, update bool) Result {
+	res := Result{Case: c}
+	expected, err := readSnapshot(c)
+
+	if err != nil {
+		res.Status, res.Err = StatusError, err
+
+		return res
+	}
+
+	equal := false
+
+	if expected != nil {
+		if equal, err = Equal(expected, result); err != nil {
+			res.Status, res.Err = StatusError, err
+
+			return res
+		}
+	}
+
+	switch {
+	case equal:
+		res.Status = StatusPassed
+	case update:
+		if err := WriteSnapshot(c, result); err != nil {
+			res.Status, res.Err = StatusError, err
+		} else {
+			res.Status = StatusUpdated
+		}
+	case expected != nil:
+		res.Status = StatusFailed
+		res.Diff = Diff(expected, result)
+		res.Err = fmt.Errorf("result does not match %s", c.Expected)
+	case !strings.HasSuffix(c.Script, TestSuffix):
+		res.Status = StatusFailed
+		res.Err = fmt.Errorf("no snapshot %s; run with --update to create it", c.Expected)
+	case bytes.Equal(bytes.TrimSpace(result), []byte("false")):
+		res.Status = StatusFailed
+		res.Err = fmt.Errorf("script returned false")
+	default:
+		res.Status = StatusPassed
+	}
+
+	return res
+}
+
+func execute(ctx context.Context, opts cliruntime.Options, params map[string]any, c Case, limits limit.Options) ([]byte, error) {
+	input, err := clirun.ResolveInput("", []string{c.Script})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := limits.WithTimeout(ctx)
+	defer cancel()
+
+	out, err := limit.Run(ctx, func(ctx context.Context) (io.ReadCloser, error) {
+		return clirun.Execute(ctx, opts, params, input)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer out.Close()
+
+	result, err := io.ReadAll(limits.Reader(out))
+
+	return result, limit.Err(ctx, err)
+}
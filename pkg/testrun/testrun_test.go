@@ -0,0 +1,200 @@
+package testrun
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscover_FindsTestsAndSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "login_test.fql"), "RETURN true")
+	writeFile(t, filepath.Join(dir, "login_test.params.yaml"), "user: alice")
+	writeFile(t, filepath.Join(dir, "scrape.fql"), "RETURN 1")
+	writeFile(t, filepath.Join(dir, "scrape.expected.json"), "1")
+	writeFile(t, filepath.Join(dir, "helper.fql"), "RETURN 2")
+	writeFile(t, filepath.Join(dir, "nested", "deep_test.fql"), "RETURN 3")
+	writeFile(t, filepath.Join(dir, ".hidden", "skipped_test.fql"), "RETURN 4")
+
+	cases, err := Discover([]string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Case{
+		{
+			Script:   filepath.Join(dir, "login_test.fql"),
+			Expected: filepath.Join(dir, "login_test.expected.json"),
+			Params:   filepath.Join(dir, "login_test.params.yaml"),
+			Name:     "login_test.fql",
+		},
+		{
+			Script:   filepath.Join(dir, "nested", "deep_test.fql"),
+			Expected: filepath.Join(dir, "nested", "deep_test.expected.json"),
+			Name:     filepath.Join("nested", "deep_test.fql"),
+		},
+		{
+			Script:   filepath.Join(dir, "scrape.fql"),
+			Expected: filepath.Join(dir, "scrape.expected.json"),
+			Name:     "scrape.fql",
+		},
+	}
+
+	if !reflect.DeepEqual(cases, want) {
+		t.Fatalf("expected cases %#v, got %#v", want, cases)
+	}
+}
+
+func TestDiscover_AcceptsScriptFiles(t *testing.T) {
+	dir := t.TempDir()
+	script := writeFile(t, filepath.Join(dir, "helper.fql"), "RETURN 2")
+	other := writeFile(t, filepath.Join(dir, "notes.txt"), "")
+
+	cases, err := Discover([]string{script})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cases) != 1 || cases[0].Script != script {
+		t.Fatalf("expected %s, got %#v", script, cases)
+	}
+
+	if _, err := Discover([]string{other}); err == nil || !strings.Contains(err.Error(), "not a .fql script") {
+		t.Fatalf("expected non-script error, got %v", err)
+	}
+}
+
+func TestEqual_ComparesStructurally(t *testing.T) {
+	equal, err := Equal([]byte(`{"b": [1, 2], "a": 1.0}`), []byte(`{"a":1,"b":[1,2]}`))
+	if err != nil || !equal {
+		t.Fatalf("expected equal documents, got %v, %v", equal, err)
+	}
+
+	equal, err = Equal([]byte(`[1, 2]`), []byte(`[2, 1]`))
+	if err != nil || equal {
+		t.Fatalf("expected order of arrays to matter, got %v, %v", equal, err)
+	}
+
+	if _, err := Equal([]byte(`{`), []byte(`1`)); err == nil || !strings.Contains(err.Error(), "snapshot") {
+		t.Fatalf("expected invalid snapshot error, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := newCase(writeFile(t, filepath.Join(dir, "scrape.fql"), ""), "scrape.fql")
+	writeFile(t, snapshot.Expected, `{"title": "Home", "links": 3}`)
+	assertion := newCase(writeFile(t, filepath.Join(dir, "check_test.fql"), ""), "check_test.fql")
+
+	tests := []struct {
+		name   string
+		c      Case
+		result string
+		status Status
+	}{
+		{name: "matching snapshot", c: snapshot, result: `{"links":3,"title":"Home"}`, status: StatusPassed},
+		{name: "differing snapshot", c: snapshot, result: `{"links":4,"title":"Home"}`, status: StatusFailed},
+		{name: "invalid result", c: snapshot, result: `not json`, status: StatusError},
+		{name: "assertion passes", c: assertion, result: `true`, status: StatusPassed},
+		{name: "assertion fails", c: assertion, result: `false`, status: StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Check(tt.c, []byte(tt.result), false)
+
+			if res.Status != tt.status {
+				t.Fatalf("expected %s, got %s (%v)", tt.status, res.Status, res.Err)
+			}
+		})
+	}
+
+	res := Check(snapshot, []byte(`{"links":4,"title":"Home"}`), false)
+	if !strings.Contains(res.Diff, `-  "links": 3,`) || !strings.Contains(res.Diff, `+  "links": 4,`) {
+		t.Fatalf("expected diff of the changed field, got:\n%s", res.Diff)
+	}
+
+	missing := newCase(writeFile(t, filepath.Join(dir, "new.fql"), ""), "new.fql")
+	if res := Check(missing, []byte(`1`), false); res.Status != StatusFailed || !strings.Contains(res.Err.Error(), "--update") {
+		t.Fatalf("expected missing snapshot failure, got %s (%v)", res.Status, res.Err)
+	}
+}
+
+func TestCheck_UpdateRewritesSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	c := newCase(writeFile(t, filepath.Join(dir, "scrape.fql"), ""), "scrape.fql")
+
+	if res := Check(c, []byte(`{"b":2,"a":[1]}`), true); res.Status != StatusUpdated {
+		t.Fatalf("expected updated, got %s (%v)", res.Status, res.Err)
+	}
+
+	data, err := os.ReadFile(c.Expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\n  \"a\": [\n    1\n  ],\n  \"b\": 2\n}\n"
+	if string(data) != want {
+		t.Fatalf("expected normalized snapshot %q, got %q", want, data)
+	}
+
+	if res := Check(c, []byte(`{"a":[1],"b":2}`), true); res.Status != StatusPassed {
+		t.Fatalf("expected unchanged snapshot to pass, got %s", res.Status)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	results := []Result{
+		{Case: Case{Script: "tests/a_test.fql"}, Status: StatusPassed, Duration: 1500 * time.Millisecond},
+		{Case: Case{Script: "tests/b.fql"}, Status: StatusFailed, Diff: "-1\n+2\n", Err: errors.New("result does not match")},
+		{Case: Case{Script: "other/c_test.fql"}, Status: StatusError, Err: errors.New("boom")},
+	}
+
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report junitSuites
+	if err := xml.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, out.String())
+	}
+
+	if report.Tests != 3 || report.Failures != 1 || report.Errors != 1 || len(report.Suites) != 2 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+
+	suite := report.Suites[0]
+	if suite.Name != "tests" || suite.Time != "1.500" || len(suite.Cases) != 2 {
+		t.Fatalf("unexpected suite: %+v", suite)
+	}
+
+	failure := suite.Cases[1].Failure
+	if failure == nil || failure.Message != "result does not match" || !strings.Contains(failure.Text, "+2") {
+		t.Fatalf("unexpected failure: %+v", failure)
+	}
+
+	if report.Suites[1].Cases[0].Error == nil {
+		t.Fatalf("expected error in %+v", report.Suites[1])
+	}
+}
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
@@ -0,0 +1,112 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+
+	"github.com/MontFerret/cli/v2/pkg/debugger"
+)
+
+// Record runs the program by stepping through session until it completes or
+// terminates, writing the location, stack, and locals of every step to w as
+// it goes, then finishes w. It returns the final event, which carries the
+// program output or error. When the session fails, the steps recorded so far
+// are kept and the trace ends as terminated. Failures to write the trace are
+// returned wrapped with "write trace". The session is not closed.
+func Record(ctx context.Context, session debugger.Session, w *Writer) (*ferret.DebugEvent, error) {
+	var previous []Frame
+
+	event, err := session.Start(ctx)
+
+	for {
+		if err != nil {
+			return nil, errors.Join(err, finish(w, ferret.DebugReasonTerminated, nil, err))
+		}
+
+		if event == nil {
+			return nil, finish(w, ferret.DebugReasonTerminated, nil, nil)
+		}
+
+		switch event.Reason {
+		case ferret.DebugReasonCompleted, ferret.DebugReasonTerminated:
+			if err := finish(w, event.Reason, event.Output, event.Error); err != nil {
+				return nil, err
+			}
+
+			return event, nil
+		}
+
+		step, captureErr := captureStep(session, event, previous)
+		if captureErr != nil {
+			return nil, errors.Join(captureErr, finish(w, ferret.DebugReasonTerminated, nil, captureErr))
+		}
+
+		if writeErr := w.WriteStep(step); writeErr != nil {
+			return nil, errors.Join(fmt.Errorf("write trace: %w", writeErr), finish(w, ferret.DebugReasonTerminated, nil, nil))
+		}
+
+		previous = step.Frames
+
+		event, err = session.Step(ctx)
+	}
+}
+
+func finish(w *Writer, reason ferret.DebugReason, output *encoding.Output, cause error) error {
+	var errText string
+	if cause != nil {
+		errText = cause.Error()
+	}
+
+	if err := w.Finish(reason, output, errText); err != nil {
+		return fmt.Errorf("write trace: %w", err)
+	}
+
+	return nil
+}
+
+func captureStep(session debugger.Session, event *ferret.DebugEvent, previous []Frame) (Step, error) {
+	step := Step{Reason: event.Reason, Location: newLocation(event.Location)}
+
+	if event.Error != nil {
+		step.Error = event.Error.Error()
+	}
+
+	frames, err := session.Frames()
+	if err != nil {
+		return step, err
+	}
+
+	for _, frame := range frames {
+		step.Frames = append(step.Frames, Frame{Name: frame.Name, Location: newLocation(frame.Location)})
+	}
+
+	locals, err := session.Locals()
+	if err != nil {
+		return step, err
+	}
+
+	for _, variable := range locals {
+		step.Locals = append(step.Locals, Variable{Name: variable.Name, Value: variable.Value.Display, Param: variable.Param})
+	}
+
+	switch {
+	case len(step.Frames) > len(previous) && len(previous) > 0:
+		call := &Call{Function: step.Frames[0].Name}
+
+		for _, variable := range step.Locals {
+			if !variable.Param {
+				call.EntryLocals = append(call.EntryLocals, variable)
+			}
+		}
+
+		step.Call = call
+	case len(step.Frames) < len(previous):
+		step.Return = &Return{Function: previous[0].Name}
+	}
+
+	return step, nil
+}
@@ -0,0 +1,247 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+const demoScript = "FUNC double(x) (\n  RETURN x * 2\n)\nLET y = 21\nRETURN double(y)"
+
+func TestRecordCapturesStepsCallsAndResult(t *testing.T) {
+	session := newRecordingSession()
+	path := filepath.Join(t.TempDir(), "trace.json")
+
+	writer, err := Create(path, source.New("demo.fql", demoScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := Record(context.Background(), session, writer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event == nil || event.Reason != ferret.DebugReasonCompleted {
+		t.Fatalf("unexpected final event: %#v", event)
+	}
+
+	recorded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded.Version != Version || recorded.Name != "demo.fql" || recorded.Source != demoScript {
+		t.Fatalf("unexpected trace header: %#v", recorded)
+	}
+	if recorded.Reason != ferret.DebugReasonCompleted || string(recorded.Result) != "42" {
+		t.Fatalf("unexpected trace result: %#v", recorded)
+	}
+	if len(recorded.Steps) != 4 {
+		t.Fatalf("expected 4 steps, got %#v", recorded.Steps)
+	}
+
+	call := recorded.Steps[2].Call
+	if call == nil || call.Function != "double" || !reflect.DeepEqual(call.EntryLocals, []Variable{{Name: "x", Value: "21"}}) {
+		t.Fatalf("unexpected call: %#v", call)
+	}
+
+	ret := recorded.Steps[3].Return
+	if ret == nil || ret.Function != "double" {
+		t.Fatalf("unexpected return: %#v", ret)
+	}
+	if len(session.expressions) != 0 {
+		t.Fatalf("expected recording not to evaluate expressions, got %q", session.expressions)
+	}
+
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("expected only the trace file to remain, got %v", entries)
+	}
+}
+
+func TestRecordReportsTraceWriteErrors(t *testing.T) {
+	writer, err := NewWriter(failingWriter{}, source.New("demo.fql", demoScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := Record(context.Background(), newRecordingSession(), writer)
+	if err == nil || !strings.Contains(err.Error(), "write trace: disk full") {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if event != nil {
+		t.Fatalf("expected no final event, got %#v", event)
+	}
+}
+
+func TestRecordKeepsStepsWhenSessionFails(t *testing.T) {
+	session := newRecordingSession()
+	session.stepErr = errors.New("connection reset")
+
+	var out bytes.Buffer
+
+	writer, err := NewWriter(&out, source.New("demo.fql", demoScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Record(context.Background(), session, writer)
+	if err == nil || err.Error() != "connection reset" {
+		t.Fatalf("expected step error, got %v", err)
+	}
+
+	recorded, err := Read(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded.Steps) != 1 || recorded.Reason != ferret.DebugReasonTerminated || recorded.Error != "connection reset" {
+		t.Fatalf("expected partial trace, got %#v", recorded)
+	}
+}
+
+func TestOutputKeepsTextContent(t *testing.T) {
+	var recorded Trace
+	recorded.setOutput(&encoding.Output{ContentType: "text/plain", Content: []byte("hello")})
+
+	if string(recorded.Result) != `"hello"` {
+		t.Fatalf("unexpected stored result: %s", recorded.Result)
+	}
+	if output := recorded.Output(); output.ContentType != "text/plain" || string(output.Content) != "hello" {
+		t.Fatalf("unexpected output: %#v", output)
+	}
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"version": 99}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected unsupported version, got %v", err)
+	}
+}
+
+// record runs session through Record and reads the trace back.
+func record(t *testing.T, session *recordingSession) *Trace {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	writer, err := NewWriter(&out, source.New("demo.fql", demoScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Record(context.Background(), session, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded, err := Read(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return recorded
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// recordingSession replays a fixed program: LET y = 21, a call to double(y),
+// and RETURN double(y).
+type recordingSession struct {
+	events      []*ferret.DebugEvent
+	frames      [][]ferret.DebugFrame
+	locals      [][]ferret.DebugVariable
+	position    int
+	stepErr     error
+	expressions []string
+}
+
+func newRecordingSession() *recordingSession {
+	main := ferret.DebugFrame{Name: "<main>", Location: ferret.DebugLocation{File: "demo.fql", Line: 5}}
+	double := ferret.DebugFrame{Name: "double", Location: ferret.DebugLocation{File: "demo.fql", Line: 2}}
+	y := ferret.DebugVariable{Name: "y", Value: ferret.DebugValue{Display: "21"}}
+
+	return &recordingSession{
+		events: []*ferret.DebugEvent{
+			{Reason: ferret.DebugReasonEntry, Location: ferret.DebugLocation{File: "demo.fql", Line: 4, Column: 1}},
+			{Reason: ferret.DebugReasonStep, Location: ferret.DebugLocation{File: "demo.fql", Line: 5, Column: 1}},
+			{Reason: ferret.DebugReasonStep, Location: ferret.DebugLocation{File: "demo.fql", Line: 2, Column: 3}},
+			{Reason: ferret.DebugReasonStep, Location: ferret.DebugLocation{File: "demo.fql", Line: 5, Column: 1}},
+			{Reason: ferret.DebugReasonCompleted, Output: &encoding.Output{ContentType: "application/json", Content: []byte("42")}},
+		},
+		frames: [][]ferret.DebugFrame{{main}, {main}, {double, main}, {main}},
+		locals: [][]ferret.DebugVariable{
+			nil,
+			{y},
+			{{Name: "x", Value: ferret.DebugValue{Display: "21"}}, {Name: "@limit", Param: true, Value: ferret.DebugValue{Display: "1"}}},
+			{y},
+		},
+	}
+}
+
+func (s *recordingSession) Start(context.Context) (*ferret.DebugEvent, error) {
+	return s.events[0], nil
+}
+
+func (s *recordingSession) Step(context.Context) (*ferret.DebugEvent, error) {
+	if s.stepErr != nil {
+		return nil, s.stepErr
+	}
+
+	s.position++
+	return s.events[s.position], nil
+}
+
+func (s *recordingSession) Continue(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.Step(ctx)
+}
+
+func (s *recordingSession) Next(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.Step(ctx)
+}
+
+func (s *recordingSession) Out(ctx context.Context) (*ferret.DebugEvent, error) {
+	return s.Step(ctx)
+}
+
+func (s *recordingSession) Pause() error {
+	return nil
+}
+
+func (s *recordingSession) SetBreakpointAt(ferret.DebugSourceLocation, ferret.DebugBreakpointOptions) (ferret.DebugBreakpoint, error) {
+	return ferret.DebugBreakpoint{}, nil
+}
+
+func (s *recordingSession) DeleteBreakpoint(ferret.DebugBreakpointID) error {
+	return nil
+}
+
+func (s *recordingSession) Breakpoints() []ferret.DebugBreakpoint {
+	return nil
+}
+
+func (s *recordingSession) Frames() ([]ferret.DebugFrame, error) {
+	return s.frames[s.position], nil
+}
+
+func (s *recordingSession) Locals() ([]ferret.DebugVariable, error) {
+	return s.locals[s.position], nil
+}
+
+func (s *recordingSession) Evaluate(_ context.Context, expression string) (ferret.DebugValue, error) {
+	s.expressions = append(s.expressions, expression)
+	return ferret.DebugValue{Display: "42"}, nil
+}
+
+func (s *recordingSession) Close() error { return nil }
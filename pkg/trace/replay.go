@@ -0,0 +1,264 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MontFerret/ferret/v2"
+	ferruntime "github.com/MontFerret/ferret/v2/pkg/runtime"
+)
+
+// ErrNotPaused indicates an inspection request outside of a recorded step,
+// before the replay started or after it reached the end of the trace.
+var ErrNotPaused = errors.New("replay is not paused at a recorded step")
+
+// Replay implements debugger.Session over a recorded trace, so the debugger
+// prompt can walk through a past execution without re-running the script.
+// Stepping moves between recorded steps; print only looks up recorded
+// variables by name.
+type Replay struct {
+	trace       *Trace
+	position    int
+	breakpoints []ferret.DebugBreakpoint
+	nextID      ferret.DebugBreakpointID
+}
+
+func NewReplay(trace *Trace) *Replay {
+	return &Replay{trace: trace, position: -1}
+}
+
+func (r *Replay) Start(context.Context) (*ferret.DebugEvent, error) {
+	r.position = -1
+
+	return r.advance(func(Step) bool { return true }, "")
+}
+
+func (r *Replay) Continue(context.Context) (*ferret.DebugEvent, error) {
+	return r.advance(func(step Step) bool {
+		return len(r.hits(step)) > 0 || step.Reason == ferret.DebugReasonRuntimeError
+	}, ferret.DebugReasonBreakpoint)
+}
+
+func (r *Replay) Step(context.Context) (*ferret.DebugEvent, error) {
+	return r.advance(func(Step) bool { return true }, ferret.DebugReasonStep)
+}
+
+func (r *Replay) Next(context.Context) (*ferret.DebugEvent, error) {
+	depth := r.depth()
+
+	return r.advance(func(step Step) bool { return len(step.Frames) <= depth }, ferret.DebugReasonStep)
+}
+
+func (r *Replay) Out(context.Context) (*ferret.DebugEvent, error) {
+	depth := r.depth()
+
+	return r.advance(func(step Step) bool { return len(step.Frames) < depth }, ferret.DebugReasonStep)
+}
+
+// Pause has nothing to interrupt, because replay never runs ahead of the
+// prompt.
+func (r *Replay) Pause() error {
+	return nil
+}
+
+// SetBreakpointAt binds the breakpoint to the first recorded location that
+// matches the binding mode.
+func (r *Replay) SetBreakpointAt(location ferret.DebugSourceLocation, options ferret.DebugBreakpointOptions) (ferret.DebugBreakpoint, error) {
+	r.nextID++
+
+	breakpoint := ferret.DebugBreakpoint{
+		ID:              r.nextID,
+		File:            location.File,
+		RequestedLine:   location.Line,
+		RequestedColumn: location.Column,
+		BindingMode:     options.BindingMode,
+	}
+
+	var best *Location
+
+	for i := range r.trace.Steps {
+		candidate := &r.trace.Steps[i].Location
+
+		if candidate.File != location.File || !bindsAt(*candidate, location, options.BindingMode) {
+			continue
+		}
+
+		if best == nil || candidate.Line < best.Line || (candidate.Line == best.Line && candidate.Column < best.Column) {
+			best = candidate
+		}
+	}
+
+	if best != nil {
+		breakpoint.Bound = true
+		breakpoint.Line = best.Line
+		breakpoint.Column = best.Column
+	}
+
+	r.breakpoints = append(r.breakpoints, breakpoint)
+
+	return breakpoint, nil
+}
+
+func (r *Replay) DeleteBreakpoint(id ferret.DebugBreakpointID) error {
+	for i, breakpoint := range r.breakpoints {
+		if breakpoint.ID == id {
+			r.breakpoints = append(r.breakpoints[:i], r.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+
+	return ferruntime.Errorf(ferruntime.ErrNotFound, "breakpoint %d", id)
+}
+
+func (r *Replay) Breakpoints() []ferret.DebugBreakpoint {
+	return append([]ferret.DebugBreakpoint(nil), r.breakpoints...)
+}
+
+func (r *Replay) Frames() ([]ferret.DebugFrame, error) {
+	step, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]ferret.DebugFrame, 0, len(step.Frames))
+
+	for _, frame := range step.Frames {
+		frames = append(frames, ferret.DebugFrame{Name: frame.Name, Location: frame.Location.debugLocation()})
+	}
+
+	return frames, nil
+}
+
+func (r *Replay) Locals() ([]ferret.DebugVariable, error) {
+	step, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+
+	locals := make([]ferret.DebugVariable, 0, len(step.Locals))
+
+	for _, variable := range step.Locals {
+		locals = append(locals, ferret.DebugVariable{
+			Name:  variable.Name,
+			Param: variable.Param,
+			Value: ferret.DebugValue{Display: variable.Value},
+		})
+	}
+
+	return locals, nil
+}
+
+// Evaluate returns the recorded value of a variable. Other expressions cannot
+// be evaluated, because the program state is no longer available.
+func (r *Replay) Evaluate(_ context.Context, expression string) (ferret.DebugValue, error) {
+	step, err := r.current()
+	if err != nil {
+		return ferret.DebugValue{}, err
+	}
+
+	name := strings.TrimSpace(expression)
+
+	for _, variable := range step.Locals {
+		if variable.Name == name {
+			return ferret.DebugValue{Display: variable.Value}, nil
+		}
+	}
+
+	return ferret.DebugValue{}, fmt.Errorf("%q was not recorded at this step; replay can only print recorded variables", name)
+}
+
+func (r *Replay) Close() error {
+	return nil
+}
+
+// advance moves to the next step accepted by stop and reports it with reason,
+// or with the recorded reason when reason is empty. Past the last step it
+// reports how the program ended.
+func (r *Replay) advance(stop func(Step) bool, reason ferret.DebugReason) (*ferret.DebugEvent, error) {
+	for r.position+1 < len(r.trace.Steps) {
+		r.position++
+		step := r.trace.Steps[r.position]
+
+		if !stop(step) {
+			continue
+		}
+
+		return r.event(step, reason), nil
+	}
+
+	r.position = len(r.trace.Steps)
+
+	return r.end(), nil
+}
+
+func (r *Replay) event(step Step, reason ferret.DebugReason) *ferret.DebugEvent {
+	event := &ferret.DebugEvent{Reason: reason, Location: step.Location.debugLocation()}
+
+	if reason == "" || step.Reason == ferret.DebugReasonRuntimeError {
+		event.Reason = step.Reason
+	}
+
+	if event.Reason == ferret.DebugReasonBreakpoint {
+		event.HitBreakpointIDs = r.hits(step)
+	}
+
+	if step.Error != "" {
+		event.Error = errors.New(step.Error)
+	}
+
+	return event
+}
+
+func (r *Replay) end() *ferret.DebugEvent {
+	event := &ferret.DebugEvent{Reason: r.trace.Reason, Output: r.trace.Output()}
+
+	if event.Reason == "" {
+		event.Reason = ferret.DebugReasonCompleted
+	}
+
+	if r.trace.Error != "" {
+		event.Error = errors.New(r.trace.Error)
+	}
+
+	return event
+}
+
+func (r *Replay) hits(step Step) []ferret.DebugBreakpointID {
+	var ids []ferret.DebugBreakpointID
+
+	for _, breakpoint := range r.breakpoints {
+		if breakpoint.Bound && breakpoint.File == step.Location.File && breakpoint.Line == step.Location.Line &&
+			(breakpoint.Column == 0 || breakpoint.Column == step.Location.Column) {
+			ids = append(ids, breakpoint.ID)
+		}
+	}
+
+	return ids
+}
+
+func (r *Replay) current() (Step, error) {
+	if r.position < 0 || r.position >= len(r.trace.Steps) {
+		return Step{}, ErrNotPaused
+	}
+
+	return r.trace.Steps[r.position], nil
+}
+
+func (r *Replay) depth() int {
+	step, err := r.current()
+	if err != nil {
+		return 0
+	}
+
+	return len(step.Frames)
+}
+
+func bindsAt(candidate Location, requested ferret.DebugSourceLocation, mode ferret.DebugBreakpointBindingMode) bool {
+	if mode == ferret.DebugBreakpointBindExact {
+		return candidate.Line == requested.Line && (requested.Column == 0 || candidate.Column == requested.Column)
+	}
+
+	return candidate.Line > requested.Line || (candidate.Line == requested.Line && candidate.Column >= requested.Column)
+}
@@ -0,0 +1,76 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MontFerret/ferret/v2"
+	ferruntime "github.com/MontFerret/ferret/v2/pkg/runtime"
+)
+
+func TestReplayWalksRecordedSteps(t *testing.T) {
+	ctx := context.Background()
+	recorded := record(t, newRecordingSession())
+
+	replay := NewReplay(recorded)
+
+	if _, err := replay.Locals(); !errors.Is(err, ErrNotPaused) {
+		t.Fatalf("expected not paused before start, got %v", err)
+	}
+
+	event, err := replay.Start(ctx)
+	if err != nil || event.Reason != ferret.DebugReasonEntry || event.Location.Line != 4 {
+		t.Fatalf("unexpected start event: %#v, %v", event, err)
+	}
+
+	breakpoint, err := replay.SetBreakpointAt(ferret.DebugSourceLocation{File: "demo.fql", Line: 1}, ferret.DebugBreakpointOptions{})
+	if err != nil || !breakpoint.Bound || breakpoint.Line != 2 || breakpoint.Column != 3 {
+		t.Fatalf("unexpected breakpoint: %#v, %v", breakpoint, err)
+	}
+
+	if unbound, _ := replay.SetBreakpointAt(ferret.DebugSourceLocation{File: "demo.fql", Line: 3}, ferret.DebugBreakpointOptions{BindingMode: ferret.DebugBreakpointBindExact}); unbound.Bound {
+		t.Fatalf("expected exact breakpoint on an unrecorded line to stay unbound: %#v", unbound)
+	}
+
+	event, _ = replay.Continue(ctx)
+	if event.Reason != ferret.DebugReasonBreakpoint || event.Location.Line != 2 || len(event.HitBreakpointIDs) != 1 || event.HitBreakpointIDs[0] != breakpoint.ID {
+		t.Fatalf("unexpected breakpoint event: %#v", event)
+	}
+
+	frames, _ := replay.Frames()
+	if len(frames) != 2 || frames[0].Name != "double" {
+		t.Fatalf("unexpected frames: %#v", frames)
+	}
+
+	value, err := replay.Evaluate(ctx, " x ")
+	if err != nil || value.Display != "21" {
+		t.Fatalf("unexpected evaluation: %#v, %v", value, err)
+	}
+	if _, err := replay.Evaluate(ctx, "x + 1"); err == nil || !strings.Contains(err.Error(), "replay can only print recorded variables") {
+		t.Fatalf("expected unrecorded expression error, got %v", err)
+	}
+
+	event, _ = replay.Out(ctx)
+	if event.Reason != ferret.DebugReasonStep || event.Location.Line != 5 {
+		t.Fatalf("unexpected step out event: %#v", event)
+	}
+
+	event, _ = replay.Next(ctx)
+	if event.Reason != ferret.DebugReasonCompleted || event.Output == nil || string(event.Output.Content) != "42" {
+		t.Fatalf("unexpected completion event: %#v", event)
+	}
+
+	if err := replay.DeleteBreakpoint(breakpoint.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := replay.DeleteBreakpoint(breakpoint.ID); !errors.Is(err, ferruntime.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	event, _ = replay.Start(ctx)
+	if event.Location.Line != 4 {
+		t.Fatalf("expected start to rewind, got %#v", event)
+	}
+}
@@ -0,0 +1,181 @@
+package trace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+// Version is the trace file format written by Record.
+const Version = 2
+
+// ErrUnsupportedVersion indicates a trace file written by an incompatible
+// version of the CLI.
+var ErrUnsupportedVersion = errors.New("unsupported trace version")
+
+type (
+	// Trace is a recorded execution of a script: every pause point the
+	// debugger engine exposed, in order, and how the program ended. It embeds
+	// the source so it can be replayed without the original script.
+	Trace struct {
+		Version int    `json:"version"`
+		Name    string `json:"name"`
+		Source  string `json:"source"`
+		Steps   []Step `json:"steps"`
+		// Reason is how the program ended, either completed or terminated.
+		Reason      ferret.DebugReason `json:"reason"`
+		ContentType string             `json:"contentType,omitempty"`
+		// Result is the program output. Output that is not valid JSON is
+		// stored as a JSON string.
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  string          `json:"error,omitempty"`
+	}
+
+	// Step is a single pause point.
+	Step struct {
+		Reason   ferret.DebugReason `json:"reason"`
+		Location Location           `json:"location"`
+		// Frames is the call stack, innermost first.
+		Frames []Frame `json:"frames,omitempty"`
+		// Locals are the variables of the innermost frame.
+		Locals []Variable `json:"locals,omitempty"`
+		// Call is set on the first step inside a user-defined function.
+		Call *Call `json:"call,omitempty"`
+		// Return is set on the first step after such a function returned.
+		Return *Return `json:"return,omitempty"`
+		Error  string  `json:"error,omitempty"`
+	}
+
+	Location struct {
+		File   string `json:"file"`
+		Line   int    `json:"line,omitempty"`
+		Column int    `json:"column,omitempty"`
+		Start  int    `json:"start,omitempty"`
+		End    int    `json:"end,omitempty"`
+	}
+
+	Frame struct {
+		Name     string   `json:"name"`
+		Location Location `json:"location"`
+	}
+
+	Variable struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Param bool   `json:"param,omitempty"`
+	}
+
+	// Call records a user-defined function entered at a step, detected by
+	// the call stack growing. The engine reports neither calls nor their
+	// arguments, so calls to builtin functions such as DOCUMENT are never
+	// recorded, and EntryLocals is a best-effort stand-in for the arguments:
+	// the variables of the new frame when it was first seen.
+	Call struct {
+		Function    string     `json:"function"`
+		EntryLocals []Variable `json:"entryLocals,omitempty"`
+	}
+
+	// Return records a user-defined function that returned before a step,
+	// inferred from the call stack shrinking. The engine does not report
+	// return values, so none is recorded.
+	Return struct {
+		Function string `json:"function"`
+	}
+)
+
+// Read decodes a trace and checks its version.
+func Read(r io.Reader) (*Trace, error) {
+	var trace Trace
+
+	if err := json.NewDecoder(r).Decode(&trace); err != nil {
+		return nil, fmt.Errorf("decode trace: %w", err)
+	}
+
+	if trace.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, trace.Version)
+	}
+
+	return &trace, nil
+}
+
+// Load reads the trace stored at path.
+func Load(path string) (*Trace, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	trace, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return trace, nil
+}
+
+// SourceFile returns the embedded script.
+func (t *Trace) SourceFile() *source.Source {
+	return source.New(t.Name, t.Source)
+}
+
+// Output returns the recorded program output, or nil when the program did
+// not complete.
+func (t *Trace) Output() *encoding.Output {
+	if len(t.Result) == 0 {
+		return nil
+	}
+
+	content := []byte(t.Result)
+
+	var text string
+	if json.Unmarshal(t.Result, &text) == nil && !isJSONContentType(t.ContentType) {
+		content = []byte(text)
+	}
+
+	return &encoding.Output{ContentType: t.ContentType, Content: content}
+}
+
+func (t *Trace) setOutput(output *encoding.Output) {
+	if output == nil {
+		return
+	}
+
+	t.ContentType = output.ContentType
+
+	if isJSONContentType(output.ContentType) && json.Valid(output.Content) {
+		t.Result = append(json.RawMessage(nil), output.Content...)
+		return
+	}
+
+	t.Result, _ = json.Marshal(string(output.Content))
+}
+
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || contentType == "application/json"
+}
+
+func newLocation(location ferret.DebugLocation) Location {
+	return Location{
+		File:   location.File,
+		Line:   location.Line,
+		Column: location.Column,
+		Start:  location.Span.Start,
+		End:    location.Span.End,
+	}
+}
+
+func (l Location) debugLocation() ferret.DebugLocation {
+	return ferret.DebugLocation{
+		File:   l.File,
+		Line:   l.Line,
+		Column: l.Column,
+		Span:   source.Span{Start: l.Start, End: l.End},
+	}
+}
@@ -0,0 +1,149 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MontFerret/ferret/v2"
+	"github.com/MontFerret/ferret/v2/pkg/encoding"
+	"github.com/MontFerret/ferret/v2/pkg/source"
+)
+
+// Writer streams a trace as it is recorded, so the steps of a long run are
+// never all held in memory. The output is the same JSON document Read
+// decodes.
+type Writer struct {
+	out   *bufio.Writer
+	file  *os.File
+	path  string
+	steps int
+	err   error
+}
+
+type (
+	header struct {
+		Version int    `json:"version"`
+		Name    string `json:"name"`
+		Source  string `json:"source"`
+	}
+
+	trailer struct {
+		Reason      ferret.DebugReason `json:"reason"`
+		ContentType string             `json:"contentType,omitempty"`
+		Result      json.RawMessage    `json:"result,omitempty"`
+		Error       string             `json:"error,omitempty"`
+	}
+)
+
+// NewWriter starts a trace of src on w.
+func NewWriter(w io.Writer, src *source.Source) (*Writer, error) {
+	writer := &Writer{out: bufio.NewWriter(w)}
+
+	data, err := json.Marshal(header{Version: Version, Name: src.Name(), Source: src.Content()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reopen the header object so the steps follow as its array field.
+	writer.write(data[:len(data)-1])
+	writer.write([]byte(`,"steps":[`))
+
+	if writer.err != nil {
+		return nil, writer.err
+	}
+
+	return writer, nil
+}
+
+// Create starts a trace of src at path. It is written to a temporary file
+// first and moved into place by Finish, so an interrupted run never leaves a
+// truncated trace behind.
+func Create(path string, src *source.Source) (*Writer, error) {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := NewWriter(temp, src)
+	if err != nil {
+		return nil, errors.Join(err, temp.Close(), os.Remove(temp.Name()))
+	}
+
+	writer.file = temp
+	writer.path = path
+
+	return writer, nil
+}
+
+// WriteStep appends a step to the trace.
+func (w *Writer) WriteStep(step Step) error {
+	data, err := json.Marshal(step)
+	if err != nil {
+		return err
+	}
+
+	if w.steps > 0 {
+		w.write([]byte{','})
+	}
+
+	w.write([]byte{'\n'})
+	w.write(data)
+	w.steps++
+
+	return w.err
+}
+
+// Finish records how the program ended and completes the trace. A trace
+// started by Create is moved into place, or removed when writing failed.
+func (w *Writer) Finish(reason ferret.DebugReason, output *encoding.Output, errText string) error {
+	ending := Trace{Reason: reason, Error: errText}
+	ending.setOutput(output)
+
+	data, err := json.Marshal(trailer{
+		Reason:      ending.Reason,
+		ContentType: ending.ContentType,
+		Result:      ending.Result,
+		Error:       ending.Error,
+	})
+	if err != nil {
+		w.err = errors.Join(w.err, err)
+	} else {
+		w.write([]byte("\n],"))
+		w.write(data[1:])
+		w.write([]byte{'\n'})
+	}
+
+	if w.err == nil {
+		w.err = w.out.Flush()
+	}
+
+	if w.file == nil {
+		return w.err
+	}
+
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+
+	if w.err != nil {
+		return errors.Join(w.err, os.Remove(w.file.Name()))
+	}
+
+	if err := os.Rename(w.file.Name(), w.path); err != nil {
+		return errors.Join(err, os.Remove(w.file.Name()))
+	}
+
+	return nil
+}
+
+func (w *Writer) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = w.out.Write(data)
+}
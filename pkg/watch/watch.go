@@ -0,0 +1,202 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/MontFerret/cli/v2/pkg/source"
+)
+
+// Debounce is how long the watched files must stay unchanged before Wait
+// returns, so that one save, which editors often perform as several writes
+// and renames, triggers a single re-run.
+var Debounce = 100 * time.Millisecond
+
+var ErrClosed = errors.New("watcher closed")
+
+// Watcher reports changes to a set of files and directory trees.
+type Watcher struct {
+	notify *fsnotify.Watcher
+	// files are watched through their parent directory, which keeps them
+	// watched when an editor replaces them with a new file.
+	files map[string]struct{}
+	// roots are watched with every directory below them.
+	roots []string
+	// ignored are paths whose changes, and changes below them, are not
+	// reported.
+	ignored []string
+}
+
+// New watches paths. A file is watched by name and a directory with
+// everything below it, except hidden entries.
+func New(paths []string) (*Watcher, error) {
+	notify, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, fmt.Errorf("watch: %w", err)
+	}
+
+	w := &Watcher{notify: notify, files: make(map[string]struct{})}
+
+	for _, path := range paths {
+		if err := w.add(path); err != nil {
+			_ = notify.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *Watcher) Close() error {
+	return w.notify.Close()
+}
+
+// Ignore excludes paths, and everything below them, from the changes Wait
+// reports. It is meant for files the watching process writes itself, such as
+// its output, which would otherwise trigger another run after every run.
+func (w *Watcher) Ignore(paths ...string) error {
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+
+		if err != nil {
+			return fmt.Errorf("watch: ignore %s: %w", path, err)
+		}
+
+		w.ignored = append(w.ignored, abs)
+	}
+
+	return nil
+}
+
+// Wait blocks until a watched file changed and no further change followed
+// for Debounce. It returns the changed paths, sorted.
+func (w *Watcher) Wait(ctx context.Context) ([]string, error) {
+	changed := make(map[string]struct{})
+	var quiet <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return nil, ErrClosed
+			}
+
+			return nil, fmt.Errorf("watch: %w", err)
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return nil, ErrClosed
+			}
+
+			if !w.relevant(event) {
+				continue
+			}
+
+			// New directories inside a watched tree are watched too.
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = w.addTree(event.Name)
+				}
+			}
+
+			changed[event.Name] = struct{}{}
+			quiet = time.After(Debounce)
+		case <-quiet:
+			paths := make([]string, 0, len(changed))
+
+			for path := range changed {
+				paths = append(paths, path)
+			}
+
+			sort.Strings(paths)
+
+			return paths, nil
+		}
+	}
+}
+
+func (w *Watcher) add(path string) error {
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", path, err)
+	}
+
+	info, err := os.Stat(abs)
+
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		w.roots = append(w.roots, abs)
+
+		return w.addTree(abs)
+	}
+
+	w.files[abs] = struct{}{}
+
+	if err := w.notify.Add(filepath.Dir(abs)); err != nil {
+		return fmt.Errorf("watch %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (w *Watcher) addTree(root string) error {
+	return source.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if err := w.notify.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+// relevant reports whether event changed the content of a watched file.
+func (w *Watcher) relevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	for _, ignored := range w.ignored {
+		if event.Name == ignored || strings.HasPrefix(event.Name, ignored+string(filepath.Separator)) {
+			return false
+		}
+	}
+
+	if _, ok := w.files[event.Name]; ok {
+		return true
+	}
+
+	if source.Hidden(filepath.Base(event.Name)) || strings.HasSuffix(event.Name, "~") {
+		return false
+	}
+
+	for _, root := range w.roots {
+		if strings.HasPrefix(event.Name, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherReportsFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	script := writeFile(t, filepath.Join(dir, "script.fql"), "RETURN 1")
+	writeFile(t, filepath.Join(dir, "other.fql"), "RETURN 2")
+
+	w := newWatcher(t, script)
+
+	// Changes to neighbours of a watched file are ignored.
+	writeFile(t, filepath.Join(dir, "other.fql"), "RETURN 3")
+	writeFile(t, script, "RETURN 4")
+	expectChanges(t, w, script)
+
+	// Editors often save by renaming a new file over the old one.
+	temp := writeFile(t, filepath.Join(dir, ".script.fql.swp"), "RETURN 5")
+	if err := os.Rename(temp, script); err != nil {
+		t.Fatal(err)
+	}
+
+	expectChanges(t, w, script)
+}
+
+func TestWatcherReportsChangesBelowDirectories(t *testing.T) {
+	root := t.TempDir()
+	w := newWatcher(t, root)
+
+	nested := filepath.Join(root, "fixtures")
+	if err := os.Mkdir(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	expectChanges(t, w, nested)
+
+	page := writeFile(t, filepath.Join(nested, "page.html"), "<html></html>")
+	writeFile(t, filepath.Join(root, ".hidden"), "ignored")
+	expectChanges(t, w, page)
+}
+
+func TestWatcherIgnoresExcludedPaths(t *testing.T) {
+	root := t.TempDir()
+	recordings := filepath.Join(root, "recordings")
+	if err := os.Mkdir(recordings, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newWatcher(t, root)
+	result := filepath.Join(root, "result.json")
+
+	if err := w.Ignore(result, recordings); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, result, "{}")
+	writeFile(t, filepath.Join(recordings, "page.har"), "{}")
+	page := writeFile(t, filepath.Join(root, "page.html"), "<html></html>")
+	expectChanges(t, w, page)
+}
+
+func TestWatcherWaitStopsWithContext(t *testing.T) {
+	w := newWatcher(t, t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := w.Wait(ctx); err != context.Canceled {
+		t.Fatalf("expected context error, got %v", err)
+	}
+}
+
+func newWatcher(t *testing.T, paths ...string) *Watcher {
+	t.Helper()
+
+	w, err := New(paths)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = w.Close()
+	})
+
+	return w
+}
+
+func expectChanges(t *testing.T, w *Watcher, want ...string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changed, err := w.Wait(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(changed, want) {
+		t.Fatalf("expected changes %v, got %v", want, changed)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}